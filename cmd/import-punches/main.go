@@ -0,0 +1,72 @@
+// Command import-punches loads a punch export from an RFID or biometric
+// terminal into the database and applies it to attendance, for files too
+// large for the upload endpoint or for scheduled imports.
+//
+//	go run ./cmd/import-punches -file attlog.dat -format log -terminal LAB-1
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"attendance-workflow/internal/punches"
+	"attendance-workflow/pkg/config"
+	"attendance-workflow/pkg/db"
+)
+
+// batchSize keeps each processing pass and its lookups small
+const batchSize = 1000
+
+func main() {
+	path := flag.String("file", "", "punch file to import")
+	format := flag.String("format", punches.FormatCSV, "file format: csv or log")
+	terminal := flag.String("terminal", "", "terminal code for records without one")
+	flag.Parse()
+
+	if *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config.Load()
+
+	if err := db.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := db.AutoMigrate(); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *path, err)
+	}
+	defer file.Close()
+
+	raw, err := punches.Parse(file, *format, *terminal)
+	if err != nil {
+		log.Fatalf("Failed to parse %s: %v", *path, err)
+	}
+	if len(raw) == 0 {
+		log.Fatalf("%s: %v", *path, punches.ErrNoPunches)
+	}
+
+	processor := punches.NewProcessor(db.DB)
+	totals := make(map[string]int)
+	for start := 0; start < len(raw); start += batchSize {
+		end := min(start+batchSize, len(raw))
+		summary, err := processor.Process(raw[start:end], 0)
+		for result, count := range summary.Results {
+			totals[string(result)] += count
+		}
+		if err != nil {
+			log.Fatalf("Import stopped after %d punches: %v", start+len(summary.Punches), err)
+		}
+		log.Printf("Processed %d/%d punches", end, len(raw))
+	}
+
+	fmt.Printf("Imported %d punches: %d applied, %d ignored, %d duplicate, %d unmapped\n",
+		len(raw), totals["applied"], totals["ignored"], totals["duplicate"], totals["unmapped"])
+}
@@ -2,18 +2,33 @@ package analytics
 
 import (
 	"net/http"
+	"time"
 
+	"attendance-workflow/internal/calendar"
 	"attendance-workflow/pkg/db"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type AnalyticsHandler struct {
-	DB db.GormDB
+	DB       db.GormDB
+	Calendar *calendar.Service
 }
 
 func NewAnalyticsHandler() *AnalyticsHandler {
-	return &AnalyticsHandler{DB: db.DB}
+	return &AnalyticsHandler{DB: db.DB, Calendar: calendar.NewService(db.DB)}
+}
+
+// excludeDays is a scope that drops attendance on the given dates, used to
+// keep holidays and weekly offs out of attendance denominators
+func excludeDays(days []time.Time) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if len(days) == 0 {
+			return tx
+		}
+		return tx.Where("attendances.date NOT IN ?", days)
+	}
 }
 
 func (h *AnalyticsHandler) GetDashboardStats(c *gin.Context) {
@@ -34,7 +49,7 @@ func (h *AnalyticsHandler) GetDashboardStats(c *gin.Context) {
 	var pending int64
 	var approved int64
 	var rejected int64
-	h.DB.Model(&db.LeaveRequest{}).Where("status = ?", db.StatusPending).Count(&pending)
+	h.DB.Model(&db.LeaveRequest{}).Where("status IN ?", db.LeaveAwaitingDecision).Count(&pending)
 	h.DB.Model(&db.LeaveRequest{}).Where("status = ?", db.StatusApproved).Count(&approved)
 	h.DB.Model(&db.LeaveRequest{}).Where("status = ?", db.StatusRejected).Count(&rejected)
 
@@ -85,30 +100,59 @@ func (h *AnalyticsHandler) GetDepartmentStats(c *gin.Context) {
 	var students int64
 	h.DB.Model(&db.User{}).Where("role = ? AND dept = ?", db.RoleStudent, dept).Count(&students)
 
-	// Total attendance
-	var presentDays int64
-	var totalDays int64
+	// Only working days of the department count
+	var bounds struct {
+		First *time.Time
+		Last  *time.Time
+	}
 	h.DB.Table("attendances").
+		Select("MIN(attendances.date) AS first, MAX(attendances.date) AS last").
 		Joins("INNER JOIN users ON users.id = attendances.student_id").
-		Where("users.dept = ? AND attendances.present = ?", dept, true).
-		Count(&presentDays)
+		Where("users.dept = ?", dept).
+		Scan(&bounds)
+	var nonWorking []time.Time
+	if bounds.First != nil && bounds.Last != nil {
+		nonWorking = h.Calendar.NonWorkingDays(dept, *bounds.First, *bounds.Last)
+	}
+
+	// Total attendance, weighted by status
+	var totals struct {
+		PresentDays float64
+		TotalDays   int64
+	}
+	h.DB.Table("attendances").
+		Select("COALESCE(SUM("+db.AttendanceWeightSQL("attendances.status")+"), 0) AS present_days, COUNT(*) AS total_days").
+		Joins("INNER JOIN users ON users.id = attendances.student_id").
+		Where("users.dept = ?", dept).
+		Scopes(excludeDays(nonWorking)).
+		Scan(&totals)
+
+	type StatusCount struct {
+		Status string `json:"status"`
+		Count  int64  `json:"count"`
+	}
+	var byStatus []StatusCount
 	h.DB.Table("attendances").
+		Select("attendances.status, COUNT(*) AS count").
 		Joins("INNER JOIN users ON users.id = attendances.student_id").
 		Where("users.dept = ?", dept).
-		Count(&totalDays)
+		Scopes(excludeDays(nonWorking)).
+		Group("attendances.status").
+		Scan(&byStatus)
 
 	var percentage float64
-	if totalDays > 0 {
-		percentage = float64(presentDays) / float64(totalDays) * 100
+	if totals.TotalDays > 0 {
+		percentage = totals.PresentDays / float64(totals.TotalDays) * 100
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"department": dept,
 		"students":   students,
 		"attendance": gin.H{
-			"present_days":          presentDays,
-			"total_days":            totalDays,
+			"present_days":          totals.PresentDays,
+			"total_days":            totals.TotalDays,
 			"attendance_percentage": percentage,
+			"by_status":             byStatus,
 		},
 	})
 }
@@ -124,7 +168,7 @@ func (h *AnalyticsHandler) GetFrequentAbsentees(c *gin.Context) {
 	h.DB.Table("attendances").
 		Select("attendances.student_id, users.name, count(*) as absent_days").
 		Joins("INNER JOIN users ON users.id = attendances.student_id").
-		Where("attendances.present = ?", false).
+		Where("attendances.status = ?", db.AttendanceAbsent).
 		Group("attendances.student_id, users.name").
 		Order("absent_days DESC").
 		Limit(10).
@@ -0,0 +1,136 @@
+package analytics
+
+import (
+	"net/http"
+	"time"
+
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// punctualitySelect aggregates arrivals: attendance that is present or late.
+// Average lateness only counts late arrivals with a recorded check-in time.
+const punctualitySelect = "COUNT(*) AS arrivals, " +
+	"SUM(CASE WHEN attendances.status = 'present' THEN 1 ELSE 0 END) AS on_time, " +
+	"SUM(CASE WHEN attendances.status = 'late' THEN 1 ELSE 0 END) AS late, " +
+	"COALESCE(AVG(CASE WHEN attendances.status = 'late' AND attendances.checked_in_at IS NOT NULL THEN attendances.minutes_late END), 0) AS average_minutes_late"
+
+type PunctualityStats struct {
+	Arrivals           int64   `json:"arrivals"`
+	OnTime             int64   `json:"on_time"`
+	Late               int64   `json:"late"`
+	Percentage         float64 `json:"punctuality_percentage"`
+	AverageMinutesLate float64 `json:"average_minutes_late"`
+}
+
+func (p *PunctualityStats) rate() {
+	if p.Arrivals > 0 {
+		p.Percentage = float64(p.OnTime) / float64(p.Arrivals) * 100
+	}
+}
+
+// GetPunctuality godoc
+// @Summary      Punctuality analytics
+// @Description  On-time and late arrivals overall, by course and for the students most often late (admin only)
+// @Tags         analytics
+// @Produce      json
+// @Security     BearerAuth
+// @Param        start_date  query     string  false  "Start date (YYYY-MM-DD), defaults to 30 days ago"
+// @Param        end_date    query     string  false  "End date (YYYY-MM-DD), defaults to today"
+// @Param        dept        query     string  false  "Department"
+// @Param        course_id   query     int     false  "Course ID"
+// @Success      200         {object}  object{overall=PunctualityStats,by_course=array,latecomers=array}
+// @Failure      400         {object}  object{error=string}
+// @Router       /analytics/punctuality [get]
+func (h *AnalyticsHandler) GetPunctuality(c *gin.Context) {
+	endDate := clock.Today()
+	startDate := endDate.AddDate(0, 0, -30)
+	if value := c.Query("start_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+			return
+		}
+		startDate = parsed
+	}
+	if value := c.Query("end_date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+			return
+		}
+		endDate = parsed
+	}
+	dept, courseID := c.Query("dept"), c.Query("course_id")
+
+	arrivals := func() *gorm.DB {
+		query := h.DB.Table("attendances").
+			Joins("INNER JOIN users ON users.id = attendances.student_id").
+			Where("attendances.status IN ?", []db.AttendanceStatus{db.AttendancePresent, db.AttendanceLate}).
+			Where("attendances.date BETWEEN ? AND ?", startDate, endDate)
+		if dept != "" {
+			query = query.Where("users.dept = ?", dept)
+		}
+		if courseID != "" {
+			query = query.Joins("INNER JOIN class_sessions ON class_sessions.id = attendances.session_id").
+				Where("class_sessions.course_id = ?", courseID)
+		}
+		return query
+	}
+
+	var overall PunctualityStats
+	arrivals().Select(punctualitySelect).Scan(&overall)
+	overall.rate()
+
+	type CoursePunctuality struct {
+		CourseID uint   `json:"course_id"`
+		Code     string `json:"code"`
+		Name     string `json:"name"`
+		PunctualityStats
+	}
+	var byCourse []CoursePunctuality
+	courseQuery := arrivals()
+	if courseID == "" {
+		courseQuery = courseQuery.Joins("INNER JOIN class_sessions ON class_sessions.id = attendances.session_id")
+	}
+	courseQuery.
+		Select("courses.id AS course_id, courses.code, courses.name, " + punctualitySelect).
+		Joins("INNER JOIN courses ON courses.id = class_sessions.course_id").
+		Group("courses.id, courses.code, courses.name").
+		Order("courses.code").
+		Scan(&byCourse)
+	for i := range byCourse {
+		byCourse[i].rate()
+	}
+
+	type Latecomer struct {
+		StudentID uint   `json:"student_id"`
+		Name      string `json:"name"`
+		Dept      string `json:"dept"`
+		PunctualityStats
+	}
+	var latecomers []Latecomer
+	arrivals().
+		Select("users.id AS student_id, users.name, users.dept, " + punctualitySelect).
+		Group("users.id, users.name, users.dept").
+		Having("SUM(CASE WHEN attendances.status = 'late' THEN 1 ELSE 0 END) > 0").
+		Order("late DESC, average_minutes_late DESC").
+		Limit(10).
+		Scan(&latecomers)
+	for i := range latecomers {
+		latecomers[i].rate()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period": gin.H{
+			"start_date": startDate.Format("2006-01-02"),
+			"end_date":   endDate.Format("2006-01-02"),
+		},
+		"overall":    overall,
+		"by_course":  byCourse,
+		"latecomers": latecomers,
+	})
+}
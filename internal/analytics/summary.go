@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"attendance-workflow/pkg/clock"
 	"attendance-workflow/pkg/db"
 
 	"github.com/gin-gonic/gin"
@@ -30,7 +31,7 @@ type MonthlyLeaveSummary struct {
 
 func (h *AnalyticsHandler) GetSummary(c *gin.Context) {
 	// Get date range from query params with defaults
-	endDate := time.Now()
+	endDate := clock.Today()
 	startDate := endDate.AddDate(0, -6, 0) // Last 6 months by default
 
 	if startDateStr := c.Query("start_date"); startDateStr != "" {
@@ -51,10 +52,16 @@ func (h *AnalyticsHandler) GetSummary(c *gin.Context) {
 	}
 	var deptAttendance []DeptAttendance
 
+	weight := db.AttendanceWeightSQL("attendances.status")
+
+	// Institution-wide non-working days are left out of every rate
+	nonWorking := h.Calendar.NonWorkingDays("", startDate, endDate)
+
 	h.DB.Table("attendances").
-		Select("users.dept, (COUNT(CASE WHEN attendances.present THEN 1 END) * 100.0 / COUNT(*)) as attendance_sum").
+		Select("users.dept, (SUM("+weight+") * 100.0 / COUNT(*)) as attendance_sum").
 		Joins("INNER JOIN users ON users.id = attendances.student_id").
 		Where("attendances.date BETWEEN ? AND ?", startDate, endDate).
+		Scopes(excludeDays(nonWorking)).
 		Where("users.dept IS NOT NULL").
 		Group("users.dept").
 		Scan(&deptAttendance)
@@ -66,8 +73,9 @@ func (h *AnalyticsHandler) GetSummary(c *gin.Context) {
 	}
 
 	h.DB.Table("attendances").
-		Select("DATE_FORMAT(date, '%Y-%m') as month, (COUNT(CASE WHEN present THEN 1 END) * 100.0 / COUNT(*)) as attendance_sum").
-		Where("date BETWEEN ? AND ?", startDate, endDate).
+		Select("TO_CHAR(attendances.date, 'YYYY-MM') as month, (SUM("+weight+") * 100.0 / COUNT(*)) as attendance_sum").
+		Where("attendances.date BETWEEN ? AND ?", startDate, endDate).
+		Scopes(excludeDays(nonWorking)).
 		Group("month").
 		Order("month").
 		Scan(&monthlyAttendance)
@@ -90,7 +98,7 @@ func (h *AnalyticsHandler) GetSummary(c *gin.Context) {
 		Scan(&leavesByStatus)
 
 	h.DB.Model(&db.LeaveRequest{}).
-		Select("DATE_FORMAT(created_at, '%Y-%m') as month, COUNT(*) as count").
+		Select("TO_CHAR(created_at, 'YYYY-MM') as month, COUNT(*) as count").
 		Where("created_at BETWEEN ? AND ?", startDate, endDate).
 		Group("month").
 		Order("month").
@@ -104,13 +112,15 @@ func (h *AnalyticsHandler) GetSummary(c *gin.Context) {
 		Rate      float64 `json:"absence_rate"`
 	}
 	var absenteeTrends []AbsenteeTrend
+	absenceRate := "((COUNT(*) - SUM(" + weight + ")) * 100.0 / COUNT(*))"
 
 	h.DB.Table("attendances").
-		Select("users.id as student_id, users.name, users.dept, (COUNT(CASE WHEN NOT attendances.present THEN 1 END) * 100.0 / COUNT(*)) as rate").
+		Select("users.id as student_id, users.name, users.dept, "+absenceRate+" as rate").
 		Joins("INNER JOIN users ON users.id = attendances.student_id").
 		Where("attendances.date BETWEEN ? AND ?", startDate, endDate).
+		Scopes(excludeDays(nonWorking)).
 		Group("users.id, users.name, users.dept").
-		Having("rate > ?", 25.0). // Students with >25% absence rate
+		Having(absenceRate+" > ?", 25.0). // Students with >25% absence rate
 		Order("rate DESC").
 		Limit(10).
 		Scan(&absenteeTrends)
@@ -4,8 +4,16 @@ import (
 	"attendance-workflow/internal/analytics"
 	"attendance-workflow/internal/attendance"
 	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/calendar"
+	"attendance-workflow/internal/condonation"
+	"attendance-workflow/internal/courses"
+	"attendance-workflow/internal/eligibility"
+	"attendance-workflow/internal/files"
+	"attendance-workflow/internal/hostel"
 	"attendance-workflow/internal/leaves"
 	"attendance-workflow/internal/notifications"
+	"attendance-workflow/internal/punches"
+	"attendance-workflow/internal/timetable"
 	"attendance-workflow/internal/users"
 
 	"github.com/gin-gonic/gin"
@@ -59,6 +67,14 @@ func SetupRoutes() *gin.Engine {
 	attendanceHandler := attendance.NewAttendanceHandler()
 	notificationHandler := notifications.NewNotificationHandler()
 	analyticsHandler := analytics.NewAnalyticsHandler()
+	fileHandler := files.NewFileHandler()
+	courseHandler := courses.NewCourseHandler()
+	calendarHandler := calendar.NewCalendarHandler()
+	punchHandler := punches.NewPunchHandler()
+	eligibilityHandler := eligibility.NewEligibilityHandler()
+	condonationHandler := condonation.NewCondonationHandler()
+	hostelHandler := hostel.NewHostelHandler()
+	timetableHandler := timetable.NewTimetableHandler()
 
 	// Public routes
 	v1 := router.Group("/api/v1")
@@ -68,6 +84,9 @@ func SetupRoutes() *gin.Engine {
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
 		}
+
+		// Signed download links carry their own authorization
+		v1.GET("/files/:id/download", fileHandler.Download)
 	}
 
 	// Protected routes
@@ -79,6 +98,8 @@ func SetupRoutes() *gin.Engine {
 		{
 			usersGroup.GET("", auth.RoleMiddleware("admin"), userHandler.GetAllUsers)
 			usersGroup.GET("/:id", userHandler.GetUserByID)
+			usersGroup.GET("/:id/photo", fileHandler.GetUserPhoto)
+			usersGroup.DELETE("/:id/device", auth.RoleMiddleware("admin"), attendanceHandler.ResetDevice)
 			usersGroup.PUT("/:id", userHandler.UpdateUser)
 			usersGroup.DELETE("/:id", auth.RoleMiddleware("admin"), userHandler.DeleteUser)
 		}
@@ -88,19 +109,139 @@ func SetupRoutes() *gin.Engine {
 		{
 			leavesGroup.POST("/apply", auth.RoleMiddleware("student"), leaveHandler.ApplyLeave)
 			leavesGroup.GET("/my", leaveHandler.GetMyLeaves)
-			leavesGroup.GET("/pending", auth.RoleMiddleware("faculty", "warden", "admin"), leaveHandler.GetPendingLeaves)
-			leavesGroup.PUT("/:id/approve", auth.RoleMiddleware("faculty", "warden", "admin"), leaveHandler.ApproveLeave)
+			leavesGroup.GET("/pending", auth.RoleMiddleware("faculty", "hod", "warden", "admin"), leaveHandler.GetPendingLeaves)
+			leavesGroup.PUT("/:id/approve", auth.RoleMiddleware("faculty", "hod", "warden", "admin"), leaveHandler.ApproveLeave)
+			leavesGroup.PUT("/:id", auth.RoleMiddleware("student"), leaveHandler.UpdateLeave)
+			leavesGroup.PUT("/:id/submit", auth.RoleMiddleware("student"), leaveHandler.SubmitLeave)
+			leavesGroup.PUT("/:id/withdraw", auth.RoleMiddleware("student"), leaveHandler.WithdrawLeave)
+			leavesGroup.PUT("/:id/cancel", auth.RoleMiddleware("student"), leaveHandler.RequestCancellation)
+			leavesGroup.PUT("/:id/cancellation", auth.RoleMiddleware("faculty", "hod", "warden", "admin"), leaveHandler.ConfirmCancellation)
+			leavesGroup.PUT("/:id/revoke", auth.RoleMiddleware("faculty", "warden", "admin"), leaveHandler.RevokeLeave)
 			leavesGroup.GET("", auth.RoleMiddleware("admin"), leaveHandler.GetAllLeaves)
 			leavesGroup.DELETE("/:id", auth.RoleMiddleware("admin"), leaveHandler.DeleteLeave)
+			leavesGroup.GET("/:id/timeline", leaveHandler.GetLeaveTimeline)
+			leavesGroup.GET("/balance", leaveHandler.GetLeaveBalance)
+			leavesGroup.GET("/quotas", leaveHandler.GetQuotas)
+			leavesGroup.PUT("/quotas", auth.RoleMiddleware("admin"), leaveHandler.SetQuota)
+			leavesGroup.DELETE("/quotas/:id", auth.RoleMiddleware("admin"), leaveHandler.DeleteQuota)
+			leavesGroup.GET("/workflows", auth.RoleMiddleware("admin"), leaveHandler.GetWorkflows)
+			leavesGroup.POST("/workflows", auth.RoleMiddleware("admin"), leaveHandler.CreateWorkflow)
+			leavesGroup.PUT("/workflows/:id", auth.RoleMiddleware("admin"), leaveHandler.UpdateWorkflow)
+			leavesGroup.DELETE("/workflows/:id", auth.RoleMiddleware("admin"), leaveHandler.DeleteWorkflow)
 		}
 
 		// Attendance
 		attendanceGroup := protected.Group("/attendance")
 		{
 			attendanceGroup.POST("/mark", attendanceHandler.MarkAttendance)
+			attendanceGroup.POST("/bulk", auth.RoleMiddleware("faculty", "admin"), attendanceHandler.BulkMarkAttendance)
+			attendanceGroup.POST("/checkin", auth.RoleMiddleware("student"), attendanceHandler.Checkin)
+			attendanceGroup.POST("/sync", auth.RoleMiddleware("faculty", "admin"), attendanceHandler.SyncAttendance)
+			attendanceGroup.PUT("/:id/review", auth.RoleMiddleware("faculty", "admin"), attendanceHandler.ReviewCheckin)
+			attendanceGroup.GET("/:id/history", attendanceHandler.GetAttendanceHistory)
+			attendanceGroup.POST("/corrections", auth.RoleMiddleware("student", "faculty"), attendanceHandler.CreateCorrection)
+			attendanceGroup.GET("/corrections", auth.RoleMiddleware("student", "faculty", "hod", "admin"), attendanceHandler.GetCorrections)
+			attendanceGroup.PUT("/corrections/:id/review", auth.RoleMiddleware("hod", "admin"), attendanceHandler.ReviewCorrection)
+			attendanceGroup.PUT("/corrections/:id/cancel", auth.RoleMiddleware("student", "faculty"), attendanceHandler.CancelCorrection)
 			attendanceGroup.GET("/student/:id", attendanceHandler.GetStudentAttendance)
 			attendanceGroup.GET("/my", auth.RoleMiddleware("student"), attendanceHandler.GetMyAttendance)
 			attendanceGroup.GET("/daily", attendanceHandler.GetDailyAttendance)
+			attendanceGroup.GET("/register", auth.RoleMiddleware("faculty", "hod", "admin"), attendanceHandler.ExportRegister)
+			attendanceGroup.POST("/register/preview", auth.RoleMiddleware("faculty", "admin"), attendanceHandler.PreviewRegisterImport)
+			attendanceGroup.POST("/register/import", auth.RoleMiddleware("faculty", "admin"), attendanceHandler.ImportRegister)
+		}
+
+		// Courses
+		coursesGroup := protected.Group("/courses")
+		{
+			coursesGroup.POST("", auth.RoleMiddleware("admin"), courseHandler.CreateCourse)
+			coursesGroup.GET("", courseHandler.GetCourses)
+			coursesGroup.GET("/:id", courseHandler.GetCourse)
+			coursesGroup.POST("/:id/enrollments", auth.RoleMiddleware("faculty", "admin"), courseHandler.EnrollStudents)
+			coursesGroup.GET("/:id/enrollments", auth.RoleMiddleware("faculty", "warden", "admin"), courseHandler.GetEnrollments)
+		}
+
+		// Class sessions
+		sessionsGroup := protected.Group("/sessions")
+		sessionsGroup.Use(auth.RoleMiddleware("faculty", "admin"))
+		{
+			sessionsGroup.POST("", courseHandler.CreateSession)
+			sessionsGroup.GET("", courseHandler.GetSessions)
+			sessionsGroup.GET("/unmarked", timetableHandler.GetUnmarkedSessions)
+			sessionsGroup.PUT("/:id/substitute", timetableHandler.SubstituteSession)
+			sessionsGroup.PUT("/:id/cancel", timetableHandler.CancelSession)
+			sessionsGroup.GET("/:id/attendance", courseHandler.GetSessionAttendance)
+			sessionsGroup.POST("/:id/checkin/open", attendanceHandler.OpenCheckin)
+			sessionsGroup.GET("/:id/checkin", attendanceHandler.GetCheckin)
+			sessionsGroup.POST("/:id/checkin/close", attendanceHandler.CloseCheckin)
+		}
+
+		// Weekly timetable
+		timetableGroup := protected.Group("/timetable")
+		{
+			timetableGroup.GET("", timetableHandler.GetTimetable)
+			timetableGroup.GET("/my", timetableHandler.GetMyTimetable)
+			timetableGroup.POST("", auth.RoleMiddleware("admin"), timetableHandler.CreateTimetableEntry)
+			timetableGroup.DELETE("/:id", auth.RoleMiddleware("admin"), timetableHandler.DeleteTimetableEntry)
+			timetableGroup.POST("/generate", auth.RoleMiddleware("admin"), timetableHandler.GenerateSessions)
+		}
+
+		// Academic calendar
+		calendarGroup := protected.Group("/calendar")
+		{
+			calendarGroup.GET("/terms", calendarHandler.GetTerms)
+			calendarGroup.POST("/terms", auth.RoleMiddleware("admin"), calendarHandler.CreateTerm)
+			calendarGroup.DELETE("/terms/:id", auth.RoleMiddleware("admin"), calendarHandler.DeleteTerm)
+			calendarGroup.GET("/holidays", calendarHandler.GetHolidays)
+			calendarGroup.POST("/holidays", auth.RoleMiddleware("admin"), calendarHandler.CreateHoliday)
+			calendarGroup.POST("/holidays/import", auth.RoleMiddleware("admin"), calendarHandler.ImportHolidays)
+			calendarGroup.DELETE("/holidays/:id", auth.RoleMiddleware("admin"), calendarHandler.DeleteHoliday)
+			calendarGroup.GET("/weekly-offs", calendarHandler.GetWeeklyOffs)
+			calendarGroup.PUT("/weekly-offs", auth.RoleMiddleware("admin"), calendarHandler.SetWeeklyOffs)
+			calendarGroup.GET("/special-days", calendarHandler.GetSpecialWorkingDays)
+			calendarGroup.POST("/special-days", auth.RoleMiddleware("admin"), calendarHandler.CreateSpecialWorkingDay)
+			calendarGroup.DELETE("/special-days/:id", auth.RoleMiddleware("admin"), calendarHandler.DeleteSpecialWorkingDay)
+			calendarGroup.GET("/events", calendarHandler.GetEvents)
+			calendarGroup.POST("/events", auth.RoleMiddleware("admin"), calendarHandler.CreateEvent)
+			calendarGroup.DELETE("/events/:id", auth.RoleMiddleware("admin"), calendarHandler.DeleteEvent)
+			calendarGroup.GET("/working-days", calendarHandler.GetWorkingDays)
+		}
+
+		// Check-in geofences and devices
+		geofencesGroup := protected.Group("/geofences")
+		{
+			geofencesGroup.GET("", auth.RoleMiddleware("faculty", "admin"), attendanceHandler.GetGeofences)
+			geofencesGroup.POST("", auth.RoleMiddleware("admin"), attendanceHandler.CreateGeofence)
+			geofencesGroup.DELETE("/:id", auth.RoleMiddleware("admin"), attendanceHandler.DeleteGeofence)
+		}
+		devicesGroup := protected.Group("/devices")
+		devicesGroup.Use(auth.RoleMiddleware("student"))
+		{
+			devicesGroup.POST("", attendanceHandler.RegisterDevice)
+			devicesGroup.GET("/my", attendanceHandler.GetMyDevice)
+		}
+
+		// RFID and biometric punches
+		punchesGroup := protected.Group("")
+		punchesGroup.Use(auth.RoleMiddleware("admin"))
+		{
+			punchesGroup.POST("/punches", punchHandler.IngestPunches)
+			punchesGroup.POST("/punches/import", punchHandler.ImportPunches)
+			punchesGroup.GET("/punches", punchHandler.GetPunches)
+			punchesGroup.GET("/cards", punchHandler.GetCards)
+			punchesGroup.POST("/cards", punchHandler.CreateCard)
+			punchesGroup.DELETE("/cards/:id", punchHandler.DeleteCard)
+			punchesGroup.GET("/terminals", punchHandler.GetTerminals)
+			punchesGroup.POST("/terminals", punchHandler.CreateTerminal)
+		}
+
+		// Files
+		filesGroup := protected.Group("/files")
+		{
+			filesGroup.POST("", fileHandler.UploadDocument)
+			filesGroup.POST("/profile-photo", fileHandler.UploadProfilePhoto)
+			filesGroup.GET("/:id", fileHandler.GetFile)
+			filesGroup.DELETE("/:id", fileHandler.DeleteFile)
 		}
 
 		// Notifications
@@ -111,6 +252,54 @@ func SetupRoutes() *gin.Engine {
 			notificationsGroup.GET("/unread-count", notificationHandler.GetUnreadCount)
 		}
 
+		// Exam eligibility
+		eligibilityGroup := protected.Group("/eligibility")
+		{
+			eligibilityGroup.GET("/rules", eligibilityHandler.GetRules)
+			eligibilityGroup.PUT("/rules", auth.RoleMiddleware("admin"), eligibilityHandler.SetRule)
+			eligibilityGroup.DELETE("/rules/:id", auth.RoleMiddleware("admin"), eligibilityHandler.DeleteRule)
+			eligibilityGroup.GET("/my", auth.RoleMiddleware("student"), eligibilityHandler.GetMyEligibility)
+			eligibilityGroup.GET("/students/:id", auth.RoleMiddleware("faculty", "hod", "warden", "admin"), eligibilityHandler.GetStudentEligibility)
+			eligibilityGroup.GET("/courses/:id/report", auth.RoleMiddleware("faculty", "hod", "admin"), eligibilityHandler.GetCourseReport)
+		}
+
+		// Condonation of attendance shortfall
+		condonationsGroup := protected.Group("/condonations")
+		{
+			condonationsGroup.POST("", auth.RoleMiddleware("student"), condonationHandler.ApplyCondonation)
+			condonationsGroup.GET("", condonationHandler.GetCondonations)
+			condonationsGroup.GET("/:id", condonationHandler.GetCondonation)
+			condonationsGroup.PUT("/:id/decide", auth.RoleMiddleware("faculty", "hod", "warden", "admin"), condonationHandler.DecideCondonation)
+			condonationsGroup.PUT("/:id/cancel", auth.RoleMiddleware("student"), condonationHandler.CancelCondonation)
+		}
+
+		// Hostels, gate passes and night roll calls
+		hostelsGroup := protected.Group("/hostels")
+		{
+			hostelsGroup.GET("", auth.RoleMiddleware("warden", "admin"), hostelHandler.GetHostels)
+			hostelsGroup.POST("", auth.RoleMiddleware("admin"), hostelHandler.CreateHostel)
+			hostelsGroup.GET("/:id/residents", auth.RoleMiddleware("warden", "admin"), hostelHandler.GetResidents)
+			hostelsGroup.POST("/:id/residents", auth.RoleMiddleware("warden", "admin"), hostelHandler.AssignResident)
+			hostelsGroup.DELETE("/:id/residents/:student_id", auth.RoleMiddleware("warden", "admin"), hostelHandler.RemoveResident)
+		}
+		gatePassesGroup := protected.Group("/gate-passes")
+		{
+			gatePassesGroup.POST("", auth.RoleMiddleware("student"), hostelHandler.RequestGatePass)
+			gatePassesGroup.GET("", auth.RoleMiddleware("student", "warden", "admin"), hostelHandler.GetGatePasses)
+			gatePassesGroup.PUT("/:id/decide", auth.RoleMiddleware("warden", "admin"), hostelHandler.DecideGatePass)
+			gatePassesGroup.PUT("/:id/return", auth.RoleMiddleware("warden", "admin"), hostelHandler.ReturnGatePass)
+			gatePassesGroup.PUT("/:id/cancel", auth.RoleMiddleware("student"), hostelHandler.CancelGatePass)
+		}
+		rollCallsGroup := protected.Group("/roll-calls")
+		rollCallsGroup.Use(auth.RoleMiddleware("warden", "admin"))
+		{
+			rollCallsGroup.POST("", hostelHandler.StartRollCall)
+			rollCallsGroup.GET("", hostelHandler.GetRollCalls)
+			rollCallsGroup.GET("/:id", hostelHandler.GetRollCall)
+			rollCallsGroup.PUT("/:id/entries", hostelHandler.MarkRollCall)
+			rollCallsGroup.PUT("/:id/close", hostelHandler.CloseRollCall)
+		}
+
 		// Analytics
 		analyticsGroup := protected.Group("/analytics")
 		analyticsGroup.Use(auth.RoleMiddleware("admin"))
@@ -120,6 +309,7 @@ func SetupRoutes() *gin.Engine {
 			analyticsGroup.GET("/department", analyticsHandler.GetDepartmentStats)
 			analyticsGroup.GET("/absentees", analyticsHandler.GetFrequentAbsentees)
 			analyticsGroup.GET("/summary", analyticsHandler.GetSummary)
+			analyticsGroup.GET("/punctuality", analyticsHandler.GetPunctuality)
 		}
 	}
 
@@ -0,0 +1,365 @@
+package attendance
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/internal/notifications"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Per-row outcomes of a bulk submission
+const (
+	rowCreated   = "created"
+	rowUpdated   = "updated"
+	rowUnchanged = "unchanged"
+	rowInvalid   = "invalid"
+)
+
+type bulkRowResult struct {
+	StudentID uint                `json:"student_id"`
+	Status    db.AttendanceStatus `json:"status,omitempty"`
+	Result    string              `json:"result"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// BulkMarkAttendance godoc
+// @Summary      Bulk mark attendance
+// @Description  Mark attendance for a whole roster in one transaction. Send either explicit records, or all_present with the absentees in except.
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.BulkAttendanceRequest  true  "Roster"
+// @Success      200      {object}  object{message=string,summary=object,results=array}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Router       /attendance/bulk [post]
+func (h *AttendanceHandler) BulkMarkAttendance(c *gin.Context) {
+	markedBy, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+	uid, ok := markedBy.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+		return
+	}
+
+	var req dto.BulkAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.AllPresent == (len(req.Records) > 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either records or all_present"})
+		return
+	}
+
+	absentStatus := db.AttendanceAbsent
+	if req.AbsentStatus != "" {
+		absentStatus = db.AttendanceStatus(req.AbsentStatus)
+		if !absentStatus.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid absent_status"})
+			return
+		}
+	}
+
+	var session *db.ClassSession
+	date := req.Date.Time
+	if req.SessionID != nil {
+		session, ok = h.sessionForMarking(c, *req.SessionID, uid)
+		if !ok {
+			return
+		}
+		date = session.Date
+	} else if date.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date is required unless session_id is given"})
+		return
+	}
+
+	if !h.requireUnlocked(c, date) {
+		return
+	}
+	if !h.requireWorkingDay(c, h.markingDept(req.SessionID, req.Dept), date) {
+		return
+	}
+
+	roster, ok := h.roster(c, session, req.Dept, req.AllPresent)
+	if !ok {
+		return
+	}
+
+	// Expand the submission into one desired status per student
+	var rows []dto.BulkAttendanceRecord
+	var except []uint
+	var onLeave map[uint]uint
+	if req.AllPresent {
+		except = req.Except
+		absent := make(map[uint]bool, len(req.Except))
+		for _, id := range req.Except {
+			absent[id] = true
+		}
+		onLeave = h.approvedLeaves(roster, date)
+		for _, studentID := range roster {
+			status := db.AttendancePresent
+			if _, ok := onLeave[studentID]; ok {
+				status = db.AttendanceOnLeave
+			} else if absent[studentID] {
+				status = absentStatus
+			}
+			rows = append(rows, dto.BulkAttendanceRecord{StudentID: studentID, Status: string(status)})
+		}
+	} else {
+		rows = req.Records
+		ids := make([]uint, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.StudentID)
+		}
+		onLeave = h.approvedLeaves(ids, date)
+	}
+
+	results := h.validateRows(rows, session, except, roster, onLeave)
+
+	var valid []uint
+	for i := range results {
+		if results[i].Result != rowInvalid {
+			valid = append(valid, results[i].StudentID)
+		}
+	}
+
+	// Records of the submission's session, or daily records of its date
+	scope := func(query *gorm.DB) *gorm.DB {
+		if session != nil {
+			return query.Where("session_id = ?", session.ID)
+		}
+		return query.Where("date = ? AND session_id IS NULL", date)
+	}
+	// Rows inserted concurrently since they were read are left alone by the
+	// insert and updated below instead
+	conflict := clause.OnConflict{Columns: []clause.Column{{Name: "student_id"}, {Name: "session_id"}}, DoNothing: true}
+	if session == nil {
+		conflict = clause.OnConflict{
+			Columns:     []clause.Column{{Name: "student_id"}, {Name: "date"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "session_id IS NULL"}}},
+			DoNothing:   true,
+		}
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []db.Attendance
+		if err := scope(tx.Where("student_id IN ?", valid)).Find(&existing).Error; err != nil {
+			return err
+		}
+		byStudent := make(map[uint]db.Attendance, len(existing))
+		for _, record := range existing {
+			byStudent[record.StudentID] = record
+		}
+
+		var created, changed []db.Attendance
+		var createdRows []*bulkRowResult
+		for i := range results {
+			row := &results[i]
+			if row.Result == rowInvalid {
+				continue
+			}
+
+			record, found := byStudent[row.StudentID]
+			switch {
+			case !found:
+				row.Result = rowCreated
+				var sessionID *uint
+				if session != nil {
+					sessionID = &session.ID
+				}
+				record := db.Attendance{
+					StudentID: row.StudentID,
+					SessionID: sessionID,
+					Date:      date,
+					MarkedBy:  uid,
+				}
+				record.SetStatus(row.Status)
+				record.Audit(uid, req.Reason)
+				created = append(created, record)
+				createdRows = append(createdRows, row)
+			case record.Status == row.Status:
+				row.Result = rowUnchanged
+			default:
+				row.Result = rowUpdated
+				record.SetStatus(row.Status)
+				record.MarkedBy = uid
+				record.Audit(uid, req.Reason)
+				changed = append(changed, record)
+			}
+		}
+
+		// Inserted one by one so a conflicting row can be told apart
+		for i := range created {
+			if err := tx.Clauses(conflict).Create(&created[i]).Error; err != nil {
+				return err
+			}
+			if created[i].ID != 0 {
+				continue
+			}
+			var current db.Attendance
+			if err := scope(tx.Where("student_id = ?", created[i].StudentID)).First(&current).Error; err != nil {
+				return err
+			}
+			row := createdRows[i]
+			if current.Status == row.Status {
+				row.Result = rowUnchanged
+				continue
+			}
+			row.Result = rowUpdated
+			current.SetStatus(row.Status)
+			current.MarkedBy = uid
+			current.Audit(uid, req.Reason)
+			changed = append(changed, current)
+		}
+
+		if len(changed) > 0 && req.Reason == "" && editNeedsReason(date) {
+			return errReasonRequired
+		}
+
+		// Saved one by one so each change lands in the record's history
+		for i := range changed {
+			if err := tx.Save(&changed[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errReasonRequired) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required to change attendance after the day it was taken"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attendance"})
+		return
+	}
+
+	summary := map[string]int{rowCreated: 0, rowUpdated: 0, rowUnchanged: 0, rowInvalid: 0}
+	var entries []notifications.AttendanceEntry
+	for _, row := range results {
+		summary[row.Result]++
+		if row.Result == rowCreated {
+			entries = append(entries, notifications.AttendanceEntry{
+				StudentID: row.StudentID,
+				Present:   row.Status.Attended(),
+				Status:    string(row.Status),
+			})
+		}
+	}
+
+	h.queueBulkNotification(c, uid, date, entries)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Attendance processed",
+		"summary": summary,
+		"results": results,
+	})
+}
+
+// roster returns the students expected in the submission: the enrolled
+// section of a session, or the students of a department for daily marking.
+// A roster is only required for all_present submissions.
+func (h *AttendanceHandler) roster(c *gin.Context, session *db.ClassSession, dept string, required bool) ([]uint, bool) {
+	var roster []uint
+	switch {
+	case session != nil:
+		h.DB.Model(&db.Enrollment{}).
+			Where("course_id = ? AND section = ?", session.CourseID, session.Section).
+			Order("student_id").
+			Pluck("student_id", &roster)
+	case dept != "":
+		h.DB.Model(&db.User{}).
+			Where("role = ? AND dept = ?", db.RoleStudent, dept).
+			Order("id").
+			Pluck("id", &roster)
+	case required:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "all_present requires session_id or dept to determine the roster"})
+		return nil, false
+	}
+	return roster, true
+}
+
+// validateRows checks every row against the known students and roster
+func (h *AttendanceHandler) validateRows(rows []dto.BulkAttendanceRecord, session *db.ClassSession, except []uint, roster []uint, onLeave map[uint]uint) []bulkRowResult {
+	ids := make([]uint, 0, len(rows)+len(except))
+	for _, row := range rows {
+		ids = append(ids, row.StudentID)
+	}
+	ids = append(ids, except...)
+
+	var studentIDs []uint
+	h.DB.Model(&db.User{}).Where("id IN ? AND role = ?", ids, db.RoleStudent).Pluck("id", &studentIDs)
+	isStudent := make(map[uint]bool, len(studentIDs))
+	for _, id := range studentIDs {
+		isStudent[id] = true
+	}
+
+	inRoster := make(map[uint]bool, len(roster))
+	for _, id := range roster {
+		inRoster[id] = true
+	}
+
+	results := make([]bulkRowResult, 0, len(rows)+len(except))
+	seen := make(map[uint]bool, len(rows))
+	for _, row := range rows {
+		status, validStatus := resolveStatus(row.Status, row.Present)
+		result := bulkRowResult{StudentID: row.StudentID, Status: status}
+		switch {
+		case !validStatus:
+			result.Result, result.Error = rowInvalid, "Invalid attendance status"
+		case row.StudentID == 0 || !isStudent[row.StudentID]:
+			result.Result, result.Error = rowInvalid, "Student not found"
+		case seen[row.StudentID]:
+			result.Result, result.Error = rowInvalid, "Duplicate student in request"
+		case session != nil && !inRoster[row.StudentID]:
+			result.Result, result.Error = rowInvalid, "Student is not enrolled in this session's course section"
+		case onLeave[row.StudentID] != 0 && status != db.AttendanceOnLeave:
+			result.Result, result.Error = rowInvalid, "Student is on approved leave on this date"
+		}
+		seen[row.StudentID] = true
+		results = append(results, result)
+	}
+
+	// Absentees that are not part of the roster would otherwise be ignored silently
+	for _, id := range except {
+		if !seen[id] {
+			results = append(results, bulkRowResult{StudentID: id, Result: rowInvalid, Error: "Student is not part of the roster"})
+			seen[id] = true
+		}
+	}
+
+	return results
+}
+
+func (h *AttendanceHandler) queueBulkNotification(c *gin.Context, uid uint, date time.Time, entries []notifications.AttendanceEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	var marker db.User
+	if err := h.DB.First(&marker, uid).Error; err != nil {
+		return
+	}
+
+	notifService := notifications.GetNotificationService()
+	if err := notifService.QueueBulkAttendanceNotification(c.Request.Context(), notifications.BulkAttendancePayload{
+		Date:     date,
+		MarkedBy: marker.Name,
+		Entries:  entries,
+	}); err != nil {
+		log.Printf("Failed to queue bulk attendance notification: %v", err)
+	}
+}
@@ -0,0 +1,516 @@
+package attendance
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/internal/notifications"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/config"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Check-in tokens have the form "<session id>.<step>.<hmac>" where step is
+// the current TTL-sized time slot, so the QR code shown in class rotates
+// every CheckinTokenTTL. The token of the previous slot is still accepted to
+// allow for a scan made just before rotation.
+
+func checkinStep(t time.Time) int64 {
+	ttl := int64(config.AppConfig.Attendance.CheckinTokenTTL / time.Second)
+	if ttl <= 0 {
+		ttl = 1
+	}
+	return t.Unix() / ttl
+}
+
+func checkinSignature(session *db.ClassSession, step int64) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.JWT.Secret+session.CheckinSecret))
+	fmt.Fprintf(mac, "%d:%d", session.ID, step)
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// checkinToken returns the current token of an open session and when it
+// rotates
+func checkinToken(session *db.ClassSession) (string, time.Time) {
+	step := checkinStep(time.Now())
+	ttl := config.AppConfig.Attendance.CheckinTokenTTL
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	expiresAt := time.Unix((step+1)*int64(ttl/time.Second), 0)
+	return fmt.Sprintf("%d.%d.%s", session.ID, step, checkinSignature(session, step)), expiresAt
+}
+
+// parseCheckinToken extracts the session ID of a token without verifying it
+func parseCheckinToken(token string) (uint, int64, string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, 0, "", false
+	}
+	sessionID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, 0, "", false
+	}
+	step, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, "", false
+	}
+	return uint(sessionID), step, parts[2], true
+}
+
+// verifyCheckinToken checks a token's signature and freshness for session
+func verifyCheckinToken(session *db.ClassSession, step int64, sig string) bool {
+	current := checkinStep(time.Now())
+	if step != current && step != current-1 {
+		return false
+	}
+	return hmac.Equal([]byte(checkinSignature(session, step)), []byte(sig))
+}
+
+// checkinWindowEnd is when an open session stops accepting check-ins
+func checkinWindowEnd(session *db.ClassSession) time.Time {
+	return session.CheckinOpenedAt.Add(config.AppConfig.Attendance.CheckinWindow)
+}
+
+func checkinState(session *db.ClassSession) gin.H {
+	state := gin.H{
+		"session_id": session.ID,
+		"open":       session.CheckinOpen(),
+		"opened_at":  session.CheckinOpenedAt,
+		"closed_at":  session.CheckinClosedAt,
+	}
+	if session.CheckinOpen() {
+		token, expiresAt := checkinToken(session)
+		state["token"] = token
+		state["token_expires_at"] = expiresAt
+		state["window_closes_at"] = checkinWindowEnd(session)
+	}
+	return state
+}
+
+// OpenCheckin godoc
+// @Summary      Open session check-in
+// @Description  Open QR self check-in for a class session held today and return the first token (session faculty or admin)
+// @Tags         sessions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Session ID"
+// @Success      200  {object}  object{message=string,data=object}
+// @Failure      400  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /sessions/{id}/checkin/open [post]
+func (h *AttendanceHandler) OpenCheckin(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	session, ok := h.checkinSession(c, uid)
+	if !ok {
+		return
+	}
+
+	if session.CheckinOpen() {
+		c.JSON(http.StatusOK, gin.H{"message": "Check-in already open", "data": checkinState(session)})
+		return
+	}
+	if session.CheckinClosedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check-in for this session has been closed"})
+		return
+	}
+	if !clock.Date(session.Date).Equal(clock.Today()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check-in can only be opened on the day of the session"})
+		return
+	}
+	if !h.requireWorkingDay(c, h.markingDept(&session.ID, ""), session.Date) {
+		return
+	}
+
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open check-in"})
+		return
+	}
+	now := time.Now()
+	session.CheckinOpenedAt = &now
+	session.CheckinSecret = hex.EncodeToString(secret)
+	if err := h.DB.Model(session).Updates(map[string]interface{}{
+		"checkin_opened_at": session.CheckinOpenedAt,
+		"checkin_secret":    session.CheckinSecret,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open check-in"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Check-in opened", "data": checkinState(session)})
+}
+
+// GetCheckin godoc
+// @Summary      Get session check-in
+// @Description  Current check-in state of a session with the rotating token to display as a QR code, and the students who checked in
+// @Tags         sessions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Session ID"
+// @Success      200  {object}  object{data=object,checkins=array}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /sessions/{id}/checkin [get]
+func (h *AttendanceHandler) GetCheckin(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	session, ok := h.checkinSession(c, uid)
+	if !ok {
+		return
+	}
+
+	var checkins []db.Attendance
+	h.DB.Preload("Student").
+		Where("session_id = ? AND source = ?", session.ID, db.SourceCheckin).
+		Order("checked_in_at").
+		Find(&checkins)
+
+	state := checkinState(session)
+	pending := 0
+	for _, checkin := range checkins {
+		if checkin.ReviewStatus == db.ReviewPending {
+			pending++
+		}
+	}
+	state["pending_review"] = pending
+
+	c.JSON(http.StatusOK, gin.H{"data": state, "checkins": checkins})
+}
+
+// CloseCheckin godoc
+// @Summary      Close session check-in
+// @Description  Stop accepting check-ins and record every enrolled student who was not checked in or marked as absent (or on_leave when on approved leave)
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                      true   "Session ID"
+// @Param        request  body      dto.CloseCheckinRequest  false  "Status for students who did not check in"
+// @Success      200      {object}  object{message=string,summary=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Router       /sessions/{id}/checkin/close [post]
+func (h *AttendanceHandler) CloseCheckin(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	session, ok := h.checkinSession(c, uid)
+	if !ok {
+		return
+	}
+
+	var req dto.CloseCheckinRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	absentStatus := db.AttendanceAbsent
+	if req.AbsentStatus != "" {
+		absentStatus = db.AttendanceStatus(req.AbsentStatus)
+		if !absentStatus.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid absent_status"})
+			return
+		}
+	}
+
+	if !session.CheckinOpen() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check-in is not open for this session"})
+		return
+	}
+
+	roster, _ := h.roster(c, session, "", false)
+	onLeave := h.approvedLeaves(roster, session.Date)
+
+	var created []db.Attendance
+	var checkedIn int64
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(session).Update("checkin_closed_at", &now).Error; err != nil {
+			return err
+		}
+
+		var marked []uint
+		if err := tx.Model(&db.Attendance{}).Where("session_id = ?", session.ID).Pluck("student_id", &marked).Error; err != nil {
+			return err
+		}
+		isMarked := make(map[uint]bool, len(marked))
+		for _, id := range marked {
+			isMarked[id] = true
+		}
+
+		for _, studentID := range roster {
+			if isMarked[studentID] {
+				continue
+			}
+			record := db.Attendance{
+				StudentID: studentID,
+				SessionID: &session.ID,
+				Date:      session.Date,
+				MarkedBy:  uid,
+			}
+			if leaveID, ok := onLeave[studentID]; ok {
+				record.SetStatus(db.AttendanceOnLeave)
+				record.Source = db.SourceLeave
+				record.LeaveID = &leaveID
+			} else {
+				record.SetStatus(absentStatus)
+			}
+			created = append(created, record)
+		}
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(&created, 100).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&db.Attendance{}).
+			Where("session_id = ? AND source = ?", session.ID, db.SourceCheckin).
+			Count(&checkedIn).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close check-in"})
+		return
+	}
+
+	var entries []notifications.AttendanceEntry
+	for _, record := range created {
+		entries = append(entries, notifications.AttendanceEntry{
+			StudentID: record.StudentID,
+			Present:   record.Present,
+			Status:    string(record.Status),
+		})
+	}
+	h.queueBulkNotification(c, uid, session.Date, entries)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Check-in closed",
+		"summary": gin.H{
+			"checked_in":     checkedIn,
+			"marked_missing": len(created),
+			"roster":         len(roster),
+		},
+	})
+}
+
+// Checkin godoc
+// @Summary      Check in to a class session
+// @Description  Record the authenticated student as present using the token from the session's QR code. Check-ins from outside the geofence, from a device not bound to the student, or from a device shared with other students are recorded as absent and held for faculty review (202).
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CheckinRequest  true  "Scanned token"
+// @Success      201      {object}  object{message=string,data=object}
+// @Success      202      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string}
+// @Router       /attendance/checkin [post]
+func (h *AttendanceHandler) Checkin(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CheckinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sessionID, step, sig, ok := parseCheckinToken(req.Token)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check-in token"})
+		return
+	}
+
+	var session db.ClassSession
+	if err := h.DB.First(&session, sessionID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check-in token"})
+		return
+	}
+	if !session.CheckinOpen() || time.Now().After(checkinWindowEnd(&session)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check-in is not open for this session"})
+		return
+	}
+	if !verifyCheckinToken(&session, step, sig) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Check-in token is invalid or has expired, scan the code again"})
+		return
+	}
+
+	var enrolled int64
+	h.DB.Model(&db.Enrollment{}).
+		Where("course_id = ? AND section = ? AND student_id = ?", session.CourseID, session.Section, uid).
+		Count(&enrolled)
+	if enrolled == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not enrolled in this session's course section"})
+		return
+	}
+
+	if _, onLeave := h.approvedLeaves([]uint{uid}, session.Date)[uid]; onLeave {
+		c.JSON(http.StatusConflict, gin.H{"error": "You are on approved leave on this date"})
+		return
+	}
+
+	var existing int64
+	h.DB.Model(&db.Attendance{}).Where("session_id = ? AND student_id = ?", session.ID, uid).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attendance for this session is already recorded"})
+		return
+	}
+
+	now := time.Now()
+	record := db.Attendance{
+		StudentID:   uid,
+		SessionID:   &session.ID,
+		Date:        session.Date,
+		Source:      db.SourceCheckin,
+		MarkedBy:    uid,
+		CheckedInAt: &now,
+		DeviceID:    req.DeviceID,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		flags, shared, err := h.checkinFlags(tx, &session, uid, req)
+		if err != nil {
+			return err
+		}
+
+		// Suspicious check-ins stay absent until the faculty accepts them
+		if len(flags) > 0 {
+			record.SetStatus(db.AttendanceAbsent)
+			record.Flags = strings.Join(flags, ",")
+			record.ReviewStatus = db.ReviewPending
+		} else {
+			record.RecordArrival(&session, now)
+		}
+		// The unique session/student index rejects concurrent duplicate check-ins
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+
+		return flagSharedDevice(tx, shared)
+	})
+	if db.IsDuplicateKey(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attendance for this session is already recorded"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record check-in"})
+		return
+	}
+
+	if record.ReviewStatus == db.ReviewPending {
+		c.JSON(http.StatusAccepted, gin.H{"message": "Check-in recorded and held for faculty review", "data": record})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Checked in successfully", "data": record})
+}
+
+// ReviewCheckin godoc
+// @Summary      Review flagged check-in
+// @Description  Accept a flagged check-in as present or reject it as absent (session faculty or admin)
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                       true  "Attendance ID"
+// @Param        request  body      dto.ReviewCheckinRequest  true  "Decision"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Router       /attendance/{id}/review [put]
+func (h *AttendanceHandler) ReviewCheckin(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attendance ID"})
+		return
+	}
+
+	var req dto.ReviewCheckinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var record db.Attendance
+	if err := h.DB.First(&record, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+		return
+	}
+	if record.ReviewStatus != db.ReviewPending || record.SessionID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attendance record is not awaiting review"})
+		return
+	}
+	session, ok := h.sessionForMarking(c, *record.SessionID, uid)
+	if !ok {
+		return
+	}
+	if !h.requireUnlocked(c, record.Date) {
+		return
+	}
+
+	record.ReviewedBy = &uid
+	reason := req.Reason
+	if reason == "" {
+		reason = "Flagged check-in reviewed: " + record.Flags
+	}
+	record.Audit(uid, reason)
+	if req.Accept {
+		record.ReviewStatus = db.ReviewAccepted
+		record.SetStatus(db.AttendancePresent)
+		if record.CheckedInAt != nil {
+			// Accepted check-ins are late when the scan was
+			record.RecordArrival(session, *record.CheckedInAt)
+		}
+	} else {
+		record.ReviewStatus = db.ReviewRejected
+		record.SetStatus(db.AttendanceAbsent)
+	}
+	if err := h.DB.Save(&record).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review check-in"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Check-in " + string(record.ReviewStatus), "data": record})
+}
+
+// checkinSession loads the session in the path for its faculty or an admin
+func (h *AttendanceHandler) checkinSession(c *gin.Context, uid uint) (*db.ClassSession, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return nil, false
+	}
+	return h.sessionForMarking(c, uint(id), uid)
+}
@@ -0,0 +1,429 @@
+package attendance
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/internal/notifications"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateCorrection godoc
+// @Summary      Request an attendance correction
+// @Description  Ask for a change to attendance, with a reason and optional evidence. Students can request corrections of their own attendance at any time; faculty only once the date is locked, since before that they can change it directly. An HOD of the student's department or an admin decides the request.
+// @Tags         corrections
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateCorrectionRequest  true  "Correction"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string}
+// @Router       /attendance/corrections [post]
+func (h *AttendanceHandler) CreateCorrection(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+	isStudent := role == string(db.RoleStudent)
+
+	var req dto.CreateCorrectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	status := db.AttendanceStatus(req.Status)
+	if !status.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attendance status"})
+		return
+	}
+
+	correction := db.AttendanceCorrection{
+		RequestedStatus: status,
+		Reason:          req.Reason,
+		EvidenceFileID:  req.EvidenceFileID,
+		RequestedBy:     uid,
+		Status:          db.CorrectionPending,
+	}
+
+	// Resolve the record being corrected
+	var record db.Attendance
+	if req.AttendanceID != nil {
+		if err := h.DB.First(&record, *req.AttendanceID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+			return
+		}
+		correction.StudentID, correction.SessionID, correction.Date = record.StudentID, record.SessionID, record.Date
+	} else {
+		correction.StudentID = req.StudentID
+		if isStudent && correction.StudentID == 0 {
+			correction.StudentID = uid
+		}
+		if correction.StudentID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "attendance_id or student_id is required"})
+			return
+		}
+		correction.SessionID, correction.Date = req.SessionID, req.Date.Time
+
+		query := h.DB.Where("student_id = ?", correction.StudentID)
+		if req.SessionID != nil {
+			var session db.ClassSession
+			if err := h.DB.First(&session, *req.SessionID).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+				return
+			}
+			var enrolled int64
+			h.DB.Model(&db.Enrollment{}).
+				Where("course_id = ? AND section = ? AND student_id = ?", session.CourseID, session.Section, correction.StudentID).
+				Count(&enrolled)
+			if enrolled == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Student is not enrolled in this session's course section"})
+				return
+			}
+			correction.Date = session.Date
+			query = query.Where("session_id = ?", session.ID)
+		} else if correction.Date.IsZero() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date is required unless session_id is given"})
+			return
+		} else {
+			query = query.Where("date = ? AND session_id IS NULL", correction.Date)
+		}
+		if err := query.First(&record).Error; err != nil {
+			record = db.Attendance{}
+		}
+	}
+	if record.ID != 0 {
+		correction.AttendanceID = &record.ID
+		correction.CurrentStatus = record.Status
+	}
+
+	// Who may ask
+	if isStudent {
+		if correction.StudentID != uid {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only request corrections of your own attendance"})
+			return
+		}
+	} else {
+		if correction.SessionID != nil {
+			if _, ok := h.sessionForMarking(c, *correction.SessionID, uid); !ok {
+				return
+			}
+		}
+		if !h.locked(correction.Date) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Attendance for this date is not locked, change it directly"})
+			return
+		}
+	}
+
+	var student db.User
+	if err := h.DB.Where("id = ? AND role = ?", correction.StudentID, db.RoleStudent).First(&student).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Student not found"})
+		return
+	}
+	if !h.requireWorkingDay(c, h.markingDept(correction.SessionID, student.Dept), correction.Date) {
+		return
+	}
+	if correction.CurrentStatus == status {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attendance already has this status"})
+		return
+	}
+
+	if req.EvidenceFileID != nil {
+		var evidence int64
+		h.DB.Model(&db.File{}).Where("id = ? AND owner_id = ?", *req.EvidenceFileID, uid).Count(&evidence)
+		if evidence == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Evidence file not found, upload it through POST /files first"})
+			return
+		}
+	}
+
+	var pending int64
+	query := h.DB.Model(&db.AttendanceCorrection{}).
+		Where("student_id = ? AND date = ? AND status = ?", correction.StudentID, correction.Date, db.CorrectionPending)
+	if correction.SessionID != nil {
+		query = query.Where("session_id = ?", *correction.SessionID)
+	} else {
+		query = query.Where("session_id IS NULL")
+	}
+	query.Count(&pending)
+	if pending > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A correction request for this attendance is already pending"})
+		return
+	}
+
+	if err := h.DB.Create(&correction).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create correction request"})
+		return
+	}
+	queueCorrectionNotification(c, correction.ID, notifications.CorrectionRaised)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Correction request submitted", "data": correction})
+}
+
+// GetCorrections godoc
+// @Summary      List attendance corrections
+// @Description  Students see requests about their attendance, faculty the requests they raised, HODs the requests of their department's students and admins every request
+// @Tags         corrections
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status  query     string  false  "pending, approved, rejected or cancelled"
+// @Param        page    query     int     false  "Page number" default(1)
+// @Param        limit   query     int     false  "Items per page" default(10)
+// @Success      200     {object}  object{data=array,page=int,limit=int,total=int64,total_pages=int64}
+// @Router       /attendance/corrections [get]
+func (h *AttendanceHandler) GetCorrections(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	query := h.DB.Model(&db.AttendanceCorrection{})
+	switch role {
+	case string(db.RoleStudent):
+		query = query.Where("student_id = ?", uid)
+	case string(db.RoleFaculty):
+		query = query.Where("requested_by = ?", uid)
+	case string(db.RoleHOD):
+		var hod db.User
+		h.DB.Select("id", "dept").First(&hod, uid)
+		students := h.DB.Model(&db.User{}).Select("id").Where("role = ? AND dept = ?", db.RoleStudent, hod.Dept)
+		query = query.Where("student_id IN (?)", students)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var corrections []db.AttendanceCorrection
+	query.Preload("Student").Preload("Requester").Preload("Reviewer").Preload("Evidence").
+		Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&corrections)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        corrections,
+		"page":        page,
+		"limit":       limit,
+		"total":       total,
+		"total_pages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// ReviewCorrection godoc
+// @Summary      Approve or reject an attendance correction
+// @Description  Decide a pending correction (HOD of the student's department or admin). Approval applies the change even though the date is locked and records it in the attendance history.
+// @Tags         corrections
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                          true  "Correction ID"
+// @Param        request  body      dto.ReviewCorrectionRequest  true  "Decision"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string}
+// @Router       /attendance/corrections/{id}/review [put]
+func (h *AttendanceHandler) ReviewCorrection(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+
+	var req dto.ReviewCorrectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	correction, ok := h.pendingCorrection(c)
+	if !ok {
+		return
+	}
+	if role == string(db.RoleHOD) {
+		var hod db.User
+		if err := h.DB.First(&hod, uid).Error; err != nil || hod.Dept == "" || hod.Dept != correction.Student.Dept {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the HOD of the student's department can review this correction"})
+			return
+		}
+	}
+
+	now := time.Now()
+	correction.ReviewedBy = &uid
+	correction.ReviewedAt = &now
+	correction.ReviewRemarks = req.Remarks
+	correction.Status = db.CorrectionRejected
+	if req.Approve {
+		correction.Status = db.CorrectionApproved
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingCorrection(tx, correction.ID); err != nil {
+			return err
+		}
+		if req.Approve {
+			record, err := applyCorrection(tx, correction, uid)
+			if err != nil {
+				return err
+			}
+			correction.AttendanceID = &record.ID
+		}
+		return tx.Save(correction).Error
+	})
+	if errors.Is(err, errCorrectionDecided) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Correction was decided by someone else at the same time"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review correction"})
+		return
+	}
+	queueCorrectionNotification(c, correction.ID, string(correction.Status))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Correction " + string(correction.Status), "data": correction})
+}
+
+// CancelCorrection godoc
+// @Summary      Cancel an attendance correction
+// @Description  Withdraw a pending correction request (requester only)
+// @Tags         corrections
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Correction ID"
+// @Success      200  {object}  object{message=string,data=object}
+// @Failure      400  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string}
+// @Router       /attendance/corrections/{id}/cancel [put]
+func (h *AttendanceHandler) CancelCorrection(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	correction, ok := h.pendingCorrection(c)
+	if !ok {
+		return
+	}
+	if correction.RequestedBy != uid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the requester can cancel a correction"})
+		return
+	}
+
+	correction.Status = db.CorrectionCancelled
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingCorrection(tx, correction.ID); err != nil {
+			return err
+		}
+		return tx.Save(correction).Error
+	})
+	if errors.Is(err, errCorrectionDecided) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Correction was decided by someone else at the same time"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel correction"})
+		return
+	}
+	queueCorrectionNotification(c, correction.ID, notifications.CorrectionCancelled)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Correction cancelled", "data": correction})
+}
+
+// pendingCorrection loads the pending correction in the path
+func (h *AttendanceHandler) pendingCorrection(c *gin.Context) (*db.AttendanceCorrection, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid correction ID"})
+		return nil, false
+	}
+
+	var correction db.AttendanceCorrection
+	if err := h.DB.Preload("Student").First(&correction, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Correction not found"})
+		return nil, false
+	}
+	if correction.Status != db.CorrectionPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Correction is already " + string(correction.Status)})
+		return nil, false
+	}
+	return &correction, true
+}
+
+// errCorrectionDecided is returned when a correction stopped being pending
+// after it was loaded
+var errCorrectionDecided = errors.New("correction already decided")
+
+// lockPendingCorrection locks the correction until the transaction ends and
+// checks that it is still pending, so concurrent reviews and cancellations
+// take effect one at a time and only the first one applies
+func lockPendingCorrection(tx *gorm.DB, id uint) error {
+	var current db.AttendanceCorrection
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&current, id).Error; err != nil {
+		return err
+	}
+	if current.Status != db.CorrectionPending {
+		return errCorrectionDecided
+	}
+	return nil
+}
+
+// applyCorrection writes the requested status to the corrected record,
+// creating it when the attendance was never marked
+func applyCorrection(tx *gorm.DB, correction *db.AttendanceCorrection, reviewerID uint) (*db.Attendance, error) {
+	query := tx.Where("student_id = ?", correction.StudentID)
+	if correction.SessionID != nil {
+		query = query.Where("session_id = ?", *correction.SessionID)
+	} else {
+		query = query.Where("date = ? AND session_id IS NULL", correction.Date)
+	}
+
+	var record db.Attendance
+	err := query.First(&record).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	record.SetStatus(correction.RequestedStatus)
+	record.MarkedBy = reviewerID
+	record.Audit(reviewerID, fmt.Sprintf("Correction #%d: %s", correction.ID, correction.Reason))
+	if record.ID == 0 {
+		record.StudentID, record.SessionID, record.Date = correction.StudentID, correction.SessionID, correction.Date
+		return &record, tx.Create(&record).Error
+	}
+	return &record, tx.Save(&record).Error
+}
+
+func queueCorrectionNotification(c *gin.Context, correctionID uint, event string) {
+	notifService := notifications.GetNotificationService()
+	if err := notifService.QueueCorrectionNotification(c.Request.Context(), notifications.CorrectionPayload{
+		CorrectionID: correctionID,
+		Event:        event,
+	}); err != nil {
+		log.Printf("Failed to queue correction notification: %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package attendance
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/pkg/config"
+	"attendance-workflow/pkg/db"
+	"attendance-workflow/pkg/geo"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// checkinFlags returns the reasons a check-in is suspicious, along with the
+// earlier check-ins of the session made from the same device that must now
+// be flagged too. A student's first check-in binds their device.
+func (h *AttendanceHandler) checkinFlags(tx *gorm.DB, session *db.ClassSession, uid uint, req dto.CheckinRequest) ([]string, []uint, error) {
+	var flags []string
+
+	// Geofence: the session's classroom fence, otherwise the campus fences
+	var fences []db.Geofence
+	query := tx.Where("kind = ?", db.GeofenceCampus)
+	if session.GeofenceID != nil {
+		query = tx.Where("id = ?", *session.GeofenceID)
+	}
+	if err := query.Find(&fences).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(fences) > 0 {
+		if req.Latitude == nil || req.Longitude == nil {
+			flags = append(flags, db.FlagNoLocation)
+		} else if !insideAny(fences, geo.Point{Lat: *req.Latitude, Lng: *req.Longitude}) {
+			flags = append(flags, db.FlagOutsideGeofence)
+		}
+	}
+
+	// Device binding
+	now := time.Now()
+	var device db.Device
+	err := tx.Where("user_id = ?", uid).First(&device).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		device = db.Device{UserID: uid, DeviceID: req.DeviceID, LastSeenAt: &now}
+		if err := tx.Create(&device).Error; err != nil {
+			return nil, nil, err
+		}
+	case err != nil:
+		return nil, nil, err
+	case device.DeviceID != req.DeviceID:
+		flags = append(flags, db.FlagNewDevice)
+	default:
+		if err := tx.Model(&device).Update("last_seen_at", &now).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Several students checking in from one device
+	var shared []uint
+	if err := tx.Model(&db.Attendance{}).
+		Where("session_id = ? AND device_id = ? AND student_id <> ?", session.ID, req.DeviceID, uid).
+		Pluck("id", &shared).Error; err != nil {
+		return nil, nil, err
+	}
+	if int64(len(shared))+1 > config.AppConfig.Attendance.MaxStudentsPerDevice {
+		flags = append(flags, db.FlagSharedDevice)
+	} else {
+		shared = nil
+	}
+
+	return flags, shared, nil
+}
+
+// flagSharedDevice holds earlier check-ins for review once their device is
+// seen checking in another student. Already reviewed records are left alone.
+func flagSharedDevice(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var records []db.Attendance
+	if err := tx.Where("id IN ? AND (review_status IS NULL OR review_status = '' OR review_status = ?)", ids, db.ReviewPending).
+		Find(&records).Error; err != nil {
+		return err
+	}
+	for i := range records {
+		record := &records[i]
+		if strings.Contains(record.Flags, db.FlagSharedDevice) {
+			continue
+		}
+		if record.Flags != "" {
+			record.Flags += ","
+		}
+		record.Flags += db.FlagSharedDevice
+		record.ReviewStatus = db.ReviewPending
+		record.SetStatus(db.AttendanceAbsent)
+		record.Audit(0, "Check-in flagged: device used by another student")
+		if err := tx.Save(record).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insideAny(fences []db.Geofence, point geo.Point) bool {
+	for _, fence := range fences {
+		radius := fence.RadiusMeters
+		if radius <= 0 {
+			radius = config.AppConfig.Attendance.GeofenceRadius
+		}
+		if fence.Polygon.Within(point, radius) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetGeofences godoc
+// @Summary      List geofences
+// @Tags         geofences
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array}
+// @Router       /geofences [get]
+func (h *AttendanceHandler) GetGeofences(c *gin.Context) {
+	var fences []db.Geofence
+	h.DB.Order("kind, name").Find(&fences)
+
+	c.JSON(http.StatusOK, gin.H{"data": fences})
+}
+
+// CreateGeofence godoc
+// @Summary      Create geofence
+// @Description  Create a campus or classroom area for check-ins. A single point with a radius describes a circle.
+// @Tags         geofences
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateGeofenceRequest  true  "Geofence"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /geofences [post]
+func (h *AttendanceHandler) CreateGeofence(c *gin.Context) {
+	var req dto.CreateGeofenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kind := db.GeofenceCampus
+	if req.Kind != "" {
+		kind = db.GeofenceKind(req.Kind)
+	}
+	if kind != db.GeofenceCampus && kind != db.GeofenceClassroom {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be campus or classroom"})
+		return
+	}
+	if len(req.Polygon) == 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "polygon needs one point (circle) or at least three points"})
+		return
+	}
+
+	polygon := make(geo.Polygon, 0, len(req.Polygon))
+	for _, p := range req.Polygon {
+		point := geo.Point{Lat: p.Lat, Lng: p.Lng}
+		if !point.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "polygon contains an invalid coordinate"})
+			return
+		}
+		polygon = append(polygon, point)
+	}
+
+	radius := config.AppConfig.Attendance.GeofenceRadius
+	if req.RadiusMeters != nil {
+		if *req.RadiusMeters < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "radius_meters cannot be negative"})
+			return
+		}
+		radius = *req.RadiusMeters
+	}
+
+	fence := db.Geofence{Name: req.Name, Kind: kind, Polygon: polygon, RadiusMeters: radius}
+	if err := h.DB.Create(&fence).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create geofence"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Geofence created successfully", "data": fence})
+}
+
+// DeleteGeofence godoc
+// @Summary      Delete geofence
+// @Description  Delete a geofence; sessions using it fall back to the campus fences
+// @Tags         geofences
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Geofence ID"
+// @Success      200  {object}  object{message=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /geofences/{id} [delete]
+func (h *AttendanceHandler) DeleteGeofence(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid geofence ID"})
+		return
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&db.ClassSession{}).Where("geofence_id = ?", id).Update("geofence_id", nil).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&db.Geofence{}, id)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return result.Error
+	})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Geofence not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Geofence deleted successfully"})
+}
+
+// RegisterDevice godoc
+// @Summary      Register my device
+// @Description  Bind the authenticated student to a device identifier. A bound device can only be changed by an admin.
+// @Tags         devices
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.RegisterDeviceRequest  true  "Device"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      409      {object}  object{error=string}
+// @Router       /devices [post]
+func (h *AttendanceHandler) RegisterDevice(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var device db.Device
+	if err := h.DB.Where("user_id = ?", uid).First(&device).Error; err == nil {
+		if device.DeviceID == req.DeviceID {
+			c.JSON(http.StatusOK, gin.H{"message": "Device already registered", "data": device})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Another device is registered to your account, ask an admin to reset it"})
+		return
+	}
+
+	device = db.Device{UserID: uid, DeviceID: req.DeviceID, Label: req.Label}
+	if err := h.DB.Create(&device).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Another device is registered to your account, ask an admin to reset it"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Device registered successfully", "data": device})
+}
+
+// GetMyDevice godoc
+// @Summary      Get my device
+// @Tags         devices
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=object}
+// @Failure      404  {object}  object{error=string}
+// @Router       /devices/my [get]
+func (h *AttendanceHandler) GetMyDevice(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var device db.Device
+	if err := h.DB.Where("user_id = ?", uid).First(&device).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No device registered"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": device})
+}
+
+// ResetDevice godoc
+// @Summary      Reset a user's device
+// @Description  Remove a user's device binding so their next device can be registered (admin only)
+// @Tags         devices
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  object{message=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /users/{id}/device [delete]
+func (h *AttendanceHandler) ResetDevice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	result := h.DB.Where("user_id = ?", id).Delete(&db.Device{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No device registered"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Device binding removed"})
+}
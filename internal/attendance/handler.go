@@ -1,29 +1,36 @@
 package attendance
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"time"
 
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/calendar"
 	"attendance-workflow/internal/dto"
 	"attendance-workflow/internal/notifications"
+	"attendance-workflow/internal/stats"
+	"attendance-workflow/pkg/clock"
 	"attendance-workflow/pkg/db"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AttendanceHandler struct {
-	DB db.GormDB
+	DB       db.GormDB
+	Calendar *calendar.Service
+	Stats    *stats.Service
 }
 
 func NewAttendanceHandler() *AttendanceHandler {
-	return &AttendanceHandler{DB: db.DB}
+	return &AttendanceHandler{DB: db.DB, Calendar: calendar.NewService(db.DB), Stats: stats.NewService(db.DB)}
 }
 
 // MarkAttendance godoc
 // @Summary      Mark attendance
-// @Description  Mark attendance for a student
+// @Description  Mark daily attendance for a student, or attendance for a class session when session_id is given. With arrived_at the student is present or late depending on the session's grace period.
 // @Tags         attendance
 // @Accept       json
 // @Produce      json
@@ -33,6 +40,7 @@ func NewAttendanceHandler() *AttendanceHandler {
 // @Success      200      {object}  object{message=string,data=object}
 // @Failure      400      {object}  object{error=string}
 // @Failure      401      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string}
 // @Router       /attendance/mark [post]
 func (h *AttendanceHandler) MarkAttendance(c *gin.Context) {
 	markedBy, ok := c.Get("user_id")
@@ -53,18 +61,110 @@ func (h *AttendanceHandler) MarkAttendance(c *gin.Context) {
 		return
 	}
 
+	status, ok := resolveStatus(req.Status, req.Present)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attendance status"})
+		return
+	}
+
+	var session *db.ClassSession
+	if req.SessionID != nil {
+		session, ok = h.sessionForMarking(c, *req.SessionID, uid)
+		if !ok {
+			return
+		}
+
+		var enrolled int64
+		h.DB.Model(&db.Enrollment{}).
+			Where("course_id = ? AND section = ? AND student_id = ?", session.CourseID, session.Section, req.StudentID).
+			Count(&enrolled)
+		if enrolled == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Student is not enrolled in this session's course section"})
+			return
+		}
+
+		req.Date.Time = session.Date
+	} else if req.Date.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date is required unless session_id is given"})
+		return
+	}
+
+	// An arrival time decides between present and late
+	var arrival db.Attendance
+	if req.ArrivedAt != nil {
+		if session == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "arrived_at requires session_id"})
+			return
+		}
+		arrival.SetStatus(status)
+		if req.Status == "" || status == db.AttendancePresent || status == db.AttendanceLate {
+			arrival.RecordArrival(session, *req.ArrivedAt)
+		}
+		status = arrival.Status
+	}
+
+	if !h.requireUnlocked(c, req.Date.Time) {
+		return
+	}
+
+	var student db.User
+	h.DB.Select("id", "dept").First(&student, req.StudentID)
+	if !h.requireWorkingDay(c, h.markingDept(req.SessionID, student.Dept), req.Date.Time) {
+		return
+	}
+
+	// Approved leave owns these days, marks must go through the leave
+	if status != db.AttendanceOnLeave {
+		if _, onLeave := h.approvedLeaves([]uint{req.StudentID}, req.Date.Time)[req.StudentID]; onLeave {
+			c.JSON(http.StatusConflict, gin.H{"error": "Student is on approved leave on this date, revoke the leave to mark attendance"})
+			return
+		}
+	}
+
 	attendance := db.Attendance{
 		StudentID: req.StudentID,
+		SessionID: req.SessionID,
 		Date:      req.Date.Time,
-		Present:   req.Present,
 		MarkedBy:  uint(uid),
 	}
+	attendance.SetStatus(status)
+	attendance.Audit(uid, req.Reason)
+	if req.ArrivedAt != nil {
+		attendance.CheckedInAt, attendance.MinutesLate = req.ArrivedAt, arrival.MinutesLate
+	}
 
 	// Check if attendance already marked
+	existingQuery := h.DB.Where("student_id = ?", req.StudentID)
+	if req.SessionID != nil {
+		existingQuery = existingQuery.Where("session_id = ?", *req.SessionID)
+	} else {
+		existingQuery = existingQuery.Where("date = ? AND session_id IS NULL", req.Date.Time)
+	}
+
 	var existing db.Attendance
-	if err := h.DB.Where("student_id = ? AND date = ?", req.StudentID, req.Date.Time).First(&existing).Error; err == nil {
-		// Update existing
-		existing.Present = req.Present
+	if err := existingQuery.First(&existing).Error; err == nil {
+		if existing.Status != status && req.Reason == "" && editNeedsReason(existing.Date) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required to change attendance after the day it was taken"})
+			return
+		}
+
+		// Update existing, recording who changed it
+		existing.SetStatus(status)
+		existing.MarkedBy = uid
+		existing.Audit(uid, req.Reason)
+		if req.ArrivedAt != nil {
+			existing.CheckedInAt, existing.MinutesLate = req.ArrivedAt, arrival.MinutesLate
+		} else if status != db.AttendanceLate {
+			existing.MinutesLate = 0
+		}
+		if existing.ReviewStatus == db.ReviewPending {
+			// Marking a flagged check-in settles its review
+			existing.ReviewedBy = &uid
+			existing.ReviewStatus = db.ReviewRejected
+			if status.Attended() {
+				existing.ReviewStatus = db.ReviewAccepted
+			}
+		}
 		if err := h.DB.Save(&existing).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update attendance"})
 			return
@@ -74,6 +174,11 @@ func (h *AttendanceHandler) MarkAttendance(c *gin.Context) {
 	}
 
 	if err := h.DB.Create(&attendance).Error; err != nil {
+		// Someone else marked the same record since it was looked up
+		if db.IsDuplicateKey(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Attendance was marked by someone else at the same time, please retry"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark attendance"})
 		return
 	}
@@ -87,6 +192,7 @@ func (h *AttendanceHandler) MarkAttendance(c *gin.Context) {
 			StudentID: attendance.StudentID,
 			Date:      attendance.Date,
 			Present:   attendance.Present,
+			Status:    string(attendance.Status),
 			MarkedBy:  marker.Name,
 		}); err != nil {
 			log.Printf("Failed to queue attendance notification: %v", err)
@@ -96,9 +202,87 @@ func (h *AttendanceHandler) MarkAttendance(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "Attendance marked successfully", "data": attendance})
 }
 
+var errReasonRequired = errors.New("reason required")
+
+// editNeedsReason reports whether changing attendance taken on date needs a
+// reason, which is the case from the day after
+func editNeedsReason(date time.Time) bool {
+	return clock.Date(date).Before(clock.Today())
+}
+
+// approvedLeaves maps each student on approved leave on date to the leave ID
+func (h *AttendanceHandler) approvedLeaves(studentIDs []uint, date time.Time) map[uint]uint {
+	var leaves []db.LeaveRequest
+	h.DB.Where("student_id IN ? AND status IN ? AND start_date <= ? AND end_date >= ?",
+		studentIDs, db.LeaveInEffect, date, date).
+		Find(&leaves)
+
+	result := make(map[uint]uint, len(leaves))
+	for _, leave := range leaves {
+		result[leave.StudentID] = leave.ID
+	}
+	return result
+}
+
+// resolveStatus validates a requested status, falling back to the legacy
+// present flag when no status is given
+func resolveStatus(status string, present bool) (db.AttendanceStatus, bool) {
+	if status == "" {
+		return db.StatusFromPresent(present), true
+	}
+	resolved := db.AttendanceStatus(status)
+	return resolved, resolved.Valid()
+}
+
+// markingDept is the department whose calendar applies to a mark: the
+// course's department for session marks, otherwise the given one
+func (h *AttendanceHandler) markingDept(sessionID *uint, dept string) string {
+	if sessionID == nil {
+		return dept
+	}
+	var course db.Course
+	h.DB.Joins("JOIN class_sessions ON class_sessions.course_id = courses.id").
+		Where("class_sessions.id = ?", *sessionID).
+		Select("courses.dept").
+		First(&course)
+	return course.Dept
+}
+
+// requireWorkingDay writes a 400 response and returns false when date is not
+// a working day for dept
+func (h *AttendanceHandler) requireWorkingDay(c *gin.Context, dept string, date time.Time) bool {
+	if working, reason := h.Calendar.IsWorkingDay(dept, date); !working {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot mark attendance on a non-working day (" + reason + ")"})
+		return false
+	}
+	return true
+}
+
+// sessionForMarking loads a class session the caller is allowed to mark,
+// writing the error response and returning false otherwise
+func (h *AttendanceHandler) sessionForMarking(c *gin.Context, sessionID uint, uid uint) (*db.ClassSession, bool) {
+	var session db.ClassSession
+	if err := h.DB.First(&session, sessionID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+
+	role, _ := c.Get("role")
+	if session.FacultyID != uid && role != string(db.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the session's faculty can mark its attendance"})
+		return nil, false
+	}
+	if session.Status == db.SessionCancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session has been cancelled"})
+		return nil, false
+	}
+
+	return &session, true
+}
+
 // GetStudentAttendance godoc
 // @Summary      Get student attendance
-// @Description  Get attendance records for a specific student
+// @Description  Get attendance records for a specific student, with stats over the same range: derived days, course, weekly and monthly breakdowns, punctuality and absence streaks
 // @Tags         attendance
 // @Accept       json
 // @Produce      json
@@ -106,7 +290,8 @@ func (h *AttendanceHandler) MarkAttendance(c *gin.Context) {
 // @Param        id         path      int     true   "Student ID"
 // @Param        start_date query     string  false  "Start date (YYYY-MM-DD)"
 // @Param        end_date   query     string  false  "End date (YYYY-MM-DD)"
-// @Success      200        {object}  object{data=array,stats=object}
+// @Success      200        {object}  object{data=array,daily=array,stats=object}
+// @Failure      400        {object}  object{error=string}
 // @Failure      401        {object}  object{error=string}
 // @Router       /attendance/student/{id} [get]
 func (h *AttendanceHandler) GetStudentAttendance(c *gin.Context) {
@@ -115,94 +300,49 @@ func (h *AttendanceHandler) GetStudentAttendance(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid student ID"})
 		return
 	}
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-
-	var attendance []db.Attendance
-	query := h.DB.Where("student_id = ?", studentID)
-
-	if startDate != "" {
-		query = query.Where("date >= ?", startDate)
-	}
-	if endDate != "" {
-		query = query.Where("date <= ?", endDate)
-	}
-
-	query.Order("date DESC").Find(&attendance)
-
-	// Calculate stats
-	var presentCount int64
-	var totalCount int64
-	h.DB.Model(&db.Attendance{}).Where("student_id = ?", studentID).Where("present = ?", true).Count(&presentCount)
-	h.DB.Model(&db.Attendance{}).Where("student_id = ?", studentID).Count(&totalCount)
-
-	var percentage float64
-	if totalCount > 0 {
-		percentage = float64(presentCount) / float64(totalCount) * 100
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": attendance,
-		"stats": gin.H{
-			"present_days":          presentCount,
-			"total_days":            totalCount,
-			"attendance_percentage": percentage,
-		},
-	})
+	h.respondWithStats(c, uint(studentID))
 }
 
 // GetMyAttendance godoc
 // @Summary      Get my attendance
-// @Description  Get attendance records for the authenticated student
+// @Description  Get attendance records for the authenticated student, with stats over the same range
 // @Tags         attendance
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        start_date query     string  false  "Start date (YYYY-MM-DD)"
 // @Param        end_date   query     string  false  "End date (YYYY-MM-DD)"
-// @Success      200        {object}  object{data=array,stats=object}
+// @Success      200        {object}  object{data=array,daily=array,stats=object}
+// @Failure      400        {object}  object{error=string}
 // @Failure      401        {object}  object{error=string}
 // @Router       /attendance/my [get]
 func (h *AttendanceHandler) GetMyAttendance(c *gin.Context) {
-	userID, ok := c.Get("user_id")
+	uid, ok := auth.CurrentUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
 		return
 	}
+	h.respondWithStats(c, uid)
+}
 
-	var attendance []db.Attendance
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-
-	query := h.DB.Where("student_id = ?", userID)
-
-	if startDate != "" {
-		query = query.Where("date >= ?", startDate)
-	}
-	if endDate != "" {
-		query = query.Where("date <= ?", endDate)
+// respondWithStats writes a student's records, derived days and stats for
+// the requested range
+func (h *AttendanceHandler) respondWithStats(c *gin.Context, studentID uint) {
+	r, err := stats.ParseRange(c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	query.Order("date DESC").Find(&attendance)
-
-	// Calculate stats
-	var presentCount int64
-	var totalCount int64
-	h.DB.Model(&db.Attendance{}).Where("student_id = ?", userID).Where("present = ?", true).Count(&presentCount)
-	h.DB.Model(&db.Attendance{}).Where("student_id = ?", userID).Count(&totalCount)
-
-	var percentage float64
-	if totalCount > 0 {
-		percentage = float64(presentCount) / float64(totalCount) * 100
+	report, err := h.Stats.ForStudent(studentID, r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data": attendance,
-		"stats": gin.H{
-			"present_days":          presentCount,
-			"total_days":            totalCount,
-			"attendance_percentage": percentage,
-		},
+		"data":  report.Records,
+		"daily": report.Daily,
+		"stats": report.Stats,
 	})
 }
 
@@ -215,16 +355,20 @@ func (h *AttendanceHandler) GetMyAttendance(c *gin.Context) {
 // @Security     BearerAuth
 // @Param        date   query     string  false  "Date (YYYY-MM-DD), defaults to today"
 // @Success      200    {object}  object{date=string,data=array}
+// @Failure      400    {object}  object{error=string}
 // @Failure      401    {object}  object{error=string}
 // @Router       /attendance/daily [get]
 func (h *AttendanceHandler) GetDailyAttendance(c *gin.Context) {
 	date := c.Query("date")
 	if date == "" {
-		date = time.Now().Format("2006-01-02")
+		date = clock.Today().Format("2006-01-02")
+	} else if _, err := stats.ParseDate("date", date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	var attendance []db.Attendance
-	h.DB.Where("date = ?", date).Preload("Student").Find(&attendance)
+	h.DB.Where("date = ?", date).Preload("Student").Preload("Session.Course").Find(&attendance)
 
 	c.JSON(http.StatusOK, gin.H{
 		"date": date,
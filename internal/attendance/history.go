@@ -0,0 +1,51 @@
+package attendance
+
+import (
+	"net/http"
+	"strconv"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAttendanceHistory godoc
+// @Summary      Get attendance history
+// @Description  Get every recorded change of an attendance record, oldest first, including the record's deletion. Students can only see their own records.
+// @Tags         attendance
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Attendance ID"
+// @Success      200  {object}  object{data=array}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /attendance/{id}/history [get]
+func (h *AttendanceHandler) GetAttendanceHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attendance ID"})
+		return
+	}
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	// History outlives the record, so it is looked up on its own
+	var entries []db.AttendanceHistory
+	h.DB.Preload("Actor").Where("attendance_id = ?", id).Order("created_at, id").Find(&entries)
+	if len(entries) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+		return
+	}
+
+	role, _ := c.Get("role")
+	if role == string(db.RoleStudent) && entries[0].StudentID != uid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own attendance history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": clock.In(entries, auth.Zone(c))})
+}
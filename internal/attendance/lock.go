@@ -0,0 +1,28 @@
+package attendance
+
+import (
+	"net/http"
+	"time"
+
+	"attendance-workflow/internal/calendar"
+
+	"github.com/gin-gonic/gin"
+)
+
+const lockedMessage = calendar.LockedMessage
+
+// locked reports whether attendance on date is locked, see
+// calendar.Service.LockedAt
+func (h *AttendanceHandler) locked(date time.Time) bool {
+	return h.Calendar.Locked(date)
+}
+
+// requireUnlocked responds with 403 and returns false when attendance on
+// date is locked
+func (h *AttendanceHandler) requireUnlocked(c *gin.Context, date time.Time) bool {
+	if h.locked(date) {
+		c.JSON(http.StatusForbidden, gin.H{"error": lockedMessage})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,689 @@
+package attendance
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/db"
+	"attendance-workflow/pkg/xlsx"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Register cells use the short codes of paper registers. Imports also accept
+// the full status names, and blank cells leave attendance as it is.
+var registerCodes = map[db.AttendanceStatus]string{
+	db.AttendancePresent: "P",
+	db.AttendanceAbsent:  "A",
+	db.AttendanceLate:    "L",
+	db.AttendanceExcused: "E",
+	db.AttendanceOnLeave: "OL",
+	db.AttendanceOnDuty:  "OD",
+	db.AttendanceHalfDay: "HD",
+}
+
+// maxRegisterSize bounds uploaded register files
+const maxRegisterSize = 5 << 20
+
+var errRegisterInvalid = errors.New("register has errors")
+
+// registerColumn is a session column of a register
+type registerColumn struct {
+	Header  string
+	Session db.ClassSession
+}
+
+// registerChange is the preview of one register cell against the stored
+// attendance
+type registerChange struct {
+	Row       int                 `json:"row"`
+	StudentID uint                `json:"student_id"`
+	Name      string              `json:"name,omitempty"`
+	SessionID uint                `json:"session_id"`
+	Column    string              `json:"column"`
+	From      db.AttendanceStatus `json:"from,omitempty"`
+	To        db.AttendanceStatus `json:"to,omitempty"`
+	Value     string              `json:"value,omitempty"` // the cell as written, for invalid cells
+	Result    string              `json:"result"`
+	Error     string              `json:"error,omitempty"`
+
+	record *db.Attendance
+}
+
+// registerProblem is a register row that does not match an enrolled student
+type registerProblem struct {
+	Row       int    `json:"row"`
+	StudentID string `json:"student_id"`
+	Name      string `json:"name,omitempty"`
+	Error     string `json:"error"`
+}
+
+type registerDiff struct {
+	Summary         map[string]int    `json:"summary"`
+	Changes         []registerChange  `json:"changes"` // created, updated and invalid cells
+	UnknownStudents []registerProblem `json:"unknown_students"`
+}
+
+func (d *registerDiff) valid() bool {
+	return d.Summary[rowInvalid] == 0 && len(d.UnknownStudents) == 0
+}
+
+// ExportRegister godoc
+// @Summary      Export attendance register
+// @Description  Download a course section's session attendance as a register: one row per enrolled student, one column per session. Cells hold P, A, L, E, OL, OD or HD and are blank when unmarked. Columns are headed by the session date, with " P<period>" added when the section meets more than once that day. For the section's faculty, the HOD of the course's department or an admin.
+// @Tags         attendance
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security     BearerAuth
+// @Param        course_id   query     int     true   "Course ID"
+// @Param        section     query     string  true   "Section"
+// @Param        start_date  query     string  false  "Start date (YYYY-MM-DD), defaults to the start of the current term"
+// @Param        end_date    query     string  false  "End date (YYYY-MM-DD), defaults to the end of the current term"
+// @Param        format      query     string  false  "csv (default) or xlsx"
+// @Success      200
+// @Failure      400         {object}  object{error=string}
+// @Failure      403         {object}  object{error=string}
+// @Router       /attendance/register [get]
+func (h *AttendanceHandler) ExportRegister(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+	course, ok := h.registerCourse(c, c.Query("course_id"), c.Query("section"))
+	if !ok {
+		return
+	}
+	if !h.canViewRegister(c, course, c.Query("section")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot view the register of this section"})
+		return
+	}
+	from, to, ok := h.registerRange(c)
+	if !ok {
+		return
+	}
+	section := c.Query("section")
+
+	var sessions []db.ClassSession
+	h.DB.Where("course_id = ? AND section = ? AND status <> ? AND date BETWEEN ? AND ?",
+		course.ID, section, db.SessionCancelled, from, to).
+		Order("date, period").
+		Find(&sessions)
+	columns := registerColumns(sessions)
+
+	var enrollments []db.Enrollment
+	h.DB.Preload("Student").
+		Where("course_id = ? AND section = ?", course.ID, section).
+		Order("student_id").
+		Find(&enrollments)
+
+	type cell struct{ student, session uint }
+	marks := make(map[cell]db.AttendanceStatus)
+	if len(sessions) > 0 {
+		ids := make([]uint, len(sessions))
+		for i, session := range sessions {
+			ids[i] = session.ID
+		}
+		var records []db.Attendance
+		h.DB.Where("session_id IN ?", ids).Find(&records)
+		for _, record := range records {
+			marks[cell{record.StudentID, *record.SessionID}] = record.Status
+		}
+	}
+
+	header := []string{"student_id", "name"}
+	for _, column := range columns {
+		header = append(header, column.Header)
+	}
+	rows := [][]string{header}
+	for _, enrollment := range enrollments {
+		row := []string{strconv.FormatUint(uint64(enrollment.StudentID), 10), enrollment.Student.Name}
+		for _, column := range columns {
+			status, marked := marks[cell{enrollment.StudentID, column.Session.ID}]
+			if !marked {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, registerCodes[status])
+		}
+		rows = append(rows, row)
+	}
+
+	filename := fmt.Sprintf("register-%s-%s-%s-%s.%s", course.Code, section,
+		from.Format("2006-01-02"), to.Format("2006-01-02"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if format == "xlsx" {
+		c.Header("Content-Type", xlsx.ContentType)
+		if err := xlsx.Write(c.Writer, course.Code+" "+section, rows); err != nil {
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+	c.Header("Content-Type", "text/csv")
+	w := csv.NewWriter(c.Writer)
+	w.WriteAll(rows)
+}
+
+// PreviewRegisterImport godoc
+// @Summary      Preview register import
+// @Description  Validate a register in the export format and compare it with the stored attendance without changing anything. Lists the cells that would be created or updated, invalid cells and rows that are not enrolled students.
+// @Tags         attendance
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file       formData  file    true   "Register (.csv or .xlsx)"
+// @Param        course_id  formData  int     true   "Course ID"
+// @Param        section    formData  string  true   "Section"
+// @Param        format     formData  string  false  "csv or xlsx, by default taken from the file name"
+// @Success      200        {object}  object{data=object}
+// @Failure      400        {object}  object{error=string,details=array}
+// @Failure      403        {object}  object{error=string}
+// @Router       /attendance/register/preview [post]
+func (h *AttendanceHandler) PreviewRegisterImport(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	course, columns, rows, ok := h.readRegisterUpload(c, uid)
+	if !ok {
+		return
+	}
+
+	diff, err := h.diffRegister(h.DB, course, c.PostForm("section"), columns, rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare register"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": diff})
+}
+
+// ImportRegister godoc
+// @Summary      Import register
+// @Description  Apply a register in the export format. The register is compared again when importing and nothing is saved if any cell is invalid or any row is not an enrolled student. Every change is recorded in the attendance history; changes to past days need a reason.
+// @Tags         attendance
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file       formData  file    true   "Register (.csv or .xlsx)"
+// @Param        course_id  formData  int     true   "Course ID"
+// @Param        section    formData  string  true   "Section"
+// @Param        format     formData  string  false  "csv or xlsx, by default taken from the file name"
+// @Param        reason     formData  string  false  "Reason for changing past attendance"
+// @Success      200        {object}  object{message=string,data=object}
+// @Failure      400        {object}  object{error=string,data=object}
+// @Failure      403        {object}  object{error=string}
+// @Router       /attendance/register/import [post]
+func (h *AttendanceHandler) ImportRegister(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	course, columns, rows, ok := h.readRegisterUpload(c, uid)
+	if !ok {
+		return
+	}
+	reason := strings.TrimSpace(c.PostForm("reason"))
+
+	var diff *registerDiff
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		diff, err = h.diffRegister(tx, course, c.PostForm("section"), columns, rows)
+		if err != nil {
+			return err
+		}
+		if !diff.valid() {
+			return errRegisterInvalid
+		}
+
+		var created []db.Attendance
+		for _, change := range diff.Changes {
+			record := change.record
+			switch change.Result {
+			case rowCreated:
+				record.MarkedBy = uid
+				record.SetStatus(change.To)
+				record.Audit(uid, reason)
+				created = append(created, *record)
+			case rowUpdated:
+				if reason == "" && editNeedsReason(record.Date) {
+					return errReasonRequired
+				}
+				record.MarkedBy = uid
+				record.SetStatus(change.To)
+				record.Audit(uid, reason)
+				// Saved one by one so each change lands in the record's history
+				if err := tx.Save(record).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if len(created) > 0 {
+			return tx.CreateInBatches(&created, 100).Error
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errRegisterInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Register has invalid cells or unknown students, nothing was imported", "data": diff})
+	case errors.Is(err, errReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required to change attendance after the day it was taken"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import register"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Register imported", "data": diff})
+	}
+}
+
+// registerCourse loads the course of a register request, writing the error
+// response and returning false when it is missing
+func (h *AttendanceHandler) registerCourse(c *gin.Context, courseID, section string) (*db.Course, bool) {
+	if courseID == "" || section == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "course_id and section are required"})
+		return nil, false
+	}
+	var course db.Course
+	if err := h.DB.First(&course, courseID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return nil, false
+	}
+	return &course, true
+}
+
+// canViewRegister allows admins, the HOD of the course's department and
+// faculty teaching the section
+func (h *AttendanceHandler) canViewRegister(c *gin.Context, course *db.Course, section string) bool {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	switch role {
+	case string(db.RoleAdmin):
+		return true
+	case string(db.RoleHOD):
+		var hod db.User
+		return h.DB.First(&hod, userID).Error == nil && hod.Dept != "" && hod.Dept == course.Dept
+	case string(db.RoleFaculty):
+		var teaches int64
+		h.DB.Model(&db.ClassSession{}).
+			Where("course_id = ? AND section = ? AND faculty_id = ?", course.ID, section, userID).
+			Count(&teaches)
+		return teaches > 0
+	}
+	return false
+}
+
+// registerRange reads start_date and end_date, defaulting to the current
+// term
+func (h *AttendanceHandler) registerRange(c *gin.Context) (time.Time, time.Time, bool) {
+	var from, to time.Time
+	if term, ok := h.Calendar.TermOn(clock.Today()); ok {
+		from, to = term.StartDate, term.EndDate
+	}
+	for param, target := range map[string]*time.Time{"start_date": &from, "end_date": &to} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " format. Use YYYY-MM-DD"})
+			return from, to, false
+		}
+		*target = parsed
+	}
+	if from.IsZero() || to.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required outside an academic term"})
+		return from, to, false
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// registerColumns heads each session with its date, adding the period when
+// the section meets more than once that day
+func registerColumns(sessions []db.ClassSession) []registerColumn {
+	perDay := make(map[string]int)
+	for _, session := range sessions {
+		perDay[session.Date.Format("2006-01-02")]++
+	}
+	columns := make([]registerColumn, len(sessions))
+	for i, session := range sessions {
+		header := session.Date.Format("2006-01-02")
+		if perDay[header] > 1 {
+			header = fmt.Sprintf("%s P%d", header, session.Period)
+		}
+		columns[i] = registerColumn{Header: header, Session: session}
+	}
+	return columns
+}
+
+// readRegisterUpload parses an uploaded register and resolves its header to
+// sessions the caller can mark. On failure it writes the error response;
+// header problems are listed in details.
+func (h *AttendanceHandler) readRegisterUpload(c *gin.Context, uid uint) (*db.Course, []registerColumn, [][]string, bool) {
+	course, ok := h.registerCourse(c, c.PostForm("course_id"), c.PostForm("section"))
+	if !ok {
+		return nil, nil, nil, false
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Register file is required"})
+		return nil, nil, nil, false
+	}
+	if header.Size > maxRegisterSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Register file is too large"})
+		return nil, nil, nil, false
+	}
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = "csv"
+		if strings.EqualFold(filepath.Ext(header.Filename), ".xlsx") {
+			format = "xlsx"
+		}
+	}
+	// A register holds a header and a row per student, with the student_id
+	// and name columns and a column per session of the section
+	var students, sessions int64
+	h.DB.Model(&db.Enrollment{}).Where("course_id = ? AND section = ?", course.ID, c.PostForm("section")).Count(&students)
+	h.DB.Model(&db.ClassSession{}).Where("course_id = ? AND section = ? AND status <> ?", course.ID, c.PostForm("section"), db.SessionCancelled).Count(&sessions)
+	limits := xlsx.Limits{Rows: int(students) + 1, Columns: int(sessions) + 2}
+
+	grid, err := readRegisterFile(header, format, limits)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid register file: " + err.Error()})
+		return nil, nil, nil, false
+	}
+	if len(grid) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Register file is empty"})
+		return nil, nil, nil, false
+	}
+
+	role, _ := c.Get("role")
+	columns, problems := h.resolveRegisterHeader(course.ID, c.PostForm("section"), grid[0], uid, role == string(db.RoleAdmin))
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid register header", "details": problems})
+		return nil, nil, nil, false
+	}
+	return course, columns, grid[1:], true
+}
+
+func readRegisterFile(header *multipart.FileHeader, format string, limits xlsx.Limits) ([][]string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch format {
+	case "xlsx":
+		return xlsx.Read(file, header.Size, limits)
+	case "csv":
+		content, err := io.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+		r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))))
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		return r.ReadAll()
+	}
+	return nil, fmt.Errorf("unsupported format %q, use csv or xlsx", format)
+}
+
+// resolveRegisterHeader checks the student_id and name columns and maps
+// every other column to a session of the section. A date alone must match
+// a single session that day; otherwise the period is required.
+func (h *AttendanceHandler) resolveRegisterHeader(courseID uint, section string, header []string, uid uint, isAdmin bool) ([]registerColumn, []string) {
+	var problems []string
+	if len(header) < 3 || !strings.EqualFold(strings.TrimSpace(header[0]), "student_id") || !strings.EqualFold(strings.TrimSpace(header[1]), "name") {
+		return nil, []string{"The first columns must be student_id and name, followed by at least one session column"}
+	}
+
+	var sessions []db.ClassSession
+	h.DB.Where("course_id = ? AND section = ? AND status <> ?", courseID, section, db.SessionCancelled).Find(&sessions)
+	byDay := make(map[string][]db.ClassSession)
+	for _, session := range sessions {
+		day := session.Date.Format("2006-01-02")
+		byDay[day] = append(byDay[day], session)
+	}
+
+	columns := make([]registerColumn, 0, len(header)-2)
+	seen := make(map[uint]string)
+	for i, raw := range header[2:] {
+		label := strings.TrimSpace(raw)
+		ref := fmt.Sprintf("column %d (%q)", i+3, label)
+		day, period, err := parseRegisterHeader(label)
+		if err != nil {
+			problems = append(problems, ref+": "+err.Error())
+			continue
+		}
+
+		var matches []db.ClassSession
+		for _, session := range byDay[day] {
+			if period == 0 || session.Period == period {
+				matches = append(matches, session)
+			}
+		}
+		switch {
+		case len(matches) == 0:
+			problems = append(problems, ref+": no session of this section")
+			continue
+		case len(matches) > 1:
+			problems = append(problems, ref+": the section has several sessions that day, add the period as \"YYYY-MM-DD P<period>\"")
+			continue
+		}
+
+		session := matches[0]
+		if previous, dup := seen[session.ID]; dup {
+			problems = append(problems, fmt.Sprintf("%s: same session as %s", ref, previous))
+			continue
+		}
+		seen[session.ID] = ref
+		if !isAdmin && session.FacultyID != uid {
+			problems = append(problems, ref+": only the session's faculty can mark its attendance")
+			continue
+		}
+		columns = append(columns, registerColumn{Header: label, Session: session})
+	}
+	return columns, problems
+}
+
+// parseRegisterHeader reads "YYYY-MM-DD" or "YYYY-MM-DD P<period>". Dates
+// that a spreadsheet turned into serial numbers are accepted too.
+func parseRegisterHeader(label string) (string, int, error) {
+	datePart, periodPart := label, ""
+	if i := strings.IndexByte(label, ' '); i >= 0 {
+		datePart, periodPart = label[:i], strings.TrimSpace(label[i+1:])
+	}
+
+	var period int
+	if periodPart != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(periodPart), "P"))
+		if err != nil || n < 0 {
+			return "", 0, errors.New("invalid period, use P<period>")
+		}
+		period = n
+	}
+
+	if date, err := time.Parse("2006-01-02", datePart); err == nil {
+		return date.Format("2006-01-02"), period, nil
+	}
+	if serial, err := strconv.ParseFloat(datePart, 64); err == nil && serial > 0 {
+		date := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(math.Floor(serial)))
+		return date.Format("2006-01-02"), period, nil
+	}
+	return "", 0, errors.New("not a session date, use YYYY-MM-DD")
+}
+
+// parseRegisterCell maps a register code or status name to a status
+func parseRegisterCell(value string) (db.AttendanceStatus, bool) {
+	value = strings.TrimSpace(value)
+	for status, code := range registerCodes {
+		if strings.EqualFold(value, code) {
+			return status, true
+		}
+	}
+	status := db.AttendanceStatus(strings.ToLower(value))
+	return status, status.Valid()
+}
+
+// diffRegister compares the register rows with the stored attendance of the
+// columns' sessions
+func (h *AttendanceHandler) diffRegister(tx *gorm.DB, course *db.Course, section string, columns []registerColumn, rows [][]string) (*registerDiff, error) {
+	diff := &registerDiff{
+		Summary:         map[string]int{rowCreated: 0, rowUpdated: 0, rowUnchanged: 0, rowInvalid: 0},
+		Changes:         []registerChange{},
+		UnknownStudents: []registerProblem{},
+	}
+
+	var enrollments []db.Enrollment
+	if err := tx.Preload("Student").Where("course_id = ? AND section = ?", course.ID, section).Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	enrolled := make(map[uint]db.User, len(enrollments))
+	studentIDs := make([]uint, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		enrolled[enrollment.StudentID] = enrollment.Student
+		studentIDs = append(studentIDs, enrollment.StudentID)
+	}
+
+	sessionIDs := make([]uint, len(columns))
+	var first, last time.Time
+	for i, column := range columns {
+		sessionIDs[i] = column.Session.ID
+		if first.IsZero() || column.Session.Date.Before(first) {
+			first = column.Session.Date
+		}
+		if column.Session.Date.After(last) {
+			last = column.Session.Date
+		}
+	}
+
+	type cell struct{ student, session uint }
+	var existing []db.Attendance
+	if err := tx.Where("session_id IN ?", sessionIDs).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	stored := make(map[cell]db.Attendance, len(existing))
+	for _, record := range existing {
+		stored[cell{record.StudentID, *record.SessionID}] = record
+	}
+
+	var leaves []db.LeaveRequest
+	if err := tx.Where("student_id IN ? AND status IN ? AND start_date <= ? AND end_date >= ?",
+		studentIDs, db.LeaveInEffect, last, first).Find(&leaves).Error; err != nil {
+		return nil, err
+	}
+	onLeave := func(studentID uint, date time.Time) bool {
+		for _, leave := range leaves {
+			if leave.StudentID == studentID && !leave.StartDate.After(date) && !leave.EndDate.Before(date) {
+				return true
+			}
+		}
+		return false
+	}
+
+	locked := make(map[uint]bool, len(columns))
+	for _, column := range columns {
+		locked[column.Session.ID] = h.locked(column.Session.Date)
+	}
+
+	seen := make(map[uint]int)
+	for i, row := range rows {
+		line := i + 2 // 1-based, after the header
+		if registerRowBlank(row) {
+			continue
+		}
+		var name string
+		if len(row) > 1 {
+			name = strings.TrimSpace(row[1])
+		}
+		rawID := strings.TrimSpace(row[0])
+
+		id, err := strconv.ParseUint(rawID, 10, 32)
+		studentID := uint(id)
+		problem := ""
+		switch {
+		case err != nil:
+			problem = "Invalid student_id"
+		case seen[studentID] != 0:
+			problem = fmt.Sprintf("Student already listed in row %d", seen[studentID])
+		default:
+			if _, ok := enrolled[studentID]; !ok {
+				problem = "Student is not enrolled in this course section"
+			}
+		}
+		if problem != "" {
+			diff.UnknownStudents = append(diff.UnknownStudents, registerProblem{Row: line, StudentID: rawID, Name: name, Error: problem})
+			continue
+		}
+		seen[studentID] = line
+		student := enrolled[studentID]
+
+		for j, column := range columns {
+			value := ""
+			if j+2 < len(row) {
+				value = strings.TrimSpace(row[j+2])
+			}
+			if value == "" {
+				continue
+			}
+			session := column.Session
+			change := registerChange{Row: line, StudentID: studentID, Name: student.Name, SessionID: session.ID, Column: column.Header}
+
+			record, found := stored[cell{studentID, session.ID}]
+			if found {
+				change.From = record.Status
+			}
+			status, ok := parseRegisterCell(value)
+			switch {
+			case !ok:
+				change.Result, change.Value, change.Error = rowInvalid, value, "Invalid attendance status"
+			case found && record.Status == status:
+				change.Result = rowUnchanged
+			case locked[session.ID]:
+				change.To, change.Result, change.Error = status, rowInvalid, lockedMessage
+			case status != db.AttendanceOnLeave && onLeave(studentID, session.Date):
+				change.To, change.Result, change.Error = status, rowInvalid, "Student is on approved leave on this date"
+			case found:
+				change.To, change.Result = status, rowUpdated
+				change.record = &record
+			default:
+				sessionID := session.ID
+				change.To, change.Result = status, rowCreated
+				change.record = &db.Attendance{StudentID: studentID, SessionID: &sessionID, Date: session.Date}
+			}
+
+			diff.Summary[change.Result]++
+			if change.Result != rowUnchanged {
+				diff.Changes = append(diff.Changes, change)
+			}
+		}
+	}
+	return diff, nil
+}
+
+func registerRowBlank(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,320 @@
+package attendance
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/internal/notifications"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxSyncBatch = 500
+	syncFeedPage = 500
+)
+
+const (
+	syncCreated   = "created"
+	syncUpdated   = "updated"
+	syncUnchanged = "unchanged"
+	syncConflict  = "conflict"
+	syncInvalid   = "invalid"
+)
+
+// syncResult is the outcome of one offline record. Conflicts carry the
+// server's record so the client can resolve and resubmit with its version.
+// Only applied and unchanged outcomes are kept as receipts; invalid and
+// conflicting records can be fixed and retried with the same client ID.
+type syncResult struct {
+	ClientID     string         `json:"client_id"`
+	Result       string         `json:"result"`
+	Error        string         `json:"error,omitempty"`
+	AttendanceID *uint          `json:"attendance_id,omitempty"`
+	Version      int            `json:"version,omitempty"`
+	Replayed     bool           `json:"replayed,omitempty"`
+	Server       *db.Attendance `json:"server,omitempty"`
+}
+
+// SyncAttendance godoc
+// @Summary      Sync offline attendance
+// @Description  Upload attendance recorded offline and fetch server changes since a cursor. Records are idempotent on client_id: replays return the original result. A record whose base_version no longer matches the server is returned as a conflict with the server's copy instead of overwriting it.
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.SyncRequest  true  "Offline records and cursor"
+// @Success      200      {object}  object{results=array,changes=array,cursor=string,has_more=bool}
+// @Failure      400      {object}  object{error=string}
+// @Router       /attendance/sync [post]
+func (h *AttendanceHandler) SyncAttendance(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+	isAdmin := role == string(db.RoleAdmin)
+
+	var req dto.SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Records) > maxSyncBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d records can be synced at once", maxSyncBatch)})
+		return
+	}
+	since, sinceID, err := decodeSyncCursor(req.Cursor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
+	results := make([]syncResult, 0, len(req.Records))
+	entries := make(map[time.Time][]notifications.AttendanceEntry)
+	for _, record := range req.Records {
+		result, applied := h.syncRecord(uid, isAdmin, record)
+		results = append(results, result)
+		if applied != nil {
+			entries[applied.Date] = append(entries[applied.Date], notifications.AttendanceEntry{
+				StudentID: applied.StudentID,
+				Present:   applied.Present,
+				Status:    string(applied.Status),
+			})
+		}
+	}
+	for date, dayEntries := range entries {
+		h.queueBulkNotification(c, uid, date, dayEntries)
+	}
+
+	changes := h.syncFeed(uid, isAdmin, req.Dept, since, sinceID)
+	cursor := req.Cursor
+	if len(changes) > 0 {
+		last := changes[len(changes)-1]
+		cursor = encodeSyncCursor(last.UpdatedAt, last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":  results,
+		"changes":  changes,
+		"cursor":   cursor,
+		"has_more": len(changes) == syncFeedPage,
+	})
+}
+
+// syncRecord applies one offline record, returning the record that was
+// created or updated, if any
+func (h *AttendanceHandler) syncRecord(uid uint, isAdmin bool, in dto.SyncRecord) (syncResult, *db.Attendance) {
+	result := syncResult{ClientID: in.ClientID}
+
+	var receipt db.SyncReceipt
+	if err := h.DB.Where("user_id = ? AND client_id = ?", uid, in.ClientID).First(&receipt).Error; err == nil {
+		return h.replay(receipt), nil
+	}
+
+	recordedAt := in.RecordedAt
+	if recordedAt.IsZero() {
+		recordedAt = time.Now()
+	}
+	receipt = db.SyncReceipt{UserID: uid, ClientID: in.ClientID, RecordedAt: recordedAt}
+
+	date, status, invalid := h.validateSyncRecord(uid, isAdmin, in)
+	if invalid != "" {
+		result.Result, result.Error = syncInvalid, invalid
+		return result, nil
+	}
+
+	var applied *db.Attendance
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("student_id = ?", in.StudentID)
+		if in.SessionID != nil {
+			query = query.Where("session_id = ?", *in.SessionID)
+		} else {
+			query = query.Where("date = ? AND session_id IS NULL", date)
+		}
+
+		// The record stays locked from the version check to the save, so a
+		// mark or sync in between cannot be overwritten
+		var existing db.Attendance
+		err := query.Clauses(clause.Locking{Strength: "UPDATE"}).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			record := db.Attendance{StudentID: in.StudentID, SessionID: in.SessionID, Date: date, MarkedBy: uid}
+			record.SetStatus(status)
+			record.Audit(uid, in.Reason)
+			if err := tx.Create(&record).Error; err != nil {
+				return err
+			}
+			result.Result = syncCreated
+			applied = &record
+		case err != nil:
+			return err
+		case existing.Status == status:
+			result.Result = syncUnchanged
+			result.AttendanceID, result.Version = &existing.ID, existing.Version
+		case in.Reason == "" && editNeedsReason(existing.Date):
+			result.Result, result.Error = syncInvalid, "A reason is required to change attendance after the day it was taken"
+		case in.BaseVersion != existing.Version:
+			result.Result, result.Error = syncConflict, "Record changed on the server since it was last synced"
+			result.Server = &existing
+			result.AttendanceID, result.Version = &existing.ID, existing.Version
+		default:
+			existing.SetStatus(status)
+			existing.MarkedBy = uid
+			existing.Audit(uid, in.Reason)
+			if err := tx.Save(&existing).Error; err != nil {
+				return err
+			}
+			result.Result = syncUpdated
+			applied = &existing
+		}
+
+		if applied != nil {
+			result.AttendanceID, result.Version = &applied.ID, applied.Version
+		}
+		if result.Result == syncInvalid || result.Result == syncConflict {
+			return nil
+		}
+		receipt.Result, receipt.AttendanceID = result.Result, result.AttendanceID
+		return tx.Create(&receipt).Error
+	})
+	if err != nil {
+		// A concurrent replay of the same client ID wins the receipt
+		return h.replayOrFail(uid, in.ClientID, syncResult{ClientID: in.ClientID, Result: syncInvalid, Error: "Failed to save record"}), nil
+	}
+
+	return result, applied
+}
+
+// validateSyncRecord checks a record the way MarkAttendance would and
+// returns its date and status, or the reason it is invalid
+func (h *AttendanceHandler) validateSyncRecord(uid uint, isAdmin bool, in dto.SyncRecord) (time.Time, db.AttendanceStatus, string) {
+	status := db.AttendanceStatus(in.Status)
+	if !status.Valid() {
+		return time.Time{}, "", "Invalid attendance status"
+	}
+
+	var student db.User
+	if err := h.DB.Where("id = ? AND role = ?", in.StudentID, db.RoleStudent).First(&student).Error; err != nil {
+		return time.Time{}, "", "Student not found"
+	}
+
+	date := in.Date.Time
+	dept := student.Dept
+	if in.SessionID != nil {
+		var session db.ClassSession
+		if err := h.DB.Preload("Course").First(&session, *in.SessionID).Error; err != nil {
+			return time.Time{}, "", "Session not found"
+		}
+		if session.FacultyID != uid && !isAdmin {
+			return time.Time{}, "", "Only the session's faculty can mark its attendance"
+		}
+		if session.Status == db.SessionCancelled {
+			return time.Time{}, "", "Session has been cancelled"
+		}
+		var enrolled int64
+		h.DB.Model(&db.Enrollment{}).
+			Where("course_id = ? AND section = ? AND student_id = ?", session.CourseID, session.Section, in.StudentID).
+			Count(&enrolled)
+		if enrolled == 0 {
+			return time.Time{}, "", "Student is not enrolled in this session's course section"
+		}
+		date, dept = session.Date, session.Course.Dept
+	} else if date.IsZero() {
+		return time.Time{}, "", "date is required unless session_id is given"
+	}
+
+	if h.locked(date) {
+		return time.Time{}, "", lockedMessage
+	}
+	if working, reason := h.Calendar.IsWorkingDay(dept, date); !working {
+		return time.Time{}, "", "Cannot mark attendance on a non-working day (" + reason + ")"
+	}
+	if status != db.AttendanceOnLeave {
+		if _, onLeave := h.approvedLeaves([]uint{in.StudentID}, date)[in.StudentID]; onLeave {
+			return time.Time{}, "", "Student is on approved leave on this date"
+		}
+	}
+	return date, status, ""
+}
+
+func (h *AttendanceHandler) replay(receipt db.SyncReceipt) syncResult {
+	result := syncResult{
+		ClientID:     receipt.ClientID,
+		Result:       receipt.Result,
+		Error:        receipt.Error,
+		AttendanceID: receipt.AttendanceID,
+		Replayed:     true,
+	}
+	if receipt.AttendanceID != nil {
+		var record db.Attendance
+		if err := h.DB.Select("id", "version").First(&record, *receipt.AttendanceID).Error; err == nil {
+			result.Version = record.Version
+		}
+	}
+	return result
+}
+
+func (h *AttendanceHandler) replayOrFail(uid uint, clientID string, fallback syncResult) syncResult {
+	var receipt db.SyncReceipt
+	if err := h.DB.Where("user_id = ? AND client_id = ?", uid, clientID).First(&receipt).Error; err == nil {
+		return h.replay(receipt)
+	}
+	return fallback
+}
+
+// syncFeed returns records changed after the cursor, oldest change first:
+// the sessions the caller teaches (all sessions for admins), plus daily
+// records of dept when given
+func (h *AttendanceHandler) syncFeed(uid uint, isAdmin bool, dept string, since time.Time, sinceID uint) []db.Attendance {
+	query := h.DB.Model(&db.Attendance{})
+	if !since.IsZero() {
+		query = query.Where("(attendances.updated_at > ? OR (attendances.updated_at = ? AND attendances.id > ?))", since, since, sinceID)
+	}
+
+	sessions := h.DB.Model(&db.ClassSession{}).Select("id")
+	if !isAdmin {
+		sessions = sessions.Where("faculty_id = ?", uid)
+	}
+	scope := h.DB.Where("attendances.session_id IN (?)", sessions)
+	if dept != "" {
+		students := h.DB.Model(&db.User{}).Select("id").Where("role = ? AND dept = ?", db.RoleStudent, dept)
+		scope = scope.Or("attendances.session_id IS NULL AND attendances.student_id IN (?)", students)
+	}
+
+	var changes []db.Attendance
+	query.Where(scope).
+		Order("attendances.updated_at, attendances.id").
+		Limit(syncFeedPage).
+		Find(&changes)
+	return changes
+}
+
+// Cursors encode the (updated_at, id) position of the last change returned
+func encodeSyncCursor(updatedAt time.Time, id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", updatedAt.UnixMicro(), id)))
+}
+
+func decodeSyncCursor(cursor string) (time.Time, uint, error) {
+	if cursor == "" {
+		return time.Time{}, 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	var micros int64
+	var id uint
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &micros, &id); err != nil {
+		return time.Time{}, 0, err
+	}
+	return time.UnixMicro(micros), id, nil
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"attendance-workflow/internal/dto"
+	"attendance-workflow/pkg/clock"
 	"attendance-workflow/pkg/db"
 
 	"github.com/gin-gonic/gin"
@@ -34,6 +35,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if _, err := clock.ParseZone(req.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown timezone, use an IANA name such as Asia/Kolkata"})
+		return
+	}
+
 	hashedPassword, err := HashPassword(req.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
@@ -46,6 +52,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Password: hashedPassword,
 		Role:     db.UserRole(req.Role),
 		Dept:     req.Dept,
+		Timezone: req.Timezone,
 	}
 
 	if err := h.DB.Create(&user).Error; err != nil {
@@ -53,7 +60,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	token, err := GenerateToken(user.ID, user.Email, string(user.Role))
+	token, err := GenerateToken(user.ID, user.Email, string(user.Role), user.Timezone)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -62,12 +69,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User registered successfully",
 		"token":   token,
-		"user": gin.H{
-			"id":    user.ID,
-			"name":  user.Name,
-			"email": user.Email,
-			"role":  user.Role,
-		},
+		"user":    userPayload(&user),
 	})
 }
 
@@ -100,7 +102,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := GenerateToken(user.ID, user.Email, string(user.Role))
+	token, err := GenerateToken(user.ID, user.Email, string(user.Role), user.Timezone)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -109,11 +111,27 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Login successful",
 		"token":   token,
-		"user": gin.H{
-			"id":    user.ID,
-			"name":  user.Name,
-			"email": user.Email,
-			"role":  user.Role,
-		},
+		"user":    userPayload(&user),
 	})
 }
+
+// userPayload is the user as returned on registration and login, with the
+// zone their times are displayed in
+func userPayload(user *db.User) gin.H {
+	return gin.H{
+		"id":                   user.ID,
+		"name":                 user.Name,
+		"email":                user.Email,
+		"role":                 user.Role,
+		"timezone":             DisplayZone(user),
+		"institution_timezone": clock.Location().String(),
+	}
+}
+
+// DisplayZone is the name of the zone user's times are shown in
+func DisplayZone(user *db.User) string {
+	if user.Timezone == "" {
+		return clock.Location().String()
+	}
+	return user.Timezone
+}
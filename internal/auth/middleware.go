@@ -3,6 +3,9 @@ package auth
 import (
 	"net/http"
 	"strings"
+	"time"
+
+	"attendance-workflow/pkg/clock"
 
 	"github.com/gin-gonic/gin"
 )
@@ -36,10 +39,44 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("role", claims.Role)
 		c.Set("email", claims.Email)
 
+		// The user's display zone, as it was when the token was issued
+		loc, err := clock.ParseZone(claims.Timezone)
+		if err != nil {
+			loc = clock.Location()
+		}
+		c.Set("timezone", loc)
+		c.Header("X-Timezone", loc.String())
+
 		c.Next()
 	}
 }
 
+// CurrentUserID is the authenticated user's ID. When it is missing it
+// writes the error response and returns false.
+func CurrentUserID(c *gin.Context) (uint, bool) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return 0, false
+	}
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+		return 0, false
+	}
+	return uid, true
+}
+
+// Zone is the zone the current user's times are shown in
+func Zone(c *gin.Context) *time.Location {
+	if loc, ok := c.Get("timezone"); ok {
+		if loc, ok := loc.(*time.Location); ok {
+			return loc
+		}
+	}
+	return clock.Location()
+}
+
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
@@ -0,0 +1,499 @@
+package calendar
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxRangeDays bounds calendar range queries
+const maxRangeDays = 366
+
+type CalendarHandler struct {
+	DB       db.GormDB
+	Calendar *Service
+}
+
+func NewCalendarHandler() *CalendarHandler {
+	return &CalendarHandler{DB: db.DB, Calendar: NewService(db.DB)}
+}
+
+// GetTerms godoc
+// @Summary      List academic terms
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array}
+// @Router       /calendar/terms [get]
+func (h *CalendarHandler) GetTerms(c *gin.Context) {
+	var terms []db.AcademicTerm
+	h.DB.Order("start_date").Find(&terms)
+
+	c.JSON(http.StatusOK, gin.H{"data": terms})
+}
+
+// CreateTerm godoc
+// @Summary      Create academic term
+// @Tags         calendar
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateTermRequest  true  "Term"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /calendar/terms [post]
+func (h *CalendarHandler) CreateTerm(c *gin.Context) {
+	var req dto.CreateTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.EndDate.Time.Before(req.StartDate.Time) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date cannot be before start date"})
+		return
+	}
+
+	var overlapping int64
+	h.DB.Model(&db.AcademicTerm{}).
+		Where("start_date <= ? AND end_date >= ?", req.EndDate.Time, req.StartDate.Time).
+		Count(&overlapping)
+	if overlapping > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Term overlaps an existing term"})
+		return
+	}
+
+	term := db.AcademicTerm{
+		Name:      req.Name,
+		StartDate: req.StartDate.Time,
+		EndDate:   req.EndDate.Time,
+	}
+	if err := h.DB.Create(&term).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create term"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Term created successfully", "data": term})
+}
+
+// DeleteTerm godoc
+// @Summary      Delete academic term
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Term ID"
+// @Success      200  {object}  object{message=string}
+// @Router       /calendar/terms/{id} [delete]
+func (h *CalendarHandler) DeleteTerm(c *gin.Context) {
+	h.deleteByID(c, &db.AcademicTerm{}, "Term")
+}
+
+// GetHolidays godoc
+// @Summary      List holidays
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from  query     string  false  "From date (YYYY-MM-DD)"
+// @Param        to    query     string  false  "To date (YYYY-MM-DD)"
+// @Param        dept  query     string  false  "Department, includes institution-wide holidays"
+// @Success      200   {object}  object{data=array}
+// @Failure      400   {object}  object{error=string}
+// @Router       /calendar/holidays [get]
+func (h *CalendarHandler) GetHolidays(c *gin.Context) {
+	query := h.DB.Order("date")
+
+	if from := c.Query("from"); from != "" {
+		date, err := time.Parse(dateLayout, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be in YYYY-MM-DD format"})
+			return
+		}
+		query = query.Where("date >= ?", date)
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.Parse(dateLayout, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be in YYYY-MM-DD format"})
+			return
+		}
+		query = query.Where("date <= ?", date)
+	}
+	if dept := c.Query("dept"); dept != "" {
+		query = query.Where("dept IN ?", []string{"", dept})
+	}
+
+	var holidays []db.Holiday
+	query.Find(&holidays)
+
+	c.JSON(http.StatusOK, gin.H{"data": holidays})
+}
+
+// CreateHoliday godoc
+// @Summary      Create holiday
+// @Tags         calendar
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateHolidayRequest  true  "Holiday"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /calendar/holidays [post]
+func (h *CalendarHandler) CreateHoliday(c *gin.Context) {
+	var req dto.CreateHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	holiday := db.Holiday{Date: req.Date.Time, Name: req.Name, Dept: req.Dept}
+	if err := h.DB.Create(&holiday).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A holiday already exists on this date"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Holiday created successfully", "data": holiday})
+}
+
+// DeleteHoliday godoc
+// @Summary      Delete holiday
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Holiday ID"
+// @Success      200  {object}  object{message=string}
+// @Router       /calendar/holidays/{id} [delete]
+func (h *CalendarHandler) DeleteHoliday(c *gin.Context) {
+	h.deleteByID(c, &db.Holiday{}, "Holiday")
+}
+
+// ImportHolidays godoc
+// @Summary      Import holidays from iCal
+// @Description  Import the events of an iCalendar (.ics) file as holidays. Multi-day events create one holiday per day, days that already have a holiday are skipped.
+// @Tags         calendar
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file  formData  file    true   "iCalendar file"
+// @Param        dept  formData  string  false  "Department, empty for the whole institution"
+// @Success      200   {object}  object{message=string,created=int,skipped=int}
+// @Failure      400   {object}  object{error=string}
+// @Router       /calendar/holidays/import [post]
+func (h *CalendarHandler) ImportHolidays(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "iCalendar file is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	events, err := parseICal(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid iCalendar file: " + err.Error()})
+		return
+	}
+
+	dept := c.PostForm("dept")
+	created, skipped := 0, 0
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		for _, event := range events {
+			name := event.Summary
+			if name == "" {
+				name = "Holiday"
+			}
+			for day := truncate(event.Start); day.Before(event.End); day = day.AddDate(0, 0, 1) {
+				holiday := db.Holiday{Date: day, Dept: dept}
+				result := tx.Where(holiday).Attrs(db.Holiday{Name: name}).FirstOrCreate(&holiday)
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected > 0 {
+					created++
+				} else {
+					skipped++
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import holidays"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Holidays imported", "created": created, "skipped": skipped})
+}
+
+// GetWeeklyOffs godoc
+// @Summary      List weekly offs
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array}
+// @Router       /calendar/weekly-offs [get]
+func (h *CalendarHandler) GetWeeklyOffs(c *gin.Context) {
+	var offs []db.WeeklyOff
+	h.DB.Order("dept, weekday").Find(&offs)
+
+	c.JSON(http.StatusOK, gin.H{"data": offs})
+}
+
+// SetWeeklyOffs godoc
+// @Summary      Set weekly offs
+// @Description  Replace the weekly offs of a department, or the institution-wide ones when dept is empty
+// @Tags         calendar
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.SetWeeklyOffsRequest  true  "Weekly offs"
+// @Success      200      {object}  object{message=string,data=array}
+// @Failure      400      {object}  object{error=string}
+// @Router       /calendar/weekly-offs [put]
+func (h *CalendarHandler) SetWeeklyOffs(c *gin.Context) {
+	var req dto.SetWeeklyOffsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	offs := make([]db.WeeklyOff, 0, len(req.Weekdays))
+	seen := make(map[int]bool)
+	for _, weekday := range req.Weekdays {
+		if weekday < 0 || weekday > 6 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Weekdays must be between 0 (Sunday) and 6 (Saturday)"})
+			return
+		}
+		if !seen[weekday] {
+			seen[weekday] = true
+			offs = append(offs, db.WeeklyOff{Dept: req.Dept, Weekday: weekday})
+		}
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("dept = ?", req.Dept).Delete(&db.WeeklyOff{}).Error; err != nil {
+			return err
+		}
+		if len(offs) == 0 {
+			return nil
+		}
+		return tx.Create(&offs).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update weekly offs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Weekly offs updated successfully", "data": offs})
+}
+
+// GetSpecialWorkingDays godoc
+// @Summary      List special working days
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array}
+// @Router       /calendar/special-days [get]
+func (h *CalendarHandler) GetSpecialWorkingDays(c *gin.Context) {
+	var days []db.SpecialWorkingDay
+	h.DB.Order("date").Find(&days)
+
+	c.JSON(http.StatusOK, gin.H{"data": days})
+}
+
+// CreateSpecialWorkingDay godoc
+// @Summary      Create special working day
+// @Description  Declare a weekly off (e.g. a Saturday) as a working day
+// @Tags         calendar
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateSpecialWorkingDayRequest  true  "Special working day"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /calendar/special-days [post]
+func (h *CalendarHandler) CreateSpecialWorkingDay(c *gin.Context) {
+	var req dto.CreateSpecialWorkingDayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	day := db.SpecialWorkingDay{Date: req.Date.Time, Name: req.Name, Dept: req.Dept}
+	if err := h.DB.Create(&day).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A special working day already exists on this date"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Special working day created successfully", "data": day})
+}
+
+// DeleteSpecialWorkingDay godoc
+// @Summary      Delete special working day
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Special working day ID"
+// @Success      200  {object}  object{message=string}
+// @Router       /calendar/special-days/{id} [delete]
+func (h *CalendarHandler) DeleteSpecialWorkingDay(c *gin.Context) {
+	h.deleteByID(c, &db.SpecialWorkingDay{}, "Special working day")
+}
+
+// GetEvents godoc
+// @Summary      List exams and mandatory events
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from  query     string  false  "From date (YYYY-MM-DD)"
+// @Param        to    query     string  false  "To date (YYYY-MM-DD)"
+// @Param        dept  query     string  false  "Department, includes institution-wide events"
+// @Success      200   {object}  object{data=array}
+// @Failure      400   {object}  object{error=string}
+// @Router       /calendar/events [get]
+func (h *CalendarHandler) GetEvents(c *gin.Context) {
+	query := h.DB.Order("start_date")
+
+	if from := c.Query("from"); from != "" {
+		date, err := time.Parse(dateLayout, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be in YYYY-MM-DD format"})
+			return
+		}
+		query = query.Where("end_date >= ?", date)
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.Parse(dateLayout, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be in YYYY-MM-DD format"})
+			return
+		}
+		query = query.Where("start_date <= ?", date)
+	}
+	if dept := c.Query("dept"); dept != "" {
+		query = query.Where("dept IN ?", []string{"", dept})
+	}
+
+	var events []db.CalendarEvent
+	query.Find(&events)
+
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
+
+// CreateEvent godoc
+// @Summary      Create exam or mandatory event
+// @Description  Add an exam or mandatory event. Working days are unchanged; leaves covering it are accepted with a warning.
+// @Tags         calendar
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateCalendarEventRequest  true  "Event"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /calendar/events [post]
+func (h *CalendarHandler) CreateEvent(c *gin.Context) {
+	var req dto.CreateCalendarEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kind := db.CalendarEventKind(req.Kind)
+	if kind != db.EventExam && kind != db.EventMandatory {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be exam or mandatory"})
+		return
+	}
+	end := req.StartDate.Time
+	if req.EndDate != nil {
+		end = req.EndDate.Time
+	}
+	if end.Before(req.StartDate.Time) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date cannot be before start date"})
+		return
+	}
+
+	event := db.CalendarEvent{Name: req.Name, Kind: kind, StartDate: req.StartDate.Time, EndDate: end, Dept: req.Dept}
+	if err := h.DB.Create(&event).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Event created successfully", "data": event})
+}
+
+// DeleteEvent godoc
+// @Summary      Delete exam or mandatory event
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Event ID"
+// @Success      200  {object}  object{message=string}
+// @Router       /calendar/events/{id} [delete]
+func (h *CalendarHandler) DeleteEvent(c *gin.Context) {
+	h.deleteByID(c, &db.CalendarEvent{}, "Event")
+}
+
+// GetWorkingDays godoc
+// @Summary      Get working days
+// @Description  Classify each day of a range as working or not for a department
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from  query     string  true   "From date (YYYY-MM-DD)"
+// @Param        to    query     string  true   "To date (YYYY-MM-DD)"
+// @Param        dept  query     string  false  "Department"
+// @Success      200   {object}  object{working_days=int,data=array}
+// @Failure      400   {object}  object{error=string}
+// @Router       /calendar/working-days [get]
+func (h *CalendarHandler) GetWorkingDays(c *gin.Context) {
+	from, err := time.Parse(dateLayout, c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from is required in YYYY-MM-DD format"})
+		return
+	}
+	to, err := time.Parse(dateLayout, c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to is required in YYYY-MM-DD format"})
+		return
+	}
+	if to.Before(from) || to.Sub(from) > maxRangeDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from and within a year of it"})
+		return
+	}
+
+	days := h.Calendar.Days(strings.TrimSpace(c.Query("dept")), from, to)
+	working := 0
+	for _, day := range days {
+		if day.Working {
+			working++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"working_days": working, "data": days})
+}
+
+func (h *CalendarHandler) deleteByID(c *gin.Context, model interface{}, name string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + strings.ToLower(name) + " ID"})
+		return
+	}
+
+	result := h.DB.Delete(model, id)
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": name + " not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": name + " deleted successfully"})
+}
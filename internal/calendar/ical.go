@@ -0,0 +1,115 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// icalEvent is the subset of a VEVENT needed to create holidays
+type icalEvent struct {
+	Summary string
+	Start   time.Time
+	End     time.Time // exclusive
+}
+
+// parseICal reads the VEVENTs of an iCalendar (RFC 5545) stream. All-day
+// events span [DTSTART, DTEND); events with a time cover their start day.
+func parseICal(r io.Reader) ([]icalEvent, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []icalEvent
+	var current *icalEvent
+	for i, line := range lines {
+		name, params, value, ok := splitProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current = &icalEvent{}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN", i+1)
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", i+1, current.Summary)
+			}
+			if current.End.IsZero() || !current.End.After(current.Start) {
+				current.End = current.Start.AddDate(0, 0, 1)
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "SUMMARY":
+			current.Summary = unescapeText(value)
+		case name == "DTSTART":
+			if current.Start, err = parseICalDate(params, value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+		case name == "DTEND":
+			if current.End, err = parseICalDate(params, value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("unterminated VEVENT")
+	}
+	return events, nil
+}
+
+// unfoldLines joins continuation lines, which start with a space or tab
+func unfoldLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitProperty splits "NAME;PARAM=X:VALUE" into its parts
+func splitProperty(line string) (name string, params string, value string, ok bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", "", "", false
+	}
+	name, params, _ = strings.Cut(head, ";")
+	return strings.ToUpper(name), strings.ToUpper(params), value, true
+}
+
+// parseICalDate accepts DATE values and DATE-TIME values, only the calendar
+// day of the start is used
+func parseICalDate(params, value string) (time.Time, error) {
+	if strings.Contains(params, "VALUE=DATE") && !strings.Contains(params, "VALUE=DATE-TIME") {
+		return time.Parse("20060102", value)
+	}
+	if len(value) == 8 {
+		return time.Parse("20060102", value)
+	}
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return truncate(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func unescapeText(value string) string {
+	replacer := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return strings.TrimSpace(replacer.Replace(value))
+}
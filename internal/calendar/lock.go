@@ -0,0 +1,38 @@
+package calendar
+
+import (
+	"time"
+
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/config"
+)
+
+// LockedMessage explains why attendance on a locked date cannot be changed
+const LockedMessage = "Attendance for this date is locked, raise a correction request instead"
+
+// LockedAt returns when attendance taken on date stops accepting direct
+// changes: ATTENDANCE_LOCK_AFTER past the end of the day, or the end of
+// its term when ATTENDANCE_LOCK_AT_TERM_END is set, whichever comes first.
+// The zero time means it never locks.
+func (s *Service) LockedAt(date time.Time) time.Time {
+	var at time.Time
+	dayEnd := clock.EndOfDay(date)
+	if window := config.AppConfig.Attendance.LockAfter; window > 0 {
+		at = dayEnd.Add(window)
+	}
+	if config.AppConfig.Attendance.LockAtTermEnd {
+		if term, ok := s.TermOn(date); ok {
+			termEnd := clock.EndOfDay(term.EndDate)
+			if at.IsZero() || termEnd.Before(at) {
+				at = termEnd
+			}
+		}
+	}
+	return at
+}
+
+// Locked reports whether attendance on date is locked now
+func (s *Service) Locked(date time.Time) bool {
+	at := s.LockedAt(date)
+	return !at.IsZero() && !time.Now().Before(at)
+}
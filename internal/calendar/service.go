@@ -0,0 +1,167 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+
+	"attendance-workflow/pkg/db"
+)
+
+const dateLayout = "2006-01-02"
+
+// Service answers working-day questions from the academic calendar. A day is
+// a working day when it falls inside a term (if any terms are defined), is
+// not a holiday for the institution or the department, and is either not a
+// weekly off or has been declared a special working day.
+type Service struct {
+	DB db.GormDB
+}
+
+func NewService(database db.GormDB) *Service {
+	return &Service{DB: database}
+}
+
+// Day describes a single calendar day for a department
+type Day struct {
+	Date    time.Time `json:"date"`
+	Working bool      `json:"working"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+// IsWorkingDay reports whether date is a working day for dept, with the
+// reason when it is not
+func (s *Service) IsWorkingDay(dept string, date time.Time) (bool, string) {
+	days := s.Days(dept, date, date)
+	if len(days) == 0 {
+		return false, "Invalid date"
+	}
+	return days[0].Working, days[0].Reason
+}
+
+// WorkingDays lists the working days for dept between start and end inclusive
+func (s *Service) WorkingDays(dept string, start, end time.Time) []time.Time {
+	var result []time.Time
+	for _, day := range s.Days(dept, start, end) {
+		if day.Working {
+			result = append(result, day.Date)
+		}
+	}
+	return result
+}
+
+// NonWorkingDays lists the days between start and end that are not working
+// days for dept
+func (s *Service) NonWorkingDays(dept string, start, end time.Time) []time.Time {
+	var result []time.Time
+	for _, day := range s.Days(dept, start, end) {
+		if !day.Working {
+			result = append(result, day.Date)
+		}
+	}
+	return result
+}
+
+// Days classifies every day between start and end inclusive for dept
+func (s *Service) Days(dept string, start, end time.Time) []Day {
+	start, end = truncate(start), truncate(end)
+	if end.Before(start) {
+		return nil
+	}
+
+	depts := []string{""}
+	if dept != "" {
+		depts = append(depts, dept)
+	}
+
+	var holidays []db.Holiday
+	s.DB.Where("date BETWEEN ? AND ? AND dept IN ?", start, end, depts).Find(&holidays)
+	holidayNames := make(map[string]string, len(holidays))
+	for _, holiday := range holidays {
+		holidayNames[holiday.Date.Format(dateLayout)] = holiday.Name
+	}
+
+	var specialDays []db.SpecialWorkingDay
+	s.DB.Where("date BETWEEN ? AND ? AND dept IN ?", start, end, depts).Find(&specialDays)
+	special := make(map[string]bool, len(specialDays))
+	for _, day := range specialDays {
+		special[day.Date.Format(dateLayout)] = true
+	}
+
+	weeklyOffs := s.weeklyOffs(dept)
+
+	var terms []db.AcademicTerm
+	s.DB.Where("start_date <= ? AND end_date >= ?", end, start).Find(&terms)
+	var termCount int64
+	s.DB.Model(&db.AcademicTerm{}).Count(&termCount)
+
+	var days []Day
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		key := date.Format(dateLayout)
+		day := Day{Date: date, Working: true}
+
+		switch {
+		case termCount > 0 && !inTerm(terms, date):
+			day.Working, day.Reason = false, "Outside academic terms"
+		case holidayNames[key] != "":
+			day.Working, day.Reason = false, fmt.Sprintf("Holiday: %s", holidayNames[key])
+		case weeklyOffs[date.Weekday()] && !special[key]:
+			day.Working, day.Reason = false, fmt.Sprintf("Weekly off: %s", date.Weekday())
+		}
+
+		days = append(days, day)
+	}
+	return days
+}
+
+// weeklyOffs returns the weekly offs of dept, falling back to the
+// institution-wide ones and then to Sunday when none are configured
+func (s *Service) weeklyOffs(dept string) map[time.Weekday]bool {
+	var offs []db.WeeklyOff
+	if dept != "" {
+		s.DB.Where("dept = ?", dept).Find(&offs)
+	}
+	if len(offs) == 0 {
+		s.DB.Where("dept = ?", "").Find(&offs)
+	}
+
+	result := make(map[time.Weekday]bool)
+	if len(offs) == 0 {
+		result[time.Sunday] = true
+		return result
+	}
+	for _, off := range offs {
+		result[time.Weekday(off.Weekday)] = true
+	}
+	return result
+}
+
+// EventsBetween returns the exams and mandatory events of dept and of the
+// whole institution that overlap start to end
+func (s *Service) EventsBetween(dept string, start, end time.Time) []db.CalendarEvent {
+	var events []db.CalendarEvent
+	s.DB.Where("start_date <= ? AND end_date >= ? AND dept IN ?", truncate(end), truncate(start), []string{"", dept}).
+		Order("start_date").Find(&events)
+	return events
+}
+
+// TermOn returns the academic term containing date, if any
+func (s *Service) TermOn(date time.Time) (*db.AcademicTerm, bool) {
+	var term db.AcademicTerm
+	if err := s.DB.Where("start_date <= ? AND end_date >= ?", truncate(date), truncate(date)).First(&term).Error; err != nil {
+		return nil, false
+	}
+	return &term, true
+}
+
+func inTerm(terms []db.AcademicTerm, date time.Time) bool {
+	for _, term := range terms {
+		if !date.Before(truncate(term.StartDate)) && !date.After(truncate(term.EndDate)) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
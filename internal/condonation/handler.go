@@ -0,0 +1,417 @@
+package condonation
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/calendar"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/internal/eligibility"
+	"attendance-workflow/internal/notifications"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/config"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CondonationHandler struct {
+	DB          db.GormDB
+	Calendar    *calendar.Service
+	Eligibility *eligibility.Service
+}
+
+func NewCondonationHandler() *CondonationHandler {
+	return &CondonationHandler{DB: db.DB, Calendar: calendar.NewService(db.DB), Eligibility: eligibility.NewService(db.DB)}
+}
+
+// ApplyCondonation godoc
+// @Summary      Apply for condonation
+// @Description  Ask to excuse sessions missed in a course, when attendance is below the course's minimum. The request goes through each role of CONDONATION_APPROVAL_PATH in turn.
+// @Tags         condonations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.ApplyCondonationRequest  true  "Condonation"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string}
+// @Router       /condonations [post]
+func (h *CondonationHandler) ApplyCondonation(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ApplyCondonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category := db.CondonationCategory(req.Category)
+	if category != db.CondonationMedical && category != db.CondonationSports && category != db.CondonationOther {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category must be medical, sports or other"})
+		return
+	}
+
+	// Where the student stands in the course
+	results, err := h.Eligibility.ForStudent(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute eligibility"})
+		return
+	}
+	var standing *eligibility.CourseEligibility
+	for i := range results {
+		if results[i].CourseID == req.CourseID {
+			standing = &results[i]
+		}
+	}
+	if standing == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You are not enrolled in this course"})
+		return
+	}
+	if standing.Eligible {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Condonation is only for attendance below the course minimum"})
+		return
+	}
+	missed := int(math.Floor(float64(standing.SessionsHeld) - standing.WeightedSessions - standing.CondonedSessions))
+	if req.Sessions > missed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can ask to condone at most " + strconv.Itoa(missed) + " missed sessions"})
+		return
+	}
+
+	condonation := db.Condonation{
+		StudentID:         uid,
+		CourseID:          req.CourseID,
+		TermID:            req.TermID,
+		Category:          category,
+		Reason:            req.Reason,
+		RequestedSessions: req.Sessions,
+		Status:            db.CondonationPending,
+		ApprovalPath:      approvalPath(),
+	}
+	if req.TermID != nil {
+		var term db.AcademicTerm
+		if err := h.DB.First(&term, *req.TermID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Term not found"})
+			return
+		}
+	} else if term, ok := h.Calendar.TermOn(clock.Today()); ok {
+		condonation.TermID = &term.ID
+	}
+
+	if len(req.DocumentFileIDs) > 0 {
+		if err := h.DB.Where("id IN ? AND owner_id = ?", req.DocumentFileIDs, uid).Find(&condonation.Documents).Error; err != nil ||
+			len(condonation.Documents) != len(req.DocumentFileIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Document not found, upload it through POST /files first"})
+			return
+		}
+	}
+
+	var pending int64
+	h.DB.Model(&db.Condonation{}).
+		Where("student_id = ? AND course_id = ? AND status = ?", uid, req.CourseID, db.CondonationPending).
+		Count(&pending)
+	if pending > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A condonation request for this course is already pending"})
+		return
+	}
+
+	if err := h.DB.Create(&condonation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit condonation request"})
+		return
+	}
+	queueNotification(c, condonation.ID, notifications.CondonationSubmitted)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Condonation request submitted", "data": condonation})
+}
+
+// GetCondonations godoc
+// @Summary      List condonations
+// @Description  Students see their own requests, faculty those of the courses they teach, HODs those of their department's students, wardens and admins every request
+// @Tags         condonations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status     query     string  false  "pending, approved, rejected or cancelled"
+// @Param        course_id  query     int     false  "Course ID"
+// @Param        page       query     int     false  "Page number" default(1)
+// @Param        limit      query     int     false  "Items per page" default(10)
+// @Success      200        {object}  object{data=array,page=int,limit=int,total=int64,total_pages=int64}
+// @Router       /condonations [get]
+func (h *CondonationHandler) GetCondonations(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	query := h.DB.Model(&db.Condonation{})
+	switch role {
+	case string(db.RoleStudent):
+		query = query.Where("student_id = ?", uid)
+	case string(db.RoleFaculty):
+		courses := h.DB.Model(&db.ClassSession{}).Select("course_id").Where("faculty_id = ?", uid)
+		query = query.Where("course_id IN (?)", courses)
+	case string(db.RoleHOD):
+		var hod db.User
+		h.DB.Select("id", "dept").First(&hod, uid)
+		students := h.DB.Model(&db.User{}).Select("id").Where("role = ? AND dept = ?", db.RoleStudent, hod.Dept)
+		query = query.Where("student_id IN (?)", students)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if courseID := c.Query("course_id"); courseID != "" {
+		query = query.Where("course_id = ?", courseID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var condonations []db.Condonation
+	query.Preload("Student").Preload("Course").Preload("Term").
+		Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&condonations)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        condonations,
+		"page":        page,
+		"limit":       limit,
+		"total":       total,
+		"total_pages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// GetCondonation godoc
+// @Summary      Get condonation
+// @Description  Get a condonation request with its documents and the decision of each approval step
+// @Tags         condonations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Condonation ID"
+// @Success      200  {object}  object{data=object,pending_role=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /condonations/{id} [get]
+func (h *CondonationHandler) GetCondonation(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	condonation, ok := h.load(c)
+	if !ok {
+		return
+	}
+
+	role, _ := c.Get("role")
+	if role == string(db.RoleStudent) && condonation.StudentID != uid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own condonation requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": condonation, "pending_role": condonation.PendingRole()})
+}
+
+// DecideCondonation godoc
+// @Summary      Approve or reject a condonation step
+// @Description  Record the decision of the role the request waits for: the course's faculty, the HOD of the student's department, or any user of another role in the path. Admins can decide any step. A rejection ends the request; the last approval grants the sessions.
+// @Tags         condonations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                           true  "Condonation ID"
+// @Param        request  body      dto.DecideCondonationRequest  true  "Decision"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Router       /condonations/{id}/decide [put]
+func (h *CondonationHandler) DecideCondonation(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+
+	var req dto.DecideCondonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	condonation, ok := h.load(c)
+	if !ok {
+		return
+	}
+	pendingRole := condonation.PendingRole()
+	if pendingRole == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Condonation is already " + string(condonation.Status)})
+		return
+	}
+	if role != string(db.RoleAdmin) && !h.isApprover(uid, string(pendingRole), condonation) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This request is waiting for the " + string(pendingRole)})
+		return
+	}
+
+	last := condonation.Step == len(condonation.Approvers())-1
+	granted := condonation.RequestedSessions
+	if req.GrantedSessions != nil {
+		if !last {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "granted_sessions can only be set at the last approval step"})
+			return
+		}
+		if *req.GrantedSessions < 1 || *req.GrantedSessions > condonation.RequestedSessions {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "granted_sessions must be between 1 and the requested sessions"})
+			return
+		}
+		granted = *req.GrantedSessions
+	}
+
+	approval := db.CondonationApproval{
+		CondonationID: condonation.ID,
+		Step:          condonation.Step,
+		Role:          db.UserRole(role.(string)),
+		ApproverID:    uid,
+		Approved:      req.Approve,
+		Remarks:       req.Remarks,
+	}
+	event := notifications.CondonationAdvanced
+	switch {
+	case !req.Approve:
+		condonation.Status = db.CondonationRejected
+		event = notifications.CondonationRejected
+	case last:
+		condonation.Status = db.CondonationApproved
+		condonation.GrantedSessions = granted
+		event = notifications.CondonationApproved
+	default:
+		condonation.Step++
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&approval).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Documents", "Approvals", "Student", "Course", "Term").Save(condonation).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record decision"})
+		return
+	}
+	queueNotification(c, condonation.ID, event)
+
+	condonation.Approvals = append(condonation.Approvals, approval)
+	c.JSON(http.StatusOK, gin.H{"message": "Decision recorded", "data": condonation, "pending_role": condonation.PendingRole()})
+}
+
+// CancelCondonation godoc
+// @Summary      Cancel condonation
+// @Description  Withdraw a pending condonation request (student only)
+// @Tags         condonations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Condonation ID"
+// @Success      200  {object}  object{message=string,data=object}
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /condonations/{id}/cancel [put]
+func (h *CondonationHandler) CancelCondonation(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	condonation, ok := h.load(c)
+	if !ok {
+		return
+	}
+	if condonation.StudentID != uid {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Condonation request not found"})
+		return
+	}
+	if condonation.Status != db.CondonationPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending condonation requests can be cancelled"})
+		return
+	}
+
+	if err := h.DB.Model(condonation).Update("status", db.CondonationCancelled).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel condonation request"})
+		return
+	}
+	condonation.Status = db.CondonationCancelled
+
+	c.JSON(http.StatusOK, gin.H{"message": "Condonation request cancelled", "data": condonation})
+}
+
+func (h *CondonationHandler) load(c *gin.Context) (*db.Condonation, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid condonation ID"})
+		return nil, false
+	}
+
+	var condonation db.Condonation
+	if err := h.DB.Preload("Student").Preload("Course").Preload("Term").Preload("Documents").
+		Preload("Approvals", func(tx *gorm.DB) *gorm.DB { return tx.Order("step") }).Preload("Approvals.Approver").
+		First(&condonation, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Condonation request not found"})
+		return nil, false
+	}
+	return &condonation, true
+}
+
+// isApprover reports whether the user can decide for role: faculty must
+// teach the student's section, HODs must head the student's department.
+// Other roles decide only as admins.
+func (h *CondonationHandler) isApprover(uid uint, role string, condonation *db.Condonation) bool {
+	var user db.User
+	if err := h.DB.First(&user, uid).Error; err != nil || string(user.Role) != role {
+		return false
+	}
+
+	switch user.Role {
+	case db.RoleFaculty:
+		var teaches int64
+		h.DB.Model(&db.ClassSession{}).
+			Joins("JOIN enrollments ON enrollments.course_id = class_sessions.course_id AND enrollments.section = class_sessions.section").
+			Where("class_sessions.course_id = ? AND class_sessions.faculty_id = ? AND enrollments.student_id = ?", condonation.CourseID, uid, condonation.StudentID).
+			Count(&teaches)
+		return teaches > 0
+	case db.RoleHOD:
+		return user.Dept != "" && user.Dept == condonation.Student.Dept
+	}
+	return user.Role == db.RoleAdmin
+}
+
+// approvalPath returns the configured approval roles, falling back to
+// admins when none are configured
+func approvalPath() string {
+	roles := config.AppConfig.Attendance.CondonationApprovalPath
+	if len(roles) == 0 {
+		return string(db.RoleAdmin)
+	}
+	return strings.Join(roles, ",")
+}
+
+func queueNotification(c *gin.Context, condonationID uint, event string) {
+	notifService := notifications.GetNotificationService()
+	if err := notifService.QueueCondonationNotification(c.Request.Context(), notifications.CondonationPayload{
+		CondonationID: condonationID,
+		Event:         event,
+	}); err != nil {
+		log.Printf("Failed to queue condonation notification: %v", err)
+	}
+}
@@ -0,0 +1,367 @@
+package courses
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CourseHandler struct {
+	DB db.GormDB
+}
+
+func NewCourseHandler() *CourseHandler {
+	return &CourseHandler{DB: db.DB}
+}
+
+// CreateCourse godoc
+// @Summary      Create course
+// @Description  Create a new course (admin only)
+// @Tags         courses
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateCourseRequest  true  "Course details"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /courses [post]
+func (h *CourseHandler) CreateCourse(c *gin.Context) {
+	var req dto.CreateCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	course := db.Course{
+		Code: req.Code,
+		Name: req.Name,
+		Dept: req.Dept,
+	}
+
+	if err := h.DB.Create(&course).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Course code already exists"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Course created successfully", "data": course})
+}
+
+// GetCourses godoc
+// @Summary      List courses
+// @Description  List courses, optionally filtered by department
+// @Tags         courses
+// @Produce      json
+// @Security     BearerAuth
+// @Param        dept  query     string  false  "Filter by department"
+// @Success      200   {object}  object{data=array}
+// @Router       /courses [get]
+func (h *CourseHandler) GetCourses(c *gin.Context) {
+	var courses []db.Course
+	query := h.DB.Order("code")
+	if dept := c.Query("dept"); dept != "" {
+		query = query.Where("dept = ?", dept)
+	}
+	query.Find(&courses)
+
+	c.JSON(http.StatusOK, gin.H{"data": courses})
+}
+
+// GetCourse godoc
+// @Summary      Get course
+// @Description  Get a course by ID
+// @Tags         courses
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Course ID"
+// @Success      200  {object}  object{data=object}
+// @Failure      404  {object}  object{error=string}
+// @Router       /courses/{id} [get]
+func (h *CourseHandler) GetCourse(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		return
+	}
+
+	var course db.Course
+	if err := h.DB.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": course})
+}
+
+// EnrollStudents godoc
+// @Summary      Enroll students
+// @Description  Enroll students into a section of a course, moving them if already enrolled
+// @Tags         courses
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                        true  "Course ID"
+// @Param        request  body      dto.EnrollStudentsRequest  true  "Students and section"
+// @Success      200      {object}  object{message=string,enrolled=int}
+// @Failure      400      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Router       /courses/{id}/enrollments [post]
+func (h *CourseHandler) EnrollStudents(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		return
+	}
+
+	var req dto.EnrollStudentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var course db.Course
+	if err := h.DB.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	var students int64
+	h.DB.Model(&db.User{}).Where("id IN ? AND role = ?", req.StudentIDs, db.RoleStudent).Count(&students)
+	if students != int64(len(req.StudentIDs)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "All IDs must belong to existing students"})
+		return
+	}
+
+	for _, studentID := range req.StudentIDs {
+		enrollment := db.Enrollment{CourseID: course.ID, StudentID: studentID}
+		if err := h.DB.Where(enrollment).Assign(db.Enrollment{Section: req.Section}).FirstOrCreate(&enrollment).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enroll students"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Students enrolled successfully", "enrolled": len(req.StudentIDs)})
+}
+
+// GetEnrollments godoc
+// @Summary      List enrollments
+// @Description  List students enrolled in a course, optionally for one section
+// @Tags         courses
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int     true   "Course ID"
+// @Param        section  query     string  false  "Section"
+// @Success      200      {object}  object{data=array}
+// @Router       /courses/{id}/enrollments [get]
+func (h *CourseHandler) GetEnrollments(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		return
+	}
+
+	var enrollments []db.Enrollment
+	query := h.DB.Preload("Student").Where("course_id = ?", id)
+	if section := c.Query("section"); section != "" {
+		query = query.Where("section = ?", section)
+	}
+	query.Order("section, student_id").Find(&enrollments)
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollments})
+}
+
+// CreateSession godoc
+// @Summary      Create class session
+// @Description  Schedule a class session for a course section (faculty/admin)
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateSessionRequest  true  "Session details"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /sessions [post]
+func (h *CourseHandler) CreateSession(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+	role, _ := c.Get("role")
+
+	var req dto.CreateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+		return
+	}
+
+	if !validSlot(req.StartTime, req.EndTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_time and end_time must be HH:MM with start before end"})
+		return
+	}
+
+	facultyID := uid
+	if req.FacultyID != 0 && req.FacultyID != uid {
+		if role != string(db.RoleAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can schedule sessions for other faculty"})
+			return
+		}
+		facultyID = req.FacultyID
+	}
+
+	var course db.Course
+	if err := h.DB.First(&course, req.CourseID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Course not found"})
+		return
+	}
+
+	if req.GeofenceID != nil {
+		var fence db.Geofence
+		if err := h.DB.First(&fence, *req.GeofenceID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Geofence not found"})
+			return
+		}
+	}
+
+	session := db.ClassSession{
+		CourseID:     course.ID,
+		Section:      req.Section,
+		Date:         req.Date.Time,
+		Period:       req.Period,
+		StartTime:    req.StartTime,
+		EndTime:      req.EndTime,
+		GraceMinutes: req.GraceMinutes,
+		FacultyID:    facultyID,
+		GeofenceID:   req.GeofenceID,
+		Room:         req.Room,
+	}
+
+	if err := h.DB.Create(&session).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A session already exists for this section and period"})
+		return
+	}
+	session.Course = course
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Session created successfully", "data": session})
+}
+
+// GetSessions godoc
+// @Summary      List class sessions
+// @Description  List class sessions filtered by date, course, section, faculty or status
+// @Tags         sessions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        date        query     string  false  "Date (YYYY-MM-DD)"
+// @Param        course_id   query     int     false  "Course ID"
+// @Param        section     query     string  false  "Section"
+// @Param        faculty_id  query     int     false  "Faculty ID"
+// @Param        status      query     string  false  "scheduled or cancelled"
+// @Success      200         {object}  object{data=array}
+// @Router       /sessions [get]
+func (h *CourseHandler) GetSessions(c *gin.Context) {
+	var sessions []db.ClassSession
+	query := h.DB.Preload("Course").Preload("Faculty")
+
+	if date := c.Query("date"); date != "" {
+		query = query.Where("date = ?", date)
+	}
+	if courseID := c.Query("course_id"); courseID != "" {
+		query = query.Where("course_id = ?", courseID)
+	}
+	if section := c.Query("section"); section != "" {
+		query = query.Where("section = ?", section)
+	}
+	if facultyID := c.Query("faculty_id"); facultyID != "" {
+		query = query.Where("faculty_id = ?", facultyID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Order("date DESC, period").Find(&sessions)
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// GetSessionAttendance godoc
+// @Summary      Get session roster
+// @Description  Get the enrolled roster of a class session with each student's attendance
+// @Tags         sessions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Session ID"
+// @Success      200  {object}  object{session=object,data=array}
+// @Failure      404  {object}  object{error=string}
+// @Router       /sessions/{id}/attendance [get]
+func (h *CourseHandler) GetSessionAttendance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	var session db.ClassSession
+	if err := h.DB.Preload("Course").First(&session, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	var enrollments []db.Enrollment
+	h.DB.Preload("Student").
+		Where("course_id = ? AND section = ?", session.CourseID, session.Section).
+		Order("student_id").
+		Find(&enrollments)
+
+	var records []db.Attendance
+	h.DB.Where("session_id = ?", session.ID).Find(&records)
+	byStudent := make(map[uint]db.Attendance, len(records))
+	for _, record := range records {
+		byStudent[record.StudentID] = record
+	}
+
+	roster := make([]gin.H, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		entry := gin.H{
+			"student_id": enrollment.StudentID,
+			"name":       enrollment.Student.Name,
+			"marked":     false,
+		}
+		if record, ok := byStudent[enrollment.StudentID]; ok {
+			entry["marked"] = true
+			entry["present"] = record.Present
+			entry["status"] = record.Status
+			entry["source"] = record.Source
+			entry["checked_in_at"] = record.CheckedInAt
+			entry["attendance_id"] = record.ID
+		}
+		roster = append(roster, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session, "data": roster})
+}
+
+// validSlot checks optional HH:MM start and end times
+func validSlot(start, end string) bool {
+	if start == "" && end == "" {
+		return true
+	}
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return false
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return false
+	}
+	return startTime.Before(endTime)
+}
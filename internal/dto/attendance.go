@@ -1,10 +1,118 @@
 package dto
 
+import "time"
+
 type MarkAttendanceRequest struct {
 	StudentID uint   `json:"student_id" binding:"required"`
-	Date      Date   `json:"date" binding:"required"`
-	Present   bool   `json:"present"`
+	Date      Date   `json:"date"`                 // required unless session_id is given
+	SessionID *uint  `json:"session_id,omitempty"` // mark a single class session
+	Status    string `json:"status,omitempty"`     // present, absent, late, excused, on_leave, on_duty, half_day
+	Present   bool   `json:"present"`              // used when status is omitted
+	Reason    string `json:"reason,omitempty"`     // required to change attendance after its day
+	// ArrivedAt records when the student arrived at a session (RFC 3339);
+	// unless another status is given they are present or late by the
+	// session's grace period
+	ArrivedAt *time.Time `json:"arrived_at,omitempty"`
+}
+
+// BulkAttendanceRequest marks a whole roster at once. Either list every
+// student in records, or set all_present to mark the roster present except
+// the students listed in except. The roster is the session's enrolled section,
+// or the students of dept for daily attendance. Absentees get absent_status,
+// which defaults to absent.
+type BulkAttendanceRequest struct {
+	Date         Date                   `json:"date"`
+	SessionID    *uint                  `json:"session_id,omitempty"`
+	Dept         string                 `json:"dept,omitempty"`
+	Records      []BulkAttendanceRecord `json:"records,omitempty"`
+	AllPresent   bool                   `json:"all_present"`
+	Except       []uint                 `json:"except,omitempty"`
+	AbsentStatus string                 `json:"absent_status,omitempty"`
+	Reason       string                 `json:"reason,omitempty"` // required to change attendance after its day
+}
+
+type BulkAttendanceRecord struct {
+	StudentID uint   `json:"student_id"`
+	Status    string `json:"status,omitempty"`
+	Present   bool   `json:"present"` // used when status is omitted
+}
+
+// CheckinRequest carries the token scanned from the session's QR code, the
+// device it was scanned on and, when geofences are configured, its location
+type CheckinRequest struct {
+	Token     string   `json:"token" binding:"required"`
+	DeviceID  string   `json:"device_id" binding:"required"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+}
+
+// ReviewCheckinRequest accepts or rejects a flagged check-in
+type ReviewCheckinRequest struct {
+	Accept bool   `json:"accept"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type CreateGeofenceRequest struct {
+	Name         string     `json:"name" binding:"required"`
+	Kind         string     `json:"kind,omitempty"` // campus (default) or classroom
+	Polygon      []GeoPoint `json:"polygon" binding:"required,min=1"`
+	RadiusMeters *float64   `json:"radius_meters,omitempty"` // defaults to GEOFENCE_RADIUS_METERS
 }
 
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type RegisterDeviceRequest struct {
+	DeviceID string `json:"device_id" binding:"required"`
+	Label    string `json:"label,omitempty"`
+}
 
+// CloseCheckinRequest closes a session's check-in. Enrolled students who did
+// not check in and were not marked are recorded with absent_status, which
+// defaults to absent.
+type CloseCheckinRequest struct {
+	AbsentStatus string `json:"absent_status,omitempty"`
+}
+
+// SyncRequest uploads attendance recorded offline and asks for the changes
+// made on the server since cursor
+type SyncRequest struct {
+	Records []SyncRecord `json:"records,omitempty" binding:"dive"`
+	Cursor  string       `json:"cursor,omitempty"`
+	Dept    string       `json:"dept,omitempty"` // include daily records of this department in the change feed
+}
 
+// SyncRecord is one attendance mark taken offline. client_id must be unique
+// per record on the device; base_version is the server version the client
+// last saw (0 for a record it has not seen on the server).
+type SyncRecord struct {
+	ClientID    string    `json:"client_id" binding:"required"`
+	StudentID   uint      `json:"student_id" binding:"required"`
+	SessionID   *uint     `json:"session_id,omitempty"`
+	Date        Date      `json:"date"` // required unless session_id is given
+	Status      string    `json:"status" binding:"required"`
+	RecordedAt  time.Time `json:"recorded_at"`
+	BaseVersion int       `json:"base_version"`
+	Reason      string    `json:"reason,omitempty"` // required to change attendance after its day
+}
+
+// CreateCorrectionRequest asks for a change to locked attendance. Give the
+// attendance_id of an existing record, or the student_id with the date or
+// session_id of one that was never marked; students may leave out their
+// own student_id. Evidence is a document uploaded through POST /files.
+type CreateCorrectionRequest struct {
+	AttendanceID   *uint  `json:"attendance_id,omitempty"`
+	StudentID      uint   `json:"student_id,omitempty"`
+	Date           Date   `json:"date"` // with student_id, unless session_id is given
+	SessionID      *uint  `json:"session_id,omitempty"`
+	Status         string `json:"status" binding:"required"`
+	Reason         string `json:"reason" binding:"required"`
+	EvidenceFileID *uint  `json:"evidence_file_id,omitempty"`
+}
+
+type ReviewCorrectionRequest struct {
+	Approve bool   `json:"approve"`
+	Remarks string `json:"remarks,omitempty"`
+}
@@ -6,6 +6,7 @@ type RegisterRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 	Role     string `json:"role" binding:"required"`
 	Dept     string `json:"dept,omitempty"`
+	Timezone string `json:"timezone,omitempty"` // IANA zone for display, the institution's when empty
 }
 
 type LoginRequest struct {
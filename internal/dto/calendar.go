@@ -0,0 +1,32 @@
+package dto
+
+type CreateTermRequest struct {
+	Name      string `json:"name" binding:"required"`
+	StartDate Date   `json:"start_date" binding:"required"`
+	EndDate   Date   `json:"end_date" binding:"required"`
+}
+
+type CreateHolidayRequest struct {
+	Date Date   `json:"date" binding:"required"`
+	Name string `json:"name" binding:"required"`
+	Dept string `json:"dept,omitempty"` // empty for the whole institution
+}
+
+type SetWeeklyOffsRequest struct {
+	Dept     string `json:"dept,omitempty"` // empty for the whole institution
+	Weekdays []int  `json:"weekdays"`       // 0 = Sunday ... 6 = Saturday
+}
+
+type CreateSpecialWorkingDayRequest struct {
+	Date Date   `json:"date" binding:"required"`
+	Name string `json:"name,omitempty"`
+	Dept string `json:"dept,omitempty"` // empty for the whole institution
+}
+
+type CreateCalendarEventRequest struct {
+	Name      string `json:"name" binding:"required"`
+	Kind      string `json:"kind" binding:"required"` // exam or mandatory
+	StartDate Date   `json:"start_date" binding:"required"`
+	EndDate   *Date  `json:"end_date,omitempty"` // defaults to start_date
+	Dept      string `json:"dept,omitempty"`     // empty for the whole institution
+}
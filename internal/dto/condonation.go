@@ -0,0 +1,20 @@
+package dto
+
+// ApplyCondonationRequest asks to excuse sessions missed in a course.
+// Supporting documents are uploaded through POST /files first.
+type ApplyCondonationRequest struct {
+	CourseID        uint   `json:"course_id" binding:"required"`
+	TermID          *uint  `json:"term_id,omitempty"`           // defaults to the current term
+	Category        string `json:"category" binding:"required"` // medical, sports or other
+	Reason          string `json:"reason" binding:"required"`
+	Sessions        int    `json:"sessions" binding:"required,min=1"`
+	DocumentFileIDs []uint `json:"document_file_ids,omitempty"`
+}
+
+// DecideCondonationRequest records one approval step. The last step may
+// grant fewer sessions than requested.
+type DecideCondonationRequest struct {
+	Approve         bool   `json:"approve"`
+	Remarks         string `json:"remarks,omitempty"`
+	GrantedSessions *int   `json:"granted_sessions,omitempty"`
+}
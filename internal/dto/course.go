@@ -0,0 +1,25 @@
+package dto
+
+type CreateCourseRequest struct {
+	Code string `json:"code" binding:"required"`
+	Name string `json:"name" binding:"required"`
+	Dept string `json:"dept,omitempty"`
+}
+
+type EnrollStudentsRequest struct {
+	StudentIDs []uint `json:"student_ids" binding:"required,min=1"`
+	Section    string `json:"section" binding:"required"`
+}
+
+type CreateSessionRequest struct {
+	CourseID     uint   `json:"course_id" binding:"required"`
+	Section      string `json:"section" binding:"required"`
+	Date         Date   `json:"date" binding:"required"`
+	Period       int    `json:"period" binding:"required,min=1"`
+	StartTime    string `json:"start_time,omitempty"`                              // HH:MM
+	EndTime      string `json:"end_time,omitempty"`                                // HH:MM
+	GraceMinutes *int   `json:"grace_minutes,omitempty" binding:"omitempty,min=0"` // overrides LATE_GRACE_PERIOD
+	FacultyID    uint   `json:"faculty_id,omitempty"`                              // admin only, defaults to the caller
+	GeofenceID   *uint  `json:"geofence_id,omitempty"`                             // classroom fence for check-ins
+	Room         string `json:"room,omitempty"`
+}
@@ -3,9 +3,13 @@ package dto
 import (
 	"encoding/json"
 	"time"
+
+	"attendance-workflow/pkg/clock"
 )
 
-// Date is a custom type that accepts YYYY-MM-DD format in JSON
+// Date is a custom type that accepts YYYY-MM-DD format in JSON. It holds
+// midnight UTC of the date as written; a timestamp keeps the date of its own
+// offset rather than being shifted into another day.
 type Date struct {
 	time.Time
 }
@@ -19,19 +23,19 @@ func (d *Date) UnmarshalJSON(data []byte) error {
 
 	// Try YYYY-MM-DD format first
 	if t, err := time.Parse("2006-01-02", dateStr); err == nil {
-		d.Time = t
+		d.Time = clock.Date(t)
 		return nil
 	}
 
 	// Fallback to RFC3339 format
 	if t, err := time.Parse(time.RFC3339, dateStr); err == nil {
-		d.Time = t
+		d.Time = clock.Date(t)
 		return nil
 	}
 
 	// Try RFC3339 without timezone
 	if t, err := time.Parse("2006-01-02T15:04:05Z", dateStr); err == nil {
-		d.Time = t
+		d.Time = clock.Date(t)
 		return nil
 	}
 
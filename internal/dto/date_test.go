@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"attendance-workflow/pkg/config"
+)
+
+func TestDateJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"date", `"2026-03-08"`, "2026-03-08"},
+		{"UTC just before midnight", `"2026-03-08T23:59:59Z"`, "2026-03-08"},
+		{"UTC at midnight", `"2026-03-09T00:00:00Z"`, "2026-03-09"},
+		{"UTC+5:30 just before midnight", `"2026-03-08T23:59:59+05:30"`, "2026-03-08"},
+		{"UTC+5:30 just after midnight", `"2026-03-09T00:00:01+05:30"`, "2026-03-09"},
+		{"UTC+14 just after midnight", `"2026-01-10T00:00:01+14:00"`, "2026-01-10"},
+		{"UTC-5 just before midnight", `"2026-01-09T23:59:59-05:00"`, "2026-01-09"},
+		{"inside the spring-forward gap", `"2026-03-08T02:30:00-05:00"`, "2026-03-08"},
+		{"fall-back overlap, first", `"2026-11-01T01:30:00-04:00"`, "2026-11-01"},
+		{"fall-back overlap, second", `"2026-11-01T01:30:00-05:00"`, "2026-11-01"},
+	}
+
+	// The date as written must not depend on the institution's timezone
+	for _, zone := range []string{"UTC", "Asia/Kolkata", "Pacific/Kiritimati", "America/New_York"} {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			t.Fatalf("load %s: %v", zone, err)
+		}
+		previous := config.AppConfig.Server.Timezone
+		config.AppConfig.Server.Timezone = loc
+
+		for _, tt := range tests {
+			t.Run(zone+"/"+tt.name, func(t *testing.T) {
+				var d Date
+				if err := json.Unmarshal([]byte(tt.input), &d); err != nil {
+					t.Fatalf("unmarshal %s: %v", tt.input, err)
+				}
+				want, _ := time.Parse("2006-01-02", tt.want)
+				if !d.Time.Equal(want) || d.Time.Location() != time.UTC {
+					t.Errorf("unmarshal %s = %v, want %s UTC", tt.input, d.Time, tt.want)
+				}
+
+				out, err := json.Marshal(d)
+				if err != nil {
+					t.Fatalf("marshal: %v", err)
+				}
+				if string(out) != `"`+tt.want+`"` {
+					t.Errorf("marshal = %s, want %q", out, tt.want)
+				}
+				var back Date
+				if err := json.Unmarshal(out, &back); err != nil || !back.Time.Equal(d.Time) {
+					t.Errorf("round trip of %s gave %v (%v)", out, back.Time, err)
+				}
+			})
+		}
+		config.AppConfig.Server.Timezone = previous
+	}
+}
+
+func TestDateJSONInvalid(t *testing.T) {
+	for _, input := range []string{`"08/03/2026"`, `"2026-02-30"`, `"2026-03-08 10:00"`, `20260308`} {
+		var d Date
+		if err := json.Unmarshal([]byte(input), &d); err == nil {
+			t.Errorf("unmarshal %s = %v, want an error", input, d.Time)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package dto
+
+// SetEligibilityRuleRequest sets the minimum attendance of a course, or of
+// every course of a program (dept) when course_id is omitted. Leaving both
+// out sets the institution default.
+type SetEligibilityRuleRequest struct {
+	Dept              string   `json:"dept,omitempty"`
+	CourseID          *uint    `json:"course_id,omitempty"`
+	MinPercentage     float64  `json:"min_percentage" binding:"required,gt=0,lte=100"`
+	WarningPercentage *float64 `json:"warning_percentage,omitempty"` // defaults to 5 points above the minimum
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+type CreateHostelRequest struct {
+	Name     string `json:"name" binding:"required"`
+	WardenID *uint  `json:"warden_id,omitempty"`
+}
+
+// AssignResidentRequest places a student in a hostel, moving them out of
+// any other hostel
+type AssignResidentRequest struct {
+	StudentID     uint   `json:"student_id" binding:"required"`
+	Block         string `json:"block,omitempty"`
+	Room          string `json:"room,omitempty"`
+	GuardianName  string `json:"guardian_name,omitempty"`
+	GuardianPhone string `json:"guardian_phone,omitempty"`
+	GuardianEmail string `json:"guardian_email,omitempty" binding:"omitempty,email"`
+}
+
+type RequestGatePassRequest struct {
+	Destination      string    `json:"destination,omitempty"`
+	Reason           string    `json:"reason" binding:"required"`
+	OutAt            time.Time `json:"out_at" binding:"required"`             // RFC 3339
+	ExpectedReturnAt time.Time `json:"expected_return_at" binding:"required"` // RFC 3339
+}
+
+type DecideGatePassRequest struct {
+	Approve bool   `json:"approve"`
+	Remarks string `json:"remarks,omitempty"`
+}
+
+// StartRollCallRequest opens the roll call of a hostel, or of one block,
+// for a night. The date defaults to today.
+type StartRollCallRequest struct {
+	HostelID uint   `json:"hostel_id" binding:"required"`
+	Block    string `json:"block,omitempty"`
+	Date     Date   `json:"date"`
+}
+
+type MarkRollCallRequest struct {
+	Entries []RollCallMark `json:"entries" binding:"required,min=1,dive"`
+}
+
+type RollCallMark struct {
+	StudentID uint   `json:"student_id" binding:"required"`
+	Status    string `json:"status" binding:"required"` // present, absent, out_on_pass or on_leave
+	Remarks   string `json:"remarks,omitempty"`
+}
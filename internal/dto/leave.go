@@ -1,16 +1,56 @@
 package dto
 
+// ApplyLeaveRequest submits a leave, or saves it as a draft to submit later
 type ApplyLeaveRequest struct {
 	LeaveType string `json:"leave_type" binding:"required"`
 	Reason    string `json:"reason" binding:"required"`
 	StartDate Date   `json:"start_date" binding:"required"`
 	EndDate   Date   `json:"end_date" binding:"required"`
+	Draft     bool   `json:"draft,omitempty"`
 }
 
+// ApproveLeaveRequest records the decision of one step of the current
+// stage. Role picks the step when an admin should decide only one of them;
+// otherwise admins decide every step of the stage.
 type ApproveLeaveRequest struct {
-	Approved bool   `json:"approved" binding:"required"`
+	Approved *bool  `json:"approved" binding:"required"`
 	Remarks  string `json:"remarks,omitempty"`
+	Role     string `json:"role,omitempty"`
 }
 
+type RevokeLeaveRequest struct {
+	Remarks string `json:"remarks,omitempty"`
+}
+
+// LeaveWorkflowRequest creates or replaces a leave approval workflow. Leave
+// out a criterion to match any leave.
+type LeaveWorkflowRequest struct {
+	Name           string `json:"name" binding:"required"`
+	LeaveType      string `json:"leave_type,omitempty"`
+	MinDays        int    `json:"min_days,omitempty" binding:"min=0"`
+	Dept           string `json:"dept,omitempty"`
+	HostelResident *bool  `json:"hostel_resident,omitempty"`
+	Priority       int    `json:"priority,omitempty"`
+	ApprovalPath   string `json:"approval_path" binding:"required"` // e.g. "faculty,warden" or "faculty+warden,hod"
+}
 
+// SetLeaveQuotaRequest sets the working days of a leave type a student can
+// take per term, for a program (dept) or the whole institution
+type SetLeaveQuotaRequest struct {
+	Dept      string `json:"dept,omitempty"`
+	LeaveType string `json:"leave_type" binding:"required"`
+	Days      *int   `json:"days" binding:"required,min=0"`
+	OnExceed  string `json:"on_exceed,omitempty"` // reject (default) or flag
+}
 
+// CancelLeaveRequest asks an approver to cancel an approved leave
+type CancelLeaveRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ConfirmCancellationRequest accepts or declines a student's request to
+// cancel an approved leave
+type ConfirmCancellationRequest struct {
+	Approved *bool  `json:"approved" binding:"required"`
+	Remarks  string `json:"remarks,omitempty"`
+}
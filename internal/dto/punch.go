@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+// IngestPunchesRequest is a batch of punches pushed by a terminal or its
+// middleware. terminal_code applies to punches that do not name one.
+type IngestPunchesRequest struct {
+	TerminalCode string        `json:"terminal_code,omitempty"`
+	Punches      []PunchRecord `json:"punches" binding:"required,min=1,dive"`
+}
+
+type PunchRecord struct {
+	CardCode     string    `json:"card_code" binding:"required"`
+	TerminalCode string    `json:"terminal_code,omitempty"`
+	Timestamp    time.Time `json:"timestamp" binding:"required"` // RFC 3339
+}
+
+type CreateCardRequest struct {
+	Code   string `json:"code" binding:"required"`
+	Kind   string `json:"kind,omitempty"` // rfid (default) or biometric
+	UserID uint   `json:"user_id" binding:"required"`
+}
+
+type CreateTerminalRequest struct {
+	Code     string `json:"code" binding:"required"`
+	Name     string `json:"name,omitempty"`
+	Location string `json:"location,omitempty"`
+	Mode     string `json:"mode,omitempty"` // daily (default) or session
+}
@@ -0,0 +1,38 @@
+package dto
+
+// CreateTimetableEntryRequest adds a weekly slot to a section's timetable.
+// The validity defaults to the whole term.
+type CreateTimetableEntryRequest struct {
+	TermID       uint   `json:"term_id" binding:"required"`
+	CourseID     uint   `json:"course_id" binding:"required"`
+	Section      string `json:"section" binding:"required"`
+	FacultyID    uint   `json:"faculty_id" binding:"required"`
+	Room         string `json:"room,omitempty"`
+	GeofenceID   *uint  `json:"geofence_id,omitempty"`
+	Weekday      *int   `json:"weekday" binding:"required,min=0,max=6"` // 0 = Sunday
+	Period       int    `json:"period" binding:"required,min=1"`
+	StartTime    string `json:"start_time,omitempty"`                              // HH:MM
+	EndTime      string `json:"end_time,omitempty"`                                // HH:MM
+	GraceMinutes *int   `json:"grace_minutes,omitempty" binding:"omitempty,min=0"` // overrides LATE_GRACE_PERIOD
+	ValidFrom    Date   `json:"valid_from"`
+	ValidTo      Date   `json:"valid_to"`
+}
+
+// GenerateSessionsRequest creates the expected sessions of a term's
+// timetable between from and to, which default to the term's dates
+type GenerateSessionsRequest struct {
+	TermID   uint   `json:"term_id" binding:"required"`
+	From     Date   `json:"from"`
+	To       Date   `json:"to"`
+	CourseID *uint  `json:"course_id,omitempty"`
+	Section  string `json:"section,omitempty"`
+}
+
+type SubstituteSessionRequest struct {
+	FacultyID uint   `json:"faculty_id" binding:"required"`
+	Remarks   string `json:"remarks,omitempty"`
+}
+
+type CancelSessionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
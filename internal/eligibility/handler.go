@@ -0,0 +1,269 @@
+package eligibility
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EligibilityHandler struct {
+	DB      db.GormDB
+	Service *Service
+}
+
+func NewEligibilityHandler() *EligibilityHandler {
+	return &EligibilityHandler{DB: db.DB, Service: NewService(db.DB)}
+}
+
+// GetRules godoc
+// @Summary      List eligibility rules
+// @Description  List the minimum attendance rules per course and program, with the default that applies otherwise
+// @Tags         eligibility
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array,default=object}
+// @Router       /eligibility/rules [get]
+func (h *EligibilityHandler) GetRules(c *gin.Context) {
+	var rules []db.EligibilityRule
+	h.DB.Preload("Course").Order("course_id NULLS FIRST, dept").Find(&rules)
+
+	c.JSON(http.StatusOK, gin.H{"data": rules, "default": h.Service.ThresholdFor(0, "")})
+}
+
+// SetRule godoc
+// @Summary      Set eligibility rule
+// @Description  Create or replace the minimum attendance of a course, of a program (dept) or of the institution (admin only)
+// @Tags         eligibility
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.SetEligibilityRuleRequest  true  "Rule"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /eligibility/rules [put]
+func (h *EligibilityHandler) SetRule(c *gin.Context) {
+	var req dto.SetEligibilityRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	warning := math.Min(req.MinPercentage+5, 100)
+	if req.WarningPercentage != nil {
+		warning = *req.WarningPercentage
+	}
+	if warning < req.MinPercentage || warning > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "warning_percentage must be between min_percentage and 100"})
+		return
+	}
+
+	query := h.DB.Model(&db.EligibilityRule{})
+	if req.CourseID != nil {
+		var course db.Course
+		if err := h.DB.First(&course, *req.CourseID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Course not found"})
+			return
+		}
+		req.Dept = ""
+		query = query.Where("course_id = ?", *req.CourseID)
+	} else {
+		query = query.Where("course_id IS NULL AND dept = ?", req.Dept)
+	}
+
+	var rule db.EligibilityRule
+	query.First(&rule)
+	rule.Dept, rule.CourseID = req.Dept, req.CourseID
+	rule.MinPercentage, rule.WarningPercentage = req.MinPercentage, warning
+	if err := h.DB.Save(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save eligibility rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Eligibility rule saved", "data": rule})
+}
+
+// DeleteRule godoc
+// @Summary      Delete eligibility rule
+// @Tags         eligibility
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Rule ID"
+// @Success      200  {object}  object{message=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /eligibility/rules/{id} [delete]
+func (h *EligibilityHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	result := h.DB.Delete(&db.EligibilityRule{}, id)
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Eligibility rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Eligibility rule deleted successfully"})
+}
+
+// GetMyEligibility godoc
+// @Summary      Get my exam eligibility
+// @Description  Attendance against each enrolled course's minimum, with how many more classes can be missed or must be attended
+// @Tags         eligibility
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array}
+// @Router       /eligibility/my [get]
+func (h *EligibilityHandler) GetMyEligibility(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+
+	h.respondForStudent(c, uid)
+}
+
+// GetStudentEligibility godoc
+// @Summary      Get a student's exam eligibility
+// @Tags         eligibility
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Student ID"
+// @Success      200  {object}  object{data=array}
+// @Router       /eligibility/students/{id} [get]
+func (h *EligibilityHandler) GetStudentEligibility(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid student ID"})
+		return
+	}
+
+	h.respondForStudent(c, uint(id))
+}
+
+func (h *EligibilityHandler) respondForStudent(c *gin.Context, studentID uint) {
+	results, err := h.Service.ForStudent(studentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute eligibility"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+// GetCourseReport godoc
+// @Summary      Course eligibility report
+// @Description  Eligibility of every student enrolled in a course, for the course's faculty, the HOD of its department or an admin. Use format=csv to download it.
+// @Tags         eligibility
+// @Produce      json
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Param        id       path      int     true   "Course ID"
+// @Param        section  query     string  false  "Section"
+// @Param        band     query     string  false  "eligible, warning or critical"
+// @Param        format   query     string  false  "json (default) or csv"
+// @Success      200      {object}  object{course=object,data=array,summary=object}
+// @Failure      403      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Router       /eligibility/courses/{id}/report [get]
+func (h *EligibilityHandler) GetCourseReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid course ID"})
+		return
+	}
+
+	var course db.Course
+	if err := h.DB.First(&course, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+	if !h.canReport(c, &course) {
+		return
+	}
+
+	results, err := h.Service.ForCourse(course.ID, c.Query("section"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute eligibility"})
+		return
+	}
+
+	summary := make(map[db.EligibilityBand]int)
+	filtered := results[:0]
+	for _, result := range results {
+		summary[result.Band]++
+		if band := c.Query("band"); band == "" || string(result.Band) == band {
+			filtered = append(filtered, result)
+		}
+	}
+
+	if c.Query("format") == "csv" {
+		writeReportCSV(c, course, filtered)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"course": course, "data": filtered, "summary": summary})
+}
+
+// canReport allows admins, the HOD of the course's department and faculty
+// teaching the course
+func (h *EligibilityHandler) canReport(c *gin.Context, course *db.Course) bool {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	switch role {
+	case string(db.RoleAdmin):
+		return true
+	case string(db.RoleHOD):
+		var hod db.User
+		if err := h.DB.First(&hod, userID).Error; err == nil && hod.Dept != "" && hod.Dept == course.Dept {
+			return true
+		}
+	case string(db.RoleFaculty):
+		var teaches int64
+		h.DB.Model(&db.ClassSession{}).Where("course_id = ? AND faculty_id = ?", course.ID, userID).Count(&teaches)
+		if teaches > 0 {
+			return true
+		}
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "You cannot view the eligibility report of this course"})
+	return false
+}
+
+func writeReportCSV(c *gin.Context, course db.Course, results []CourseEligibility) {
+	filename := fmt.Sprintf("eligibility-%s-%s.csv", course.Code, clock.Today().Format("2006-01-02"))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{
+		"student_id", "student_name", "section", "sessions_held", "weighted_sessions", "condoned_sessions", "attendance_percentage",
+		"min_percentage", "band", "eligible", "remaining_sessions", "can_miss", "must_attend",
+	})
+	for _, r := range results {
+		w.Write([]string{
+			strconv.FormatUint(uint64(r.StudentID), 10),
+			r.StudentName,
+			r.Section,
+			strconv.Itoa(r.SessionsHeld),
+			strconv.FormatFloat(r.WeightedSessions, 'f', 2, 64),
+			strconv.FormatFloat(r.CondonedSessions, 'f', 2, 64),
+			strconv.FormatFloat(r.Percentage, 'f', 2, 64),
+			strconv.FormatFloat(r.Threshold.Minimum, 'f', 2, 64),
+			string(r.Band),
+			strconv.FormatBool(r.Eligible),
+			strconv.Itoa(r.RemainingSessions),
+			strconv.Itoa(r.CanMiss),
+			strconv.Itoa(r.MustAttend),
+		})
+	}
+	w.Flush()
+}
@@ -0,0 +1,313 @@
+package eligibility
+
+import (
+	"math"
+	"sort"
+
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/config"
+	"attendance-workflow/pkg/db"
+)
+
+// epsilon absorbs float error in the projections so exact thresholds count
+// as met
+const epsilon = 1e-9
+
+// Threshold is the minimum and warning attendance, in percent, for a course
+type Threshold struct {
+	Minimum float64 `json:"min_percentage"`
+	Warning float64 `json:"warning_percentage"`
+}
+
+// CourseEligibility is where a student stands against a course's minimum.
+// The projection counts the course section's remaining scheduled sessions;
+// when none are scheduled it looks at the next classes, however many are
+// held.
+type CourseEligibility struct {
+	StudentID         uint               `json:"student_id"`
+	StudentName       string             `json:"student_name,omitempty"`
+	CourseID          uint               `json:"course_id"`
+	Code              string             `json:"code"`
+	Name              string             `json:"name"`
+	Section           string             `json:"section"`
+	SessionsHeld      int                `json:"sessions_held"`
+	WeightedSessions  float64            `json:"weighted_sessions"`
+	LatePenalty       float64            `json:"late_penalty,omitempty"`      // weight lost to LATES_PER_ABSENCE
+	CondonedSessions  float64            `json:"condoned_sessions,omitempty"` // missed sessions excused by approved condonation
+	Percentage        float64            `json:"attendance_percentage"`
+	Threshold         Threshold          `json:"threshold"`
+	Band              db.EligibilityBand `json:"band"`
+	Eligible          bool               `json:"eligible"`
+	RemainingSessions int                `json:"remaining_sessions"`
+	CanMiss           int                `json:"can_miss"`    // further classes that can be missed while staying eligible
+	MustAttend        int                `json:"must_attend"` // further classes that must be attended to be eligible
+	Reachable         bool               `json:"reachable"`   // false when even full attendance of the remaining sessions falls short
+}
+
+type Service struct {
+	DB db.GormDB
+}
+
+func NewService(database db.GormDB) *Service {
+	return &Service{DB: database}
+}
+
+// ForStudent returns the eligibility of a student in each enrolled course
+func (s *Service) ForStudent(studentID uint) ([]CourseEligibility, error) {
+	var enrollments []db.Enrollment
+	if err := s.DB.Preload("Course").Preload("Student").Where("student_id = ?", studentID).Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	return s.evaluate(enrollments)
+}
+
+// ForCourse returns the eligibility of every student enrolled in a course,
+// or in one section of it when section is set
+func (s *Service) ForCourse(courseID uint, section string) ([]CourseEligibility, error) {
+	query := s.DB.Preload("Course").Preload("Student").Where("course_id = ?", courseID)
+	if section != "" {
+		query = query.Where("section = ?", section)
+	}
+	var enrollments []db.Enrollment
+	if err := query.Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	return s.evaluate(enrollments)
+}
+
+// All returns the eligibility of every enrollment, one course at a time
+func (s *Service) All() ([]CourseEligibility, error) {
+	var courseIDs []uint
+	if err := s.DB.Model(&db.Enrollment{}).Distinct("course_id").Pluck("course_id", &courseIDs).Error; err != nil {
+		return nil, err
+	}
+	var all []CourseEligibility
+	for _, courseID := range courseIDs {
+		results, err := s.ForCourse(courseID, "")
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, results...)
+	}
+	return all, nil
+}
+
+// ThresholdFor resolves the threshold of a course for a student of dept:
+// the course's rule, then the program's rule, then the configured default
+func (s *Service) ThresholdFor(courseID uint, dept string) Threshold {
+	return s.rules().resolve(courseID, dept)
+}
+
+type ruleSet struct {
+	byCourse map[uint]Threshold
+	byDept   map[string]Threshold
+}
+
+func (s *Service) rules() ruleSet {
+	set := ruleSet{byCourse: make(map[uint]Threshold), byDept: make(map[string]Threshold)}
+	var rules []db.EligibilityRule
+	s.DB.Find(&rules)
+	for _, rule := range rules {
+		threshold := Threshold{Minimum: rule.MinPercentage, Warning: rule.WarningPercentage}
+		if rule.CourseID != nil {
+			set.byCourse[*rule.CourseID] = threshold
+		} else {
+			set.byDept[rule.Dept] = threshold
+		}
+	}
+	return set
+}
+
+func (r ruleSet) resolve(courseID uint, dept string) Threshold {
+	if threshold, ok := r.byCourse[courseID]; ok {
+		return threshold
+	}
+	if threshold, ok := r.byDept[dept]; ok {
+		return threshold
+	}
+	if threshold, ok := r.byDept[""]; ok {
+		return threshold
+	}
+	return Threshold{
+		Minimum: config.AppConfig.Attendance.EligibilityMinPercent,
+		Warning: config.AppConfig.Attendance.EligibilityWarningPercent,
+	}
+}
+
+// evaluate computes eligibility for enrollments with Course and Student
+// preloaded, using a handful of grouped queries regardless of their number
+func (s *Service) evaluate(enrollments []db.Enrollment) ([]CourseEligibility, error) {
+	if len(enrollments) == 0 {
+		return []CourseEligibility{}, nil
+	}
+
+	type key struct{ student, course uint }
+	var studentIDs, courseIDs []uint
+	for _, enrollment := range enrollments {
+		studentIDs = append(studentIDs, enrollment.StudentID)
+		courseIDs = append(courseIDs, enrollment.CourseID)
+	}
+
+	// Sessions held so far, by student and course
+	var heldRows []struct {
+		StudentID uint
+		CourseID  uint
+		Held      int
+		Weighted  float64
+		Lates     int
+	}
+	if err := s.DB.Model(&db.Attendance{}).
+		Select("attendances.student_id, class_sessions.course_id, COUNT(*) AS held, COALESCE(SUM("+db.AttendanceWeightSQL("attendances.status")+"), 0) AS weighted, "+
+			"SUM(CASE WHEN attendances.status = 'late' THEN 1 ELSE 0 END) AS lates").
+		Joins("JOIN class_sessions ON class_sessions.id = attendances.session_id").
+		Where("attendances.student_id IN ? AND class_sessions.course_id IN ?", studentIDs, courseIDs).
+		Group("attendances.student_id, class_sessions.course_id").
+		Scan(&heldRows).Error; err != nil {
+		return nil, err
+	}
+	held := make(map[key]int, len(heldRows))
+	weighted := make(map[key]float64, len(heldRows))
+	lates := make(map[key]int, len(heldRows))
+	for _, row := range heldRows {
+		held[key{row.StudentID, row.CourseID}] = row.Held
+		lates[key{row.StudentID, row.CourseID}] = row.Lates
+		weighted[key{row.StudentID, row.CourseID}] = row.Weighted - db.LatePenalty(row.Lates)
+	}
+
+	// Sessions scheduled from today on that the student has no record for yet
+	today := clock.Today()
+	var upcoming []db.ClassSession
+	if err := s.DB.Select("id", "course_id", "section").
+		Where("course_id IN ? AND date >= ? AND status <> ?", courseIDs, today, db.SessionCancelled).
+		Find(&upcoming).Error; err != nil {
+		return nil, err
+	}
+	type slot struct {
+		course  uint
+		section string
+	}
+	scheduled := make(map[slot]int)
+	sessionSlot := make(map[uint]slot, len(upcoming))
+	upcomingIDs := make([]uint, 0, len(upcoming))
+	for _, session := range upcoming {
+		sl := slot{session.CourseID, session.Section}
+		scheduled[sl]++
+		sessionSlot[session.ID] = sl
+		upcomingIDs = append(upcomingIDs, session.ID)
+	}
+	markedAhead := make(map[key]int)
+	if len(upcomingIDs) > 0 {
+		var marked []db.Attendance
+		if err := s.DB.Select("student_id", "session_id").
+			Where("session_id IN ? AND student_id IN ?", upcomingIDs, studentIDs).
+			Find(&marked).Error; err != nil {
+			return nil, err
+		}
+		for _, record := range marked {
+			markedAhead[key{record.StudentID, sessionSlot[*record.SessionID].course}]++
+		}
+	}
+
+	// Sessions excused by approved condonations
+	var condonedRows []struct {
+		StudentID uint
+		CourseID  uint
+		Granted   int
+	}
+	if err := s.DB.Model(&db.Condonation{}).
+		Select("student_id, course_id, SUM(granted_sessions) AS granted").
+		Where("status = ? AND student_id IN ? AND course_id IN ?", db.CondonationApproved, studentIDs, courseIDs).
+		Group("student_id, course_id").
+		Scan(&condonedRows).Error; err != nil {
+		return nil, err
+	}
+	condoned := make(map[key]int, len(condonedRows))
+	for _, row := range condonedRows {
+		condoned[key{row.StudentID, row.CourseID}] = row.Granted
+	}
+
+	rules := s.rules()
+	results := make([]CourseEligibility, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		k := key{enrollment.StudentID, enrollment.CourseID}
+		result := CourseEligibility{
+			StudentID:         enrollment.StudentID,
+			StudentName:       enrollment.Student.Name,
+			CourseID:          enrollment.CourseID,
+			Code:              enrollment.Course.Code,
+			Name:              enrollment.Course.Name,
+			Section:           enrollment.Section,
+			SessionsHeld:      held[k],
+			WeightedSessions:  weighted[k],
+			LatePenalty:       db.LatePenalty(lates[k]),
+			Threshold:         rules.resolve(enrollment.CourseID, enrollment.Student.Dept),
+			RemainingSessions: scheduled[slot{enrollment.CourseID, enrollment.Section}] - markedAhead[k],
+		}
+		if result.RemainingSessions < 0 {
+			result.RemainingSessions = 0
+		}
+		// Condonation only excuses sessions that were actually missed
+		result.CondonedSessions = math.Min(float64(condoned[k]), float64(result.SessionsHeld)-result.WeightedSessions)
+		project(&result)
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Code != results[j].Code {
+			return results[i].Code < results[j].Code
+		}
+		return results[i].StudentName < results[j].StudentName
+	})
+	return results, nil
+}
+
+// project sets the percentage, band and projection of a result from its
+// held, weighted, condoned and remaining sessions
+func project(r *CourseEligibility) {
+	minimum := r.Threshold.Minimum / 100
+	held, attended, remaining := float64(r.SessionsHeld), r.WeightedSessions+r.CondonedSessions, float64(r.RemainingSessions)
+
+	r.Percentage = 100
+	if r.SessionsHeld > 0 {
+		r.Percentage = attended / held * 100
+	}
+	switch {
+	case r.Percentage+epsilon < r.Threshold.Minimum:
+		r.Band = db.BandCritical
+	case r.Percentage+epsilon < r.Threshold.Warning:
+		r.Band = db.BandWarning
+	default:
+		r.Band = db.BandEligible
+	}
+	r.Eligible = r.Band != db.BandCritical
+	r.Reachable = true
+
+	if r.RemainingSessions > 0 {
+		// Attending n of the remaining sessions ends at (attended+n)/(held+remaining)
+		need := int(math.Ceil(minimum*(held+remaining) - attended - epsilon))
+		if need < 0 {
+			need = 0
+		}
+		if need > r.RemainingSessions {
+			r.Reachable = false
+			r.MustAttend = r.RemainingSessions
+			return
+		}
+		r.MustAttend = need
+		r.CanMiss = r.RemainingSessions - need
+		return
+	}
+
+	// Open-ended: consecutive misses or attendances from now on
+	if r.Eligible {
+		if minimum > 0 {
+			r.CanMiss = int(math.Floor(attended/minimum - held + epsilon))
+		}
+		return
+	}
+	if minimum >= 1 {
+		r.Reachable = false
+		return
+	}
+	r.MustAttend = int(math.Ceil((minimum*held-attended)/(1-minimum) - epsilon))
+}
@@ -0,0 +1,461 @@
+package files
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/pkg/config"
+	"attendance-workflow/pkg/db"
+	"attendance-workflow/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Allowed MIME types per file kind, detected from the file content
+var allowedTypes = map[db.FileKind][]string{
+	db.FileKindProfilePhoto: {"image/jpeg", "image/png", "image/gif"},
+	db.FileKindDocument:     {"application/pdf", "image/jpeg", "image/png"},
+}
+
+var extensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+}
+
+type FileHandler struct {
+	DB      db.GormDB
+	Storage storage.Storage
+}
+
+func NewFileHandler() *FileHandler {
+	store, err := storage.New(config.AppConfig.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize file storage: %v", err)
+	}
+	return &FileHandler{DB: db.DB, Storage: store}
+}
+
+// UploadDocument godoc
+// @Summary      Upload a document
+// @Description  Upload a supporting document (PDF, JPEG or PNG)
+// @Tags         files
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file  formData  file  true  "Document"
+// @Success      201   {object}  object{message=string,data=object}
+// @Failure      400   {object}  object{error=string}
+// @Failure      413   {object}  object{error=string}
+// @Router       /files [post]
+func (h *FileHandler) UploadDocument(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	file, ok := h.store(c, uid, db.FileKindDocument)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "File uploaded successfully", "data": h.fileResponse(file)})
+}
+
+// UploadProfilePhoto godoc
+// @Summary      Upload profile photo
+// @Description  Upload or replace the authenticated user's profile photo
+// @Tags         files
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file  formData  file  true  "Photo (JPEG, PNG or GIF)"
+// @Success      201   {object}  object{message=string,data=object}
+// @Failure      400   {object}  object{error=string}
+// @Failure      413   {object}  object{error=string}
+// @Router       /files/profile-photo [post]
+func (h *FileHandler) UploadProfilePhoto(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var user db.User
+	if err := h.DB.First(&user, uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	file, ok := h.store(c, uid, db.FileKindProfilePhoto)
+	if !ok {
+		return
+	}
+
+	previous := user.PhotoFileID
+	if err := h.DB.Model(&user).Update("photo_file_id", file.ID).Error; err != nil {
+		// Nothing refers to the new photo
+		h.remove(c, file)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile photo"})
+		return
+	}
+
+	// The previous photo is no longer referenced
+	if previous != nil {
+		var old db.File
+		if err := h.DB.First(&old, *previous).Error; err == nil {
+			h.remove(c, &old)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Profile photo updated successfully", "data": h.fileResponse(file)})
+}
+
+// GetUserPhoto godoc
+// @Summary      Get user photo
+// @Description  Get signed URLs for a user's profile photo and thumbnail
+// @Tags         files
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  object{data=object}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /users/{id}/photo [get]
+func (h *FileHandler) GetUserPhoto(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+	if uint(id) != uid && role == string(db.RoleStudent) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return
+	}
+
+	var user db.User
+	if err := h.DB.First(&user, id).Error; err != nil || user.PhotoFileID == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile photo not found"})
+		return
+	}
+
+	var file db.File
+	if err := h.DB.First(&file, *user.PhotoFileID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile photo not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": h.fileResponse(&file)})
+}
+
+// GetFile godoc
+// @Summary      Get file
+// @Description  Get file metadata with signed, expiring download URLs
+// @Tags         files
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "File ID"
+// @Success      200  {object}  object{data=object}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /files/{id} [get]
+func (h *FileHandler) GetFile(c *gin.Context) {
+	file, ok := h.accessibleFile(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": h.fileResponse(file)})
+}
+
+// DeleteFile godoc
+// @Summary      Delete file
+// @Description  Delete a file (owner or admin)
+// @Tags         files
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "File ID"
+// @Success      200  {object}  object{message=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /files/{id} [delete]
+func (h *FileHandler) DeleteFile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+
+	var file db.File
+	if err := h.DB.First(&file, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	if file.OwnerID != uid && role != string(db.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return
+	}
+
+	h.DB.Model(&db.User{}).Where("photo_file_id = ?", file.ID).Update("photo_file_id", nil)
+	if !h.remove(c, &file) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "File deleted successfully"})
+}
+
+// Download godoc
+// @Summary      Download file
+// @Description  Download a file using a signed URL obtained from the file endpoints
+// @Tags         files
+// @Produce      octet-stream
+// @Param        id         path      int     true   "File ID"
+// @Param        variant    query     string  false  "original or thumbnail"
+// @Param        expires    query     int     true   "Expiry (unix seconds)"
+// @Param        signature  query     string  true   "URL signature"
+// @Success      200
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /files/{id}/download [get]
+func (h *FileHandler) Download(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+	variant := c.DefaultQuery("variant", variantOriginal)
+
+	if !verifySignature(uint(id), variant, c.Query("expires"), c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired download link"})
+		return
+	}
+
+	var file db.File
+	if err := h.DB.First(&file, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	key, contentType := file.StorageKey, file.ContentType
+	if variant == variantThumbnail {
+		if file.ThumbnailKey == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not available"})
+			return
+		}
+		key, contentType = file.ThumbnailKey, "image/jpeg"
+	}
+
+	reader, err := h.Storage.Get(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", file.FileName))
+	c.Header("Cache-Control", "private, max-age=300")
+	c.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
+}
+
+// store validates the multipart "file" field and saves it, along with a
+// thumbnail for photos. It writes the error response and returns false on failure.
+func (h *FileHandler) store(c *gin.Context, ownerID uint, kind db.FileKind) (*db.File, bool) {
+	maxBytes := config.AppConfig.Storage.MaxUploadBytes
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File exceeds the maximum size of %d bytes", maxBytes)})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return nil, false
+	}
+	if header.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File exceeds the maximum size of %d bytes", maxBytes)})
+		return nil, false
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return nil, false
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxBytes+1))
+	if err != nil || int64(len(data)) > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File exceeds the maximum size of %d bytes", maxBytes)})
+		return nil, false
+	}
+
+	// Trust the content, not the client-supplied header
+	contentType := http.DetectContentType(data)
+	if !isAllowed(kind, contentType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File type %s is not allowed, expected one of %s",
+			contentType, strings.Join(allowedTypes[kind], ", "))})
+		return nil, false
+	}
+
+	var thumbnail []byte
+	if kind == db.FileKindProfilePhoto {
+		thumbnail, err = generateThumbnail(data)
+		if errors.Is(err, errImageTooLarge) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Image must be at most %d megapixels", maxImagePixels/1_000_000)})
+			return nil, false
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image file"})
+			return nil, false
+		}
+	}
+
+	key, err := newStorageKey(kind, ownerID, contentType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	if err := h.Storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		log.Printf("Failed to store file %s: %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return nil, false
+	}
+
+	file := db.File{
+		OwnerID:     ownerID,
+		Kind:        kind,
+		FileName:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  key,
+	}
+
+	if thumbnail != nil {
+		file.ThumbnailKey = strings.TrimSuffix(key, filepath.Ext(key)) + "_thumb.jpg"
+		if err := h.Storage.Put(ctx, file.ThumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err != nil {
+			log.Printf("Failed to store thumbnail %s: %v", file.ThumbnailKey, err)
+			h.Storage.Delete(ctx, key)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+			return nil, false
+		}
+	}
+
+	if err := h.DB.Create(&file).Error; err != nil {
+		h.Storage.Delete(ctx, key)
+		if file.ThumbnailKey != "" {
+			h.Storage.Delete(ctx, file.ThumbnailKey)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return nil, false
+	}
+
+	return &file, true
+}
+
+// remove deletes the file record and its stored objects
+func (h *FileHandler) remove(c *gin.Context, file *db.File) bool {
+	if err := h.DB.Delete(file).Error; err != nil {
+		return false
+	}
+
+	ctx := c.Request.Context()
+	if err := h.Storage.Delete(ctx, file.StorageKey); err != nil {
+		log.Printf("Failed to delete stored file %s: %v", file.StorageKey, err)
+	}
+	if file.ThumbnailKey != "" {
+		if err := h.Storage.Delete(ctx, file.ThumbnailKey); err != nil {
+			log.Printf("Failed to delete stored thumbnail %s: %v", file.ThumbnailKey, err)
+		}
+	}
+	return true
+}
+
+// accessibleFile loads the file from the :id param if the caller may see it.
+// Owners can always access their files, staff can access any file.
+func (h *FileHandler) accessibleFile(c *gin.Context) (*db.File, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return nil, false
+	}
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	role, _ := c.Get("role")
+
+	var file db.File
+	if err := h.DB.First(&file, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return nil, false
+	}
+
+	if file.OwnerID != uid && role == string(db.RoleStudent) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return nil, false
+	}
+
+	return &file, true
+}
+
+func (h *FileHandler) fileResponse(file *db.File) gin.H {
+	url, expiresAt := signedURL(file.ID, variantOriginal)
+	resp := gin.H{
+		"file":       file,
+		"url":        url,
+		"expires_at": expiresAt,
+	}
+	if file.ThumbnailKey != "" {
+		thumbURL, _ := signedURL(file.ID, variantThumbnail)
+		resp["thumbnail_url"] = thumbURL
+	}
+	return resp
+}
+
+func isAllowed(kind db.FileKind, contentType string) bool {
+	for _, allowed := range allowedTypes[kind] {
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func newStorageKey(kind db.FileKind, ownerID uint, contentType string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	ext := extensions[contentType]
+	return fmt.Sprintf("%s/%d/%s%s", kind, ownerID, hex.EncodeToString(buf), ext), nil
+}
@@ -0,0 +1,93 @@
+package files
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// Register decoders for the accepted photo formats
+	_ "image/gif"
+	_ "image/png"
+)
+
+const thumbnailSize = 256
+
+// maxImagePixels caps the decoded size of a photo. A small compressed file
+// can declare huge dimensions and would otherwise be decoded in full.
+const maxImagePixels = 40_000_000
+
+var errImageTooLarge = errors.New("image dimensions are too large")
+
+// generateThumbnail decodes an image and returns a JPEG scaled down to fit
+// within thumbnailSize x thumbnailSize, preserving the aspect ratio
+func generateThumbnail(data []byte) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		return nil, errImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, image.ErrFormat
+	}
+
+	dstW, dstH := width, height
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			dstW = thumbnailSize
+			dstH = max(1, height*thumbnailSize/width)
+		} else {
+			dstH = thumbnailSize
+			dstW = max(1, width*thumbnailSize/height)
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := bounds.Min.Y + y*height/dstH
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/dstH)
+		for x := 0; x < dstW; x++ {
+			x0 := bounds.Min.X + x*width/dstW
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/dstW)
+			dst.Set(x, y, averageColor(src, x0, y0, x1, y1))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// averageColor box-filters the source pixels in [x0,x1) x [y0,y1)
+func averageColor(src image.Image, x0, y0, x1, y1 int) color.RGBA {
+	var r, g, b, a, n uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			cr, cg, cb, ca := src.At(x, y).RGBA()
+			r += uint64(cr)
+			g += uint64(cg)
+			b += uint64(cb)
+			a += uint64(ca)
+			n++
+		}
+	}
+	return color.RGBA{
+		R: uint8(r / n >> 8),
+		G: uint8(g / n >> 8),
+		B: uint8(b / n >> 8),
+		A: uint8(a / n >> 8),
+	}
+}
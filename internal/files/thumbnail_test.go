@@ -0,0 +1,43 @@
+package files
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGenerateThumbnail(t *testing.T) {
+	thumb, err := generateThumbnail(encodePNG(t, 1024, 512))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil || format != "jpeg" || cfg.Width != thumbnailSize || cfg.Height != thumbnailSize/2 {
+		t.Errorf("thumbnail is %s %dx%d (%v), want jpeg %dx%d", format, cfg.Width, cfg.Height, err, thumbnailSize, thumbnailSize/2)
+	}
+}
+
+func TestGenerateThumbnailRejectsHugeDimensions(t *testing.T) {
+	// A tiny PNG whose header claims 50000x50000 pixels
+	data := encodePNG(t, 1, 1)
+	binary.BigEndian.PutUint32(data[16:], 50000)
+	binary.BigEndian.PutUint32(data[20:], 50000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, err := generateThumbnail(data); !errors.Is(err, errImageTooLarge) {
+		t.Errorf("generateThumbnail = %v, want errImageTooLarge", err)
+	}
+}
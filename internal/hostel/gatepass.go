@@ -0,0 +1,243 @@
+package hostel
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestGatePass godoc
+// @Summary      Request gate pass
+// @Description  Ask the warden for permission to stay out of the hostel (resident students only)
+// @Tags         gate-passes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.RequestGatePassRequest  true  "Gate pass"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /gate-passes [post]
+func (h *HostelHandler) RequestGatePass(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RequestGatePassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.ExpectedReturnAt.After(req.OutAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected_return_at must be after out_at"})
+		return
+	}
+
+	var residents int64
+	h.DB.Model(&db.HostelResident{}).Where("student_id = ?", uid).Count(&residents)
+	if residents == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Gate passes are only for hostel residents"})
+		return
+	}
+
+	pass := db.GatePass{
+		StudentID:        uid,
+		Destination:      req.Destination,
+		Reason:           req.Reason,
+		OutAt:            req.OutAt,
+		ExpectedReturnAt: req.ExpectedReturnAt,
+		Status:           db.GatePassPending,
+	}
+	if err := h.DB.Create(&pass).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request gate pass"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Gate pass requested", "data": clock.In(pass, auth.Zone(c))})
+}
+
+// GetGatePasses godoc
+// @Summary      List gate passes
+// @Description  Students see their own passes, wardens and admins every pass
+// @Tags         gate-passes
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status     query     string  false  "pending, approved, rejected or cancelled"
+// @Param        hostel_id  query     int     false  "Hostel ID"
+// @Param        out        query     bool    false  "Only approved passes whose student has not returned"
+// @Success      200        {object}  object{data=array}
+// @Router       /gate-passes [get]
+func (h *HostelHandler) GetGatePasses(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+
+	query := h.DB.Model(&db.GatePass{})
+	if role == string(db.RoleStudent) {
+		query = query.Where("student_id = ?", uid)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if hostelID := c.Query("hostel_id"); hostelID != "" {
+		residents := h.DB.Model(&db.HostelResident{}).Select("student_id").Where("hostel_id = ?", hostelID)
+		query = query.Where("student_id IN (?)", residents)
+	}
+	if c.Query("out") == "true" {
+		query = query.Where("status = ? AND out_at <= ? AND returned_at IS NULL", db.GatePassApproved, time.Now())
+	}
+
+	var passes []db.GatePass
+	query.Preload("Student").Preload("Approver").Order("out_at DESC").Find(&passes)
+
+	c.JSON(http.StatusOK, gin.H{"data": clock.In(passes, auth.Zone(c))})
+}
+
+// DecideGatePass godoc
+// @Summary      Approve or reject gate pass
+// @Tags         gate-passes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                        true  "Gate pass ID"
+// @Param        request  body      dto.DecideGatePassRequest  true  "Decision"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Router       /gate-passes/{id}/decide [put]
+func (h *HostelHandler) DecideGatePass(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.DecideGatePassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pass, ok := h.managedPass(c)
+	if !ok {
+		return
+	}
+	if pass.Status != db.GatePassPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Gate pass is already " + string(pass.Status)})
+		return
+	}
+
+	pass.Status = db.GatePassRejected
+	if req.Approve {
+		pass.Status = db.GatePassApproved
+	}
+	pass.ApprovedBy = &uid
+	pass.Remarks = req.Remarks
+	if err := h.DB.Save(pass).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update gate pass"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Gate pass " + string(pass.Status), "data": clock.In(pass, auth.Zone(c))})
+}
+
+// ReturnGatePass godoc
+// @Summary      Record return
+// @Description  Record that the student of an approved gate pass is back in the hostel
+// @Tags         gate-passes
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Gate pass ID"
+// @Success      200  {object}  object{message=string,data=object}
+// @Failure      400  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /gate-passes/{id}/return [put]
+func (h *HostelHandler) ReturnGatePass(c *gin.Context) {
+	pass, ok := h.managedPass(c)
+	if !ok {
+		return
+	}
+	if pass.Status != db.GatePassApproved || pass.ReturnedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only approved passes not yet returned can be closed"})
+		return
+	}
+
+	now := time.Now()
+	pass.ReturnedAt = &now
+	if err := h.DB.Model(pass).Update("returned_at", &now).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record return"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Return recorded", "data": clock.In(pass, auth.Zone(c))})
+}
+
+// CancelGatePass godoc
+// @Summary      Cancel gate pass
+// @Description  Withdraw a pending pass, or an approved one before leaving (student only)
+// @Tags         gate-passes
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Gate pass ID"
+// @Success      200  {object}  object{message=string,data=object}
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /gate-passes/{id}/cancel [put]
+func (h *HostelHandler) CancelGatePass(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var pass db.GatePass
+	if err := h.DB.Where("id = ? AND student_id = ?", c.Param("id"), uid).First(&pass).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Gate pass not found"})
+		return
+	}
+	if pass.Status != db.GatePassPending && (pass.Status != db.GatePassApproved || !pass.OutAt.After(time.Now())) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending passes, or approved passes before leaving, can be cancelled"})
+		return
+	}
+
+	pass.Status = db.GatePassCancelled
+	if err := h.DB.Model(&pass).Update("status", pass.Status).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel gate pass"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Gate pass cancelled", "data": clock.In(pass, auth.Zone(c))})
+}
+
+// managedPass loads a gate pass of a resident of a hostel the caller manages
+func (h *HostelHandler) managedPass(c *gin.Context) (*db.GatePass, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gate pass ID"})
+		return nil, false
+	}
+
+	var pass db.GatePass
+	if err := h.DB.Preload("Student").First(&pass, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Gate pass not found"})
+		return nil, false
+	}
+
+	var hostel db.Hostel
+	err = h.DB.Joins("JOIN hostel_residents ON hostel_residents.hostel_id = hostels.id").
+		Where("hostel_residents.student_id = ?", pass.StudentID).First(&hostel).Error
+	if err == nil && !canManage(c, &hostel) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This student lives in a hostel managed by another warden"})
+		return nil, false
+	}
+	return &pass, true
+}
@@ -0,0 +1,211 @@
+package hostel
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HostelHandler struct {
+	DB db.GormDB
+}
+
+func NewHostelHandler() *HostelHandler {
+	return &HostelHandler{DB: db.DB}
+}
+
+// GetHostels godoc
+// @Summary      List hostels
+// @Tags         hostels
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array}
+// @Router       /hostels [get]
+func (h *HostelHandler) GetHostels(c *gin.Context) {
+	var hostels []db.Hostel
+	h.DB.Preload("Warden").Order("name").Find(&hostels)
+
+	c.JSON(http.StatusOK, gin.H{"data": hostels})
+}
+
+// CreateHostel godoc
+// @Summary      Create hostel
+// @Description  Create a hostel, optionally assigning its warden (admin only)
+// @Tags         hostels
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateHostelRequest  true  "Hostel"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string}
+// @Router       /hostels [post]
+func (h *HostelHandler) CreateHostel(c *gin.Context) {
+	var req dto.CreateHostelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.WardenID != nil {
+		var warden db.User
+		if err := h.DB.Where("id = ? AND role = ?", *req.WardenID, db.RoleWarden).First(&warden).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Warden not found"})
+			return
+		}
+	}
+
+	hostel := db.Hostel{Name: req.Name, WardenID: req.WardenID}
+	if err := h.DB.Create(&hostel).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A hostel with this name already exists"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Hostel created successfully", "data": hostel})
+}
+
+// GetResidents godoc
+// @Summary      List residents
+// @Description  List the residents of a hostel with their guardian contacts
+// @Tags         hostels
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      int     true   "Hostel ID"
+// @Param        block  query     string  false  "Block"
+// @Success      200    {object}  object{data=array}
+// @Failure      403    {object}  object{error=string}
+// @Failure      404    {object}  object{error=string}
+// @Router       /hostels/{id}/residents [get]
+func (h *HostelHandler) GetResidents(c *gin.Context) {
+	hostel, ok := h.managedHostel(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	query := h.DB.Preload("Student").Where("hostel_id = ?", hostel.ID)
+	if block := c.Query("block"); block != "" {
+		query = query.Where("block = ?", block)
+	}
+	var residents []db.HostelResident
+	query.Order("block, room").Find(&residents)
+
+	c.JSON(http.StatusOK, gin.H{"data": residents})
+}
+
+// AssignResident godoc
+// @Summary      Assign resident
+// @Description  Place a student in a hostel room, moving them out of their previous hostel
+// @Tags         hostels
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                        true  "Hostel ID"
+// @Param        request  body      dto.AssignResidentRequest  true  "Resident"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Router       /hostels/{id}/residents [post]
+func (h *HostelHandler) AssignResident(c *gin.Context) {
+	hostel, ok := h.managedHostel(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req dto.AssignResidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var student db.User
+	if err := h.DB.Where("id = ? AND role = ?", req.StudentID, db.RoleStudent).First(&student).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Student not found"})
+		return
+	}
+
+	var resident db.HostelResident
+	h.DB.Where("student_id = ?", req.StudentID).First(&resident)
+	resident.HostelID = hostel.ID
+	resident.StudentID = req.StudentID
+	resident.Block = req.Block
+	resident.Room = req.Room
+	resident.GuardianName = req.GuardianName
+	resident.GuardianPhone = req.GuardianPhone
+	resident.GuardianEmail = req.GuardianEmail
+	if err := h.DB.Save(&resident).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign resident"})
+		return
+	}
+	resident.Student = student
+
+	c.JSON(http.StatusOK, gin.H{"message": "Resident assigned successfully", "data": resident})
+}
+
+// RemoveResident godoc
+// @Summary      Remove resident
+// @Description  Move a student out of a hostel
+// @Tags         hostels
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          path      int  true  "Hostel ID"
+// @Param        student_id  path      int  true  "Student ID"
+// @Success      200         {object}  object{message=string}
+// @Failure      403         {object}  object{error=string}
+// @Failure      404         {object}  object{error=string}
+// @Router       /hostels/{id}/residents/{student_id} [delete]
+func (h *HostelHandler) RemoveResident(c *gin.Context) {
+	hostel, ok := h.managedHostel(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	result := h.DB.Where("hostel_id = ? AND student_id = ?", hostel.ID, c.Param("student_id")).Delete(&db.HostelResident{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resident not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Resident removed successfully"})
+}
+
+// managedHostel loads a hostel the caller may manage: admins manage every
+// hostel, wardens the hostels assigned to them and those without a warden
+func (h *HostelHandler) managedHostel(c *gin.Context, rawID string) (*db.Hostel, bool) {
+	id, err := strconv.ParseUint(rawID, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hostel ID"})
+		return nil, false
+	}
+
+	var hostel db.Hostel
+	if err := h.DB.First(&hostel, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Hostel not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load hostel"})
+		}
+		return nil, false
+	}
+
+	if !canManage(c, &hostel) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This hostel is managed by another warden"})
+		return nil, false
+	}
+	return &hostel, true
+}
+
+func canManage(c *gin.Context, hostel *db.Hostel) bool {
+	role, _ := c.Get("role")
+	if role == string(db.RoleAdmin) {
+		return true
+	}
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+	return role == string(db.RoleWarden) && (hostel.WardenID == nil || *hostel.WardenID == uid)
+}
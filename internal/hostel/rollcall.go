@@ -0,0 +1,406 @@
+package hostel
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/internal/notifications"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/config"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StartRollCall godoc
+// @Summary      Start roll call
+// @Description  Open the night roll call of a hostel or block. Every resident gets an entry; residents on an approved leave or out on a valid gate pass are accounted for automatically.
+// @Tags         roll-calls
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.StartRollCallRequest  true  "Roll call"
+// @Success      201      {object}  object{message=string,data=object,summary=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string,data=object}
+// @Router       /roll-calls [post]
+func (h *HostelHandler) StartRollCall(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.StartRollCallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hostel, ok := h.managedHostel(c, strconv.FormatUint(uint64(req.HostelID), 10))
+	if !ok {
+		return
+	}
+
+	date := clock.Date(req.Date.Time)
+	if req.Date.IsZero() {
+		date = clock.Today()
+	}
+
+	var existing db.RollCall
+	if err := h.DB.Where("hostel_id = ? AND block = ? AND date = ?", hostel.ID, req.Block, date).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "The roll call of this night has already been started", "data": existing})
+		return
+	}
+
+	query := h.DB.Where("hostel_id = ?", hostel.ID)
+	if req.Block != "" {
+		query = query.Where("block = ?", req.Block)
+	}
+	var residents []db.HostelResident
+	query.Find(&residents)
+	if len(residents) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No residents to call"})
+		return
+	}
+
+	rollCall := db.RollCall{HostelID: hostel.ID, Block: req.Block, Date: date, TakenBy: uid}
+	for _, resident := range residents {
+		rollCall.Entries = append(rollCall.Entries, db.RollCallEntry{StudentID: resident.StudentID})
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := reconcile(tx, &rollCall); err != nil {
+			return err
+		}
+		return tx.Create(&rollCall).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start roll call"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Roll call started", "data": rollCall, "summary": summarize(rollCall.Entries)})
+}
+
+// GetRollCalls godoc
+// @Summary      List roll calls
+// @Tags         roll-calls
+// @Produce      json
+// @Security     BearerAuth
+// @Param        hostel_id  query     int     false  "Hostel ID"
+// @Param        date       query     string  false  "Night (YYYY-MM-DD)"
+// @Param        page       query     int     false  "Page number" default(1)
+// @Param        limit      query     int     false  "Items per page" default(10)
+// @Success      200        {object}  object{data=array,page=int,limit=int,total=int64,total_pages=int64}
+// @Failure      400        {object}  object{error=string}
+// @Router       /roll-calls [get]
+func (h *HostelHandler) GetRollCalls(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	query := h.DB.Model(&db.RollCall{})
+	if hostelID := c.Query("hostel_id"); hostelID != "" {
+		query = query.Where("hostel_id = ?", hostelID)
+	}
+	if raw := c.Query("date"); raw != "" {
+		date, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+			return
+		}
+		query = query.Where("date = ?", date)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var rollCalls []db.RollCall
+	query.Preload("Hostel").Order("date DESC, hostel_id, block").Limit(limit).Offset((page - 1) * limit).Find(&rollCalls)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        rollCalls,
+		"page":        page,
+		"limit":       limit,
+		"total":       total,
+		"total_pages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// GetRollCall godoc
+// @Summary      Get roll call
+// @Description  Get a roll call with every resident's entry and the count of each status
+// @Tags         roll-calls
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Roll call ID"
+// @Success      200  {object}  object{data=object,summary=object}
+// @Failure      404  {object}  object{error=string}
+// @Router       /roll-calls/{id} [get]
+func (h *HostelHandler) GetRollCall(c *gin.Context) {
+	rollCall, ok := h.loadRollCall(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rollCall, "summary": summarize(rollCall.Entries)})
+}
+
+// MarkRollCall godoc
+// @Summary      Mark roll call
+// @Description  Mark residents present or absent. A resident marked absent who is covered by an approved leave or gate pass is recorded as on_leave or out_on_pass; anyone else marked absent is unaccounted for and their warden and guardian are alerted at once.
+// @Tags         roll-calls
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                      true  "Roll call ID"
+// @Param        request  body      dto.MarkRollCallRequest  true  "Entries"
+// @Success      200      {object}  object{message=string,data=object,summary=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Router       /roll-calls/{id}/entries [put]
+func (h *HostelHandler) MarkRollCall(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MarkRollCallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rollCall, ok := h.openRollCall(c)
+	if !ok {
+		return
+	}
+
+	entries := make(map[uint]*db.RollCallEntry, len(rollCall.Entries))
+	for i := range rollCall.Entries {
+		entries[rollCall.Entries[i].StudentID] = &rollCall.Entries[i]
+	}
+	for _, mark := range req.Entries {
+		entry, found := entries[mark.StudentID]
+		if !found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Student " + strconv.FormatUint(uint64(mark.StudentID), 10) + " is not called in this roll call"})
+			return
+		}
+		status := db.RollCallStatus(mark.Status)
+		switch status {
+		case db.RollCallPresent, db.RollCallAbsent:
+		case db.RollCallOutOnPass, db.RollCallOnLeave:
+			// Only a leave or gate pass accounts for an absence, see reconcile
+			status = db.RollCallAbsent
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be present, absent, out_on_pass or on_leave"})
+			return
+		}
+		entry.Status = status
+		entry.LeaveID, entry.GatePassID = nil, nil
+		entry.MarkedBy = uid
+		entry.Remarks = mark.Remarks
+	}
+
+	alerted, err := h.save(rollCall)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark roll call"})
+		return
+	}
+	alertUnaccounted(c, rollCall.ID, alerted)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Roll call marked", "data": rollCall, "summary": summarize(rollCall.Entries)})
+}
+
+// CloseRollCall godoc
+// @Summary      Close roll call
+// @Description  Finish a roll call. Residents still unmarked are checked against leaves and gate passes once more, and the rest are unaccounted for.
+// @Tags         roll-calls
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Roll call ID"
+// @Success      200  {object}  object{message=string,data=object,summary=object}
+// @Failure      400  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /roll-calls/{id}/close [put]
+func (h *HostelHandler) CloseRollCall(c *gin.Context) {
+	rollCall, ok := h.openRollCall(c)
+	if !ok {
+		return
+	}
+
+	for i := range rollCall.Entries {
+		if rollCall.Entries[i].Status == "" {
+			rollCall.Entries[i].Status = db.RollCallAbsent
+		}
+	}
+	now := time.Now()
+	rollCall.ClosedAt = &now
+
+	alerted, err := h.save(rollCall)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close roll call"})
+		return
+	}
+	alertUnaccounted(c, rollCall.ID, alerted)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Roll call closed", "data": rollCall, "summary": summarize(rollCall.Entries)})
+}
+
+// save reconciles and stores a roll call and its entries, returning the
+// residents newly found unaccounted for
+func (h *HostelHandler) save(rollCall *db.RollCall) ([]uint, error) {
+	var alerted []uint
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := reconcile(tx, rollCall); err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range rollCall.Entries {
+			entry := &rollCall.Entries[i]
+			if entry.Status == db.RollCallAbsent && entry.AlertedAt == nil {
+				entry.AlertedAt = &now
+				alerted = append(alerted, entry.StudentID)
+			}
+			if err := tx.Omit("Student").Save(entry).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Entries", "Hostel").Save(rollCall).Error
+	})
+	return alerted, err
+}
+
+// reconcile accounts for unmarked and absent residents who are on an
+// approved leave that night or out on a gate pass valid at roll call time
+func reconcile(tx *gorm.DB, rollCall *db.RollCall) error {
+	var studentIDs []uint
+	for _, entry := range rollCall.Entries {
+		if entry.Status == "" || entry.Status == db.RollCallAbsent {
+			studentIDs = append(studentIDs, entry.StudentID)
+		}
+	}
+	if len(studentIDs) == 0 {
+		return nil
+	}
+
+	var leaves []db.LeaveRequest
+	if err := tx.Where("student_id IN ? AND status IN ? AND start_date <= ? AND end_date >= ?",
+		studentIDs, db.LeaveInEffect, rollCall.Date, rollCall.Date).Find(&leaves).Error; err != nil {
+		return err
+	}
+	onLeave := make(map[uint]uint, len(leaves))
+	for _, leave := range leaves {
+		onLeave[leave.StudentID] = leave.ID
+	}
+
+	at := callTime(rollCall.Date)
+	var passes []db.GatePass
+	if err := tx.Where("student_id IN ? AND status = ? AND out_at <= ? AND expected_return_at >= ?",
+		studentIDs, db.GatePassApproved, at, at).Find(&passes).Error; err != nil {
+		return err
+	}
+	outOnPass := make(map[uint]uint, len(passes))
+	for i := range passes {
+		if passes[i].Covers(at) {
+			outOnPass[passes[i].StudentID] = passes[i].ID
+		}
+	}
+
+	for i := range rollCall.Entries {
+		entry := &rollCall.Entries[i]
+		if entry.Status != "" && entry.Status != db.RollCallAbsent {
+			continue
+		}
+		if leaveID, ok := onLeave[entry.StudentID]; ok {
+			entry.Status, entry.LeaveID = db.RollCallOnLeave, &leaveID
+		} else if passID, ok := outOnPass[entry.StudentID]; ok {
+			entry.Status, entry.GatePassID = db.RollCallOutOnPass, &passID
+		}
+	}
+	return nil
+}
+
+// callTime is when the roll call of a night is taken, in the institution's
+// timezone
+func callTime(date time.Time) time.Time {
+	t, err := time.Parse("15:04", config.AppConfig.Attendance.RollCallTime)
+	if err != nil {
+		t = time.Date(0, 1, 1, 23, 59, 0, 0, time.UTC)
+	}
+	return clock.At(date, t.Hour(), t.Minute())
+}
+
+func summarize(entries []db.RollCallEntry) gin.H {
+	counts := map[db.RollCallStatus]int{}
+	for _, entry := range entries {
+		counts[entry.Status]++
+	}
+	return gin.H{
+		"residents":   len(entries),
+		"present":     counts[db.RollCallPresent],
+		"absent":      counts[db.RollCallAbsent],
+		"out_on_pass": counts[db.RollCallOutOnPass],
+		"on_leave":    counts[db.RollCallOnLeave],
+		"unmarked":    counts[""],
+	}
+}
+
+func (h *HostelHandler) loadRollCall(c *gin.Context) (*db.RollCall, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid roll call ID"})
+		return nil, false
+	}
+
+	var rollCall db.RollCall
+	if err := h.DB.Preload("Hostel").
+		Preload("Entries", func(tx *gorm.DB) *gorm.DB { return tx.Order("student_id") }).Preload("Entries.Student").
+		First(&rollCall, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Roll call not found"})
+		return nil, false
+	}
+	return &rollCall, true
+}
+
+// openRollCall loads a roll call the caller manages that is still open
+func (h *HostelHandler) openRollCall(c *gin.Context) (*db.RollCall, bool) {
+	rollCall, ok := h.loadRollCall(c)
+	if !ok {
+		return nil, false
+	}
+	if !canManage(c, &rollCall.Hostel) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This hostel is managed by another warden"})
+		return nil, false
+	}
+	if rollCall.ClosedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Roll call is closed"})
+		return nil, false
+	}
+	return rollCall, true
+}
+
+func alertUnaccounted(c *gin.Context, rollCallID uint, studentIDs []uint) {
+	if len(studentIDs) == 0 {
+		return
+	}
+	notifService := notifications.GetNotificationService()
+	if err := notifService.QueueRollCallAlert(c.Request.Context(), notifications.RollCallAlertPayload{
+		RollCallID: rollCallID,
+		StudentIDs: studentIDs,
+	}); err != nil {
+		log.Printf("Failed to queue roll call alert: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package leaves
+
+import (
+	"fmt"
+	"time"
+
+	"attendance-workflow/internal/calendar"
+	"attendance-workflow/pkg/db"
+
+	"gorm.io/gorm"
+)
+
+// leaveDays returns the working days covered by a leave according to the
+// academic calendar of the student's department
+func leaveDays(tx *gorm.DB, leave *db.LeaveRequest) []time.Time {
+	var student db.User
+	tx.Select("id", "dept").First(&student, leave.StudentID)
+
+	return calendar.NewService(tx).WorkingDays(student.Dept, leave.StartDate, leave.EndDate)
+}
+
+// applyLeaveAttendance materializes on_leave records for every working day of
+// an approved leave. Days the student was marked absent are converted, days
+// they were marked as attending are left alone and returned as conflicts.
+func applyLeaveAttendance(tx *gorm.DB, leave *db.LeaveRequest, actorID uint) (int, []db.Attendance, error) {
+	days := leaveDays(tx, leave)
+	if len(days) == 0 {
+		return 0, nil, nil
+	}
+
+	var existing []db.Attendance
+	if err := tx.Where("student_id = ? AND date IN ?", leave.StudentID, days).Find(&existing).Error; err != nil {
+		return 0, nil, err
+	}
+
+	marked := make(map[string]bool, len(existing))
+	var convert []db.Attendance
+	var conflicts []db.Attendance
+	for _, record := range existing {
+		if record.SessionID == nil {
+			marked[record.Date.Format("2006-01-02")] = true
+		}
+		switch {
+		case record.Status == db.AttendanceAbsent:
+			convert = append(convert, record)
+		case record.Status != db.AttendanceOnLeave:
+			conflicts = append(conflicts, record)
+		}
+	}
+
+	reason := fmt.Sprintf("Leave #%d approved", leave.ID)
+	for i := range convert {
+		record := &convert[i]
+		record.SetStatus(db.AttendanceOnLeave)
+		record.LeaveID = &leave.ID
+		record.Audit(actorID, reason)
+		if err := tx.Save(record).Error; err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var created []db.Attendance
+	for _, day := range days {
+		if marked[day.Format("2006-01-02")] {
+			continue
+		}
+		record := db.Attendance{
+			StudentID: leave.StudentID,
+			Date:      day,
+			Source:    db.SourceLeave,
+			LeaveID:   &leave.ID,
+			MarkedBy:  actorID,
+		}
+		record.SetStatus(db.AttendanceOnLeave)
+		record.Audit(actorID, reason)
+		created = append(created, record)
+	}
+
+	if len(created) > 0 {
+		if err := tx.CreateInBatches(&created, 100).Error; err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return len(created) + len(convert), conflicts, nil
+}
+
+// revertLeaveAttendance undoes applyLeaveAttendance when a leave is cancelled
+// or revoked: records created for the leave are removed and records that
+// were converted from absent go back to absent.
+func revertLeaveAttendance(tx *gorm.DB, leave *db.LeaveRequest, actorID uint) error {
+	reason := fmt.Sprintf("Leave #%d withdrawn", leave.ID)
+
+	var records []db.Attendance
+	if err := tx.Where("leave_id = ?", leave.ID).Find(&records).Error; err != nil {
+		return err
+	}
+	for i := range records {
+		record := &records[i]
+		record.Audit(actorID, reason)
+		switch {
+		case record.Source == db.SourceLeave:
+			if err := tx.Delete(record).Error; err != nil {
+				return err
+			}
+		case record.Status == db.AttendanceOnLeave:
+			record.SetStatus(db.AttendanceAbsent)
+			record.LeaveID = nil
+			if err := tx.Save(record).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Model(&db.Attendance{}).Where("leave_id = ?", leave.ID).Update("leave_id", nil).Error
+}
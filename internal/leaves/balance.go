@@ -0,0 +1,255 @@
+package leaves
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"attendance-workflow/internal/calendar"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var leaveTypes = []db.LeaveType{db.LeaveTypeMedical, db.LeaveTypePersonal, db.LeaveTypeEmergency, db.LeaveTypeOther}
+
+// Balance is what is left of a leave type's quota in a term. Leaves in
+// effect are deducted, pending ones are held back until they are decided.
+type Balance struct {
+	LeaveType db.LeaveType        `json:"leave_type"`
+	Quota     *int                `json:"quota"` // nil when the type is unlimited
+	Used      int                 `json:"used"`
+	Pending   int                 `json:"pending"`
+	Remaining *int                `json:"remaining"`
+	OnExceed  db.LeaveQuotaPolicy `json:"on_exceed,omitempty"`
+}
+
+// Period is the span quotas are counted over: the academic term, or the
+// calendar year when no terms are defined
+type Period struct {
+	TermID *uint     `json:"term_id,omitempty"`
+	Name   string    `json:"name"`
+	Start  time.Time `json:"start_date"`
+	End    time.Time `json:"end_date"`
+}
+
+// quotaPeriod returns the period containing date. Leaves count towards the
+// period of their start date.
+func quotaPeriod(tx *gorm.DB, date time.Time) (Period, bool) {
+	if term, ok := calendar.NewService(tx).TermOn(date); ok {
+		return Period{TermID: &term.ID, Name: term.Name, Start: term.StartDate, End: term.EndDate}, true
+	}
+	var terms int64
+	tx.Model(&db.AcademicTerm{}).Count(&terms)
+	if terms > 0 {
+		return Period{}, false
+	}
+	year := date.Year()
+	return Period{
+		Name:  strconv.Itoa(year),
+		Start: time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}, true
+}
+
+// leaveBalances returns the student's balance of every leave type in period.
+// exclude leaves a leave out of the counts, such as one being edited.
+func leaveBalances(tx *gorm.DB, studentID uint, period Period, exclude uint) []Balance {
+	var student db.User
+	tx.Select("id", "dept").First(&student, studentID)
+
+	var quotas []db.LeaveQuota
+	tx.Where("dept = '' OR dept = ?", student.Dept).Order("dept").Find(&quotas)
+	byType := make(map[db.LeaveType]db.LeaveQuota)
+	for _, quota := range quotas {
+		// Ordered by dept, so the program's quota overrides the institution's
+		byType[quota.LeaveType] = quota
+	}
+
+	type usage struct {
+		LeaveType db.LeaveType
+		Status    db.LeaveStatus
+		Days      int
+	}
+	var usages []usage
+	tx.Model(&db.LeaveRequest{}).
+		Select("leave_type, status, SUM(days) AS days").
+		Where("student_id = ? AND id <> ? AND start_date BETWEEN ? AND ?", studentID, exclude, period.Start, period.End).
+		Where("status IN ?", append(append([]db.LeaveStatus{}, db.LeaveAwaitingDecision...), db.LeaveInEffect...)).
+		Group("leave_type, status").
+		Scan(&usages)
+
+	balances := make([]Balance, 0, len(leaveTypes))
+	for _, leaveType := range leaveTypes {
+		balance := Balance{LeaveType: leaveType}
+		for _, u := range usages {
+			switch {
+			case u.LeaveType != leaveType:
+			case u.Status == db.StatusSubmitted || u.Status == db.StatusInReview:
+				balance.Pending += u.Days
+			default:
+				balance.Used += u.Days
+			}
+		}
+		if quota, ok := byType[leaveType]; ok {
+			remaining := quota.Days - balance.Used - balance.Pending
+			balance.Quota, balance.Remaining, balance.OnExceed = &quota.Days, &remaining, quota.OnExceed
+		}
+		balances = append(balances, balance)
+	}
+	return balances
+}
+
+// checkBalance compares a leave with what is left of its type's quota. It
+// returns the balance, and whether the leave fits. A leave outside any term
+// or of an unlimited type always fits.
+func checkBalance(tx *gorm.DB, leave *db.LeaveRequest) (*Balance, bool) {
+	period, ok := quotaPeriod(tx, leave.StartDate)
+	if !ok {
+		return nil, true
+	}
+	for _, balance := range leaveBalances(tx, leave.StudentID, period, leave.ID) {
+		if balance.LeaveType == leave.LeaveType {
+			return &balance, balance.Remaining == nil || leave.Days <= *balance.Remaining
+		}
+	}
+	return nil, true
+}
+
+// GetLeaveBalance godoc
+// @Summary      Get leave balance
+// @Description  Quota, used, pending and remaining working days of each leave type in the term containing date. Students see their own balance; faculty, HODs, wardens and admins pass student_id.
+// @Tags         leaves
+// @Produce      json
+// @Security     BearerAuth
+// @Param        student_id  query     int     false  "Student ID (staff only)"
+// @Param        date        query     string  false  "Date in the term (YYYY-MM-DD), defaults to today"
+// @Success      200         {object}  object{data=[]Balance,period=Period}
+// @Failure      400         {object}  object{error=string}
+// @Router       /leaves/balance [get]
+func (h *LeaveHandler) GetLeaveBalance(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return
+	}
+	studentID, _ := userID.(uint)
+	role, _ := c.Get("role")
+	if role != string(db.RoleStudent) {
+		id, err := strconv.ParseUint(c.Query("student_id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "student_id is required"})
+			return
+		}
+		studentID = uint(id)
+	}
+
+	date := clock.Today()
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+	period, ok := quotaPeriod(h.DB, date)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No academic term on " + date.Format("2006-01-02")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": leaveBalances(h.DB, studentID, period, 0), "period": period})
+}
+
+// GetQuotas godoc
+// @Summary      List leave quotas
+// @Description  List the per-term leave quotas of the institution and of each program
+// @Tags         leaves
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array}
+// @Router       /leaves/quotas [get]
+func (h *LeaveHandler) GetQuotas(c *gin.Context) {
+	var quotas []db.LeaveQuota
+	h.DB.Order("dept, leave_type").Find(&quotas)
+	c.JSON(http.StatusOK, gin.H{"data": quotas})
+}
+
+// SetQuota godoc
+// @Summary      Set leave quota
+// @Description  Create or replace the working days of a leave type a student can take per term, for a program (dept) or the institution. on_exceed is reject or flag (admin only)
+// @Tags         leaves
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.SetLeaveQuotaRequest  true  "Quota"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /leaves/quotas [put]
+func (h *LeaveHandler) SetQuota(c *gin.Context) {
+	var req dto.SetLeaveQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validLeaveType(db.LeaveType(req.LeaveType)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "leave_type must be Medical, Personal, Emergency or Other"})
+		return
+	}
+	policy := db.LeaveQuotaPolicy(req.OnExceed)
+	if policy == "" {
+		policy = db.QuotaReject
+	}
+	if policy != db.QuotaReject && policy != db.QuotaFlag {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "on_exceed must be reject or flag"})
+		return
+	}
+
+	var quota db.LeaveQuota
+	h.DB.Where("dept = ? AND leave_type = ?", req.Dept, req.LeaveType).First(&quota)
+	quota.Dept, quota.LeaveType = req.Dept, db.LeaveType(req.LeaveType)
+	quota.Days, quota.OnExceed = *req.Days, policy
+	if err := h.DB.Save(&quota).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save leave quota"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Leave quota saved", "data": quota})
+}
+
+// DeleteQuota godoc
+// @Summary      Delete leave quota
+// @Description  Delete a leave quota, making the leave type unlimited unless an institution quota applies (admin only)
+// @Tags         leaves
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Quota ID"
+// @Success      200  {object}  object{message=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /leaves/quotas/{id} [delete]
+func (h *LeaveHandler) DeleteQuota(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quota ID"})
+		return
+	}
+	result := h.DB.Delete(&db.LeaveQuota{}, id)
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave quota not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Leave quota deleted successfully"})
+}
+
+func validLeaveType(leaveType db.LeaveType) bool {
+	for _, t := range leaveTypes {
+		if t == leaveType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,329 @@
+package leaves
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/internal/notifications"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UpdateLeave godoc
+// @Summary      Edit leave
+// @Description  Change a draft, or a submitted leave request before any approver has decided on it (student only). The days, balance and approval path of a submitted request are worked out again.
+// @Tags         leaves
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                    true  "Leave ID"
+// @Param        request  body      dto.ApplyLeaveRequest  true  "Leave request details"
+// @Success      200      {object}  object{message=string,data=object,warnings=[]string}
+// @Failure      400      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string,conflicts=array}
+// @Router       /leaves/{id} [put]
+func (h *LeaveHandler) UpdateLeave(c *gin.Context) {
+	leave, ok := h.loadOwn(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ApplyLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch leave.Status {
+	case db.StatusDraft, db.StatusSubmitted:
+	case db.StatusInReview:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An approver has already decided on this request; withdraw it and apply again"})
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only drafts and submitted leave requests can be edited"})
+		return
+	}
+
+	warnings, err := h.saveLeave(c, &req, leave)
+	if errors.Is(err, errLeaveRejected) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update leave request"})
+		return
+	}
+	if leave.Status == db.StatusSubmitted {
+		queueApprovalNotification(c, leave.ID, notifications.LeaveEdited)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Leave request updated", "data": leave, "warnings": warnings})
+}
+
+// WithdrawLeave godoc
+// @Summary      Withdraw leave
+// @Description  Withdraw a draft or a leave request that is not decided yet (student only). Its days go back to the balance.
+// @Tags         leaves
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Leave ID"
+// @Success      200  {object}  object{message=string,data=object}
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /leaves/{id}/withdraw [put]
+func (h *LeaveHandler) WithdrawLeave(c *gin.Context) {
+	leave, ok := h.loadOwn(c)
+	if !ok {
+		return
+	}
+	submitted := leave.AwaitingDecision()
+	if err := leave.MoveTo(db.StatusWithdrawn, leave.StudentID, ""); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only leave requests that are not decided yet can be withdrawn"})
+		return
+	}
+
+	leave.SetAwaiting(nil)
+	if err := h.DB.Omit("Student", "Approver", "Approvals").Save(leave).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw leave request"})
+		return
+	}
+	if submitted {
+		queueApprovalNotification(c, leave.ID, notifications.LeaveWithdrawn)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Leave request withdrawn", "data": leave})
+}
+
+// RequestCancellation godoc
+// @Summary      Request leave cancellation
+// @Description  Ask to cancel an approved leave that has not ended yet (student only). The leave stays in effect until an approver confirms.
+// @Tags         leaves
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                     true  "Leave ID"
+// @Param        request  body      dto.CancelLeaveRequest  true  "Reason"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Router       /leaves/{id}/cancel [put]
+func (h *LeaveHandler) RequestCancellation(c *gin.Context) {
+	leave, ok := h.loadOwn(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CancelLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if leave.Status != db.StatusApproved {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only approved leaves can be cancelled"})
+		return
+	}
+	if leave.EndDate.Before(clock.Today()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The leave has already ended"})
+		return
+	}
+
+	if err := leave.MoveTo(db.StatusCancellationRequested, leave.StudentID, req.Reason); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	leave.CancellationReason = req.Reason
+	if err := h.DB.Omit("Student", "Approver", "Approvals").Save(leave).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request cancellation"})
+		return
+	}
+	queueApprovalNotification(c, leave.ID, notifications.LeaveCancellationRequested)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cancellation requested", "data": leave})
+}
+
+// ConfirmCancellation godoc
+// @Summary      Confirm leave cancellation
+// @Description  Accept or decline a student's request to cancel an approved leave. Any role of the leave's approval path can decide for their students, admins for everyone. Accepting cancels the leave and removes the on_leave attendance it created.
+// @Tags         leaves
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                             true  "Leave ID"
+// @Param        request  body      dto.ConfirmCancellationRequest  true  "Decision"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Router       /leaves/{id}/cancellation [put]
+func (h *LeaveHandler) ConfirmCancellation(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+	role, _ := c.Get("role")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave ID"})
+		return
+	}
+
+	var req dto.ConfirmCancellationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var leave db.LeaveRequest
+	if err := h.DB.First(&leave, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave request not found"})
+		return
+	}
+	if leave.Status != db.StatusCancellationRequested {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No cancellation was requested for this leave"})
+		return
+	}
+	if role != string(db.RoleAdmin) {
+		step := db.UserRole(role.(string))
+		inPath := false
+		for _, stage := range leave.Stages() {
+			inPath = inPath || containsRole(stage, step)
+		}
+		if !inPath || !h.isApprover(uid, step, &leave) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not approve leaves of this student"})
+			return
+		}
+	}
+
+	if req.Remarks != "" {
+		leave.Remarks = req.Remarks
+	}
+	if !*req.Approved {
+		if err := leave.MoveTo(db.StatusApproved, uid, req.Remarks); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err := h.DB.Save(&leave).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decline cancellation"})
+			return
+		}
+		queueApprovalNotification(c, leave.ID, notifications.LeaveCancellationDeclined)
+		c.JSON(http.StatusOK, gin.H{"message": "Cancellation declined", "data": leave})
+		return
+	}
+
+	if err := leave.MoveTo(db.StatusCancelled, uid, req.Remarks); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&leave).Error; err != nil {
+			return err
+		}
+		return revertLeaveAttendance(tx, &leave, uid)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel leave request"})
+		return
+	}
+
+	notifService := notifications.GetNotificationService()
+	if err := notifService.QueueLeaveStatusNotification(c.Request.Context(), notifications.LeaveStatusUpdatePayload{
+		LeaveID:   leave.ID,
+		StudentID: leave.StudentID,
+		Status:    string(leave.Status),
+		Remarks:   leave.Remarks,
+	}); err != nil {
+		log.Printf("Failed to queue notification: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Leave request cancelled", "data": leave})
+}
+
+// SubmitLeave godoc
+// @Summary      Submit draft leave
+// @Description  Submit a draft leave request for approval (student only). Its days, overlaps and balance are checked as when applying.
+// @Tags         leaves
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Leave ID"
+// @Success      200  {object}  object{message=string,data=object,warnings=[]string}
+// @Failure      400  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Failure      409  {object}  object{error=string,conflicts=array}
+// @Router       /leaves/{id}/submit [put]
+func (h *LeaveHandler) SubmitLeave(c *gin.Context) {
+	leave, ok := h.loadOwn(c)
+	if !ok {
+		return
+	}
+	if leave.Status != db.StatusDraft {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only drafts can be submitted"})
+		return
+	}
+
+	if err := leave.MoveTo(db.StatusSubmitted, leave.StudentID, ""); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	req := dto.ApplyLeaveRequest{
+		LeaveType: string(leave.LeaveType),
+		Reason:    leave.Reason,
+		StartDate: dto.Date{Time: leave.StartDate},
+		EndDate:   dto.Date{Time: leave.EndDate},
+	}
+	warnings, err := h.saveLeave(c, &req, leave)
+	if errors.Is(err, errLeaveRejected) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit leave request"})
+		return
+	}
+	queueApprovalNotification(c, leave.ID, notifications.LeaveSubmitted)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Leave request submitted successfully", "data": leave, "warnings": warnings})
+}
+
+// saveLeave runs prepareLeave and saves the leave in one transaction. It
+// returns errLeaveRejected once prepareLeave has responded.
+func (h *LeaveHandler) saveLeave(c *gin.Context, req *dto.ApplyLeaveRequest, leave *db.LeaveRequest) ([]string, error) {
+	var warnings []string
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockStudent(tx, leave.StudentID); err != nil {
+			return err
+		}
+		var ok bool
+		if warnings, ok = h.prepareLeave(c, tx, req, leave); !ok {
+			return errLeaveRejected
+		}
+		return tx.Omit("Student", "Approver", "Approvals").Save(leave).Error
+	})
+	return warnings, err
+}
+
+// loadOwn loads the caller's leave named by the id parameter
+func (h *LeaveHandler) loadOwn(c *gin.Context) (*db.LeaveRequest, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave ID"})
+		return nil, false
+	}
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return nil, false
+	}
+
+	var leave db.LeaveRequest
+	if err := h.DB.Where("student_id = ?", userID).First(&leave, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave request not found"})
+		return nil, false
+	}
+	return &leave, true
+}
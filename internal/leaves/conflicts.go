@@ -0,0 +1,63 @@
+package leaves
+
+import (
+	"errors"
+	"fmt"
+
+	"attendance-workflow/internal/calendar"
+	"attendance-workflow/pkg/db"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errLeaveRejected rolls back a leave that prepareLeave has already
+// answered for
+var errLeaveRejected = errors.New("leave rejected")
+
+// lockStudent locks the student's row until the transaction ends, so
+// concurrent applications of one student see each other's leaves in the
+// overlap and balance checks
+func lockStudent(tx *gorm.DB, studentID uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&db.User{}, studentID).Error
+}
+
+// lockLeave locks the leave's row until the transaction ends, so concurrent
+// decisions on it are taken one after the other
+func lockLeave(tx *gorm.DB, id uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&db.LeaveRequest{}, id).Error
+}
+
+// overlappingLeaves returns the student's other leaves that share a day with
+// leave and are awaiting a decision or in effect. Drafts do not block.
+func overlappingLeaves(tx *gorm.DB, leave *db.LeaveRequest) []db.LeaveRequest {
+	var leaves []db.LeaveRequest
+	tx.Where("student_id = ? AND id <> ? AND start_date <= ? AND end_date >= ?",
+		leave.StudentID, leave.ID, leave.EndDate, leave.StartDate).
+		Where("status IN ?", append(append([]db.LeaveStatus{}, db.LeaveAwaitingDecision...), db.LeaveInEffect...)).
+		Order("start_date").
+		Find(&leaves)
+	return leaves
+}
+
+// coveredEvents returns the exams and mandatory events of the student's
+// department and of the institution falling within leave
+func coveredEvents(tx *gorm.DB, leave *db.LeaveRequest) []db.CalendarEvent {
+	var student db.User
+	tx.Select("id", "dept").First(&student, leave.StudentID)
+
+	return calendar.NewService(tx).EventsBetween(student.Dept, leave.StartDate, leave.EndDate)
+}
+
+// eventWarnings describes the events a leave covers
+func eventWarnings(events []db.CalendarEvent) []string {
+	warnings := make([]string, len(events))
+	for i, event := range events {
+		span := event.StartDate.Format("2006-01-02")
+		if !event.EndDate.Equal(event.StartDate) {
+			span += " to " + event.EndDate.Format("2006-01-02")
+		}
+		warnings[i] = fmt.Sprintf("Leave covers %s %s (%s)", event.Kind, event.Name, span)
+	}
+	return warnings
+}
@@ -1,15 +1,19 @@
 package leaves
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"attendance-workflow/internal/dto"
 	"attendance-workflow/internal/notifications"
 	"attendance-workflow/pkg/db"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type LeaveHandler struct {
@@ -22,15 +26,16 @@ func NewLeaveHandler() *LeaveHandler {
 
 // ApplyLeave godoc
 // @Summary      Apply for leave
-// @Description  Submit a leave request
+// @Description  Submit a leave request, or save it with draft=true to submit later. It goes through the approval path of the matching leave workflow, or LEAVE_APPROVAL_PATH when none matches. Days counts the working days of the student's department calendar. Leaves overlapping the student's submitted, in-review or approved leaves are rejected. Leaves needing more days than left in their type's quota are rejected, or flagged with exceeds_balance when the quota allows it. Leaves covering exams or mandatory events are accepted with warnings and flagged with covers_events.
 // @Tags         leaves
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        request  body      dto.ApplyLeaveRequest  true  "Leave request details"
-// @Success      201      {object}  object{message=string,data=object}
+// @Success      201      {object}  object{message=string,data=object,warnings=[]string}
 // @Failure      400      {object}  object{error=string}
 // @Failure      401      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string,conflicts=array}
 // @Router       /leaves/apply [post]
 func (h *LeaveHandler) ApplyLeave(c *gin.Context) {
 	userID, ok := c.Get("user_id")
@@ -45,11 +50,6 @@ func (h *LeaveHandler) ApplyLeave(c *gin.Context) {
 		return
 	}
 
-	if req.EndDate.Time.Before(req.StartDate.Time) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "End date cannot be before start date"})
-		return
-	}
-
 	uid, ok := userID.(uint)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
@@ -58,24 +58,100 @@ func (h *LeaveHandler) ApplyLeave(c *gin.Context) {
 
 	leaveReq := db.LeaveRequest{
 		StudentID: uid,
-		LeaveType: db.LeaveType(req.LeaveType),
-		Reason:    req.Reason,
-		StartDate: req.StartDate.Time,
-		EndDate:   req.EndDate.Time,
-		Status:    db.StatusPending,
+		Status:    db.StatusSubmitted,
 	}
-
-	if err := h.DB.Create(&leaveReq).Error; err != nil {
+	if req.Draft {
+		leaveReq.Status = db.StatusDraft
+	}
+	var warnings []string
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockStudent(tx, uid); err != nil {
+			return err
+		}
+		var ok bool
+		if warnings, ok = h.prepareLeave(c, tx, &req, &leaveReq); !ok {
+			return errLeaveRejected
+		}
+		return tx.Create(&leaveReq).Error
+	})
+	if errors.Is(err, errLeaveRejected) {
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Draft {
+		c.JSON(http.StatusCreated, gin.H{"message": "Leave request saved as draft", "data": leaveReq, "warnings": warnings})
+		return
+	}
+	queueApprovalNotification(c, leaveReq.ID, notifications.LeaveSubmitted)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Leave request submitted successfully",
-		"data":    leaveReq,
+		"message":  "Leave request submitted successfully",
+		"data":     leaveReq,
+		"warnings": warnings,
 	})
 }
 
+// prepareLeave fills leave from req, checks its dates and returns warnings
+// for the exams and mandatory events it covers. Unless the leave is a draft,
+// it also rejects overlaps with the student's other leaves, checks the
+// balance and puts the leave on the first stage of its approval path. Run
+// it in the transaction saving the leave, after lockStudent.
+func (h *LeaveHandler) prepareLeave(c *gin.Context, tx *gorm.DB, req *dto.ApplyLeaveRequest, leave *db.LeaveRequest) ([]string, bool) {
+	if !validLeaveType(db.LeaveType(req.LeaveType)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "leave_type must be Medical, Personal, Emergency or Other"})
+		return nil, false
+	}
+
+	if req.EndDate.Time.Before(req.StartDate.Time) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End date cannot be before start date"})
+		return nil, false
+	}
+
+	leave.LeaveType = db.LeaveType(req.LeaveType)
+	leave.Reason = req.Reason
+	leave.StartDate = req.StartDate.Time
+	leave.EndDate = req.EndDate.Time
+
+	leave.Days = len(leaveDays(tx, leave))
+	if leave.Days == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Leave does not cover any working day"})
+		return nil, false
+	}
+	events := coveredEvents(tx, leave)
+	leave.CoversEvents = len(events) > 0
+	warnings := eventWarnings(events)
+	if leave.Status == db.StatusDraft {
+		return warnings, true
+	}
+
+	if overlaps := overlappingLeaves(tx, leave); len(overlaps) > 0 {
+		other := overlaps[0]
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("Leave overlaps your %s %s leave from %s to %s",
+				strings.ReplaceAll(string(other.Status), "_", " "), other.LeaveType,
+				other.StartDate.Format("2006-01-02"), other.EndDate.Format("2006-01-02")),
+			"conflicts": overlaps,
+		})
+		return nil, false
+	}
+	leave.ExceedsBalance = false
+	if balance, ok := checkBalance(tx, leave); !ok {
+		if balance.OnExceed != db.QuotaFlag {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   fmt.Sprintf("Leave needs %d working days but only %d %s days are left this term", leave.Days, *balance.Remaining, leave.LeaveType),
+				"balance": balance,
+			})
+			return nil, false
+		}
+		leave.ExceedsBalance = true
+	}
+	startApproval(tx, leave)
+	return warnings, true
+}
+
 // GetMyLeaves godoc
 // @Summary      Get my leaves
 // @Description  Get leave requests for the authenticated user
@@ -108,7 +184,7 @@ func (h *LeaveHandler) GetMyLeaves(c *gin.Context) {
 	status := c.Query("status")
 
 	offset := (page - 1) * limit
-	query := h.DB.Where("student_id = ?", userID)
+	query := h.DB.Preload("Approvals").Where("student_id = ?", userID)
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -133,7 +209,7 @@ func (h *LeaveHandler) GetMyLeaves(c *gin.Context) {
 
 // GetPendingLeaves godoc
 // @Summary      Get pending leaves
-// @Description  Get all pending leave requests (admin/faculty/warden only)
+// @Description  Get the pending leave requests waiting for the caller's decision: faculty see their students', HODs their department's and wardens their hostels' residents'. Admins see every pending request.
 // @Tags         leaves
 // @Accept       json
 // @Produce      json
@@ -145,6 +221,8 @@ func (h *LeaveHandler) GetMyLeaves(c *gin.Context) {
 // @Router       /leaves/pending [get]
 func (h *LeaveHandler) GetPendingLeaves(c *gin.Context) {
 	role, _ := c.Get("role")
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
 
 	var leaves []db.LeaveRequest
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -157,16 +235,19 @@ func (h *LeaveHandler) GetPendingLeaves(c *gin.Context) {
 	}
 
 	offset := (page - 1) * limit
-	query := h.DB.Where("status = ?", db.StatusPending)
-
-	if role == "faculty" || role == "warden" {
-		// Faculty and Warden can see all pending
-		query = query.Preload("Student")
+	query := h.DB.Model(&db.LeaveRequest{}).Where("status IN ?", db.LeaveAwaitingDecision)
+
+	if role != string(db.RoleAdmin) {
+		userRole := db.UserRole(role.(string))
+		query = query.Where("',' || pending_roles || ',' LIKE ?", "%,"+string(userRole)+",%")
+		if students := h.approverStudents(uid, userRole); students != nil {
+			query = query.Where("student_id IN (?)", students)
+		}
 	}
 
 	var total int64
 	query.Count(&total)
-	query.Limit(limit).Offset(offset).Find(&leaves)
+	query.Preload("Student").Preload("Approvals").Order("created_at").Limit(limit).Offset(offset).Find(&leaves)
 
 	c.JSON(http.StatusOK, gin.H{
 		"data":        leaves,
@@ -179,7 +260,7 @@ func (h *LeaveHandler) GetPendingLeaves(c *gin.Context) {
 
 // ApproveLeave godoc
 // @Summary      Approve or reject leave
-// @Description  Approve or reject a leave request (admin/faculty/warden only)
+// @Description  Record the decision of a step of the leave's current stage: the student's faculty, the HOD of their department, the warden of their hostel, or an admin, who decides every step of the stage unless role is given. A rejection ends the request; the leave is approved once every stage has passed.
 // @Tags         leaves
 // @Accept       json
 // @Produce      json
@@ -188,6 +269,7 @@ func (h *LeaveHandler) GetPendingLeaves(c *gin.Context) {
 // @Param        request body      dto.ApproveLeaveRequest true "Approval decision"
 // @Success      200     {object}  object{message=string,data=object}
 // @Failure      400     {object}  object{error=string}
+// @Failure      403     {object}  object{error=string}
 // @Failure      404     {object}  object{error=string}
 // @Failure      401     {object}  object{error=string}
 // @Router       /leaves/{id}/approve [put]
@@ -202,6 +284,7 @@ func (h *LeaveHandler) ApproveLeave(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
 		return
 	}
+	role, _ := c.Get("role")
 
 	var req dto.ApproveLeaveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -209,37 +292,134 @@ func (h *LeaveHandler) ApproveLeave(c *gin.Context) {
 		return
 	}
 
-	var leave db.LeaveRequest
-	if err := h.DB.First(&leave, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Leave request not found"})
-		return
-	}
-
-	if leave.Status != db.StatusPending {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Leave request already processed"})
-		return
-	}
-
 	uid, ok := userID.(uint)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
 		return
 	}
 
-	approvedBy := uint(uid)
-	leave.ApprovedBy = &approvedBy
-	leave.Remarks = req.Remarks
-
-	if req.Approved {
-		leave.Status = db.StatusApproved
-	} else {
-		leave.Status = db.StatusRejected
+	// The decision is taken on the locked leave, so concurrent decisions on
+	// one stage each see the steps the other settled. It commits together
+	// with the status change and its attendance entries.
+	var leave db.LeaveRequest
+	var approvals []db.LeaveApproval
+	var advanced bool
+	var daysMarked int
+	var conflicts []db.Attendance
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockLeave(tx, uint(id)); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Leave request not found"})
+			return errLeaveRejected
+		}
+		if err := tx.Preload("Approvals").First(&leave, id).Error; err != nil {
+			return err
+		}
+
+		awaiting := leave.Awaiting()
+		if !leave.AwaitingDecision() || len(awaiting) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Leave request already processed"})
+			return errLeaveRejected
+		}
+
+		// The steps this decision settles
+		decided := awaiting
+		if role != string(db.RoleAdmin) || req.Role != "" {
+			step := db.UserRole(req.Role)
+			if role != string(db.RoleAdmin) {
+				if req.Role != "" && req.Role != role {
+					c.JSON(http.StatusForbidden, gin.H{"error": "You can only decide as " + role.(string)})
+					return errLeaveRejected
+				}
+				step = db.UserRole(role.(string))
+			}
+			if !containsRole(awaiting, step) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "This request is waiting for the " + strings.Join(roleNames(awaiting), " and ")})
+				return errLeaveRejected
+			}
+			if role != string(db.RoleAdmin) && !h.isApprover(uid, step, &leave) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You do not decide leaves of this student"})
+				return errLeaveRejected
+			}
+			decided = []db.UserRole{step}
+		}
+
+		approvals = make([]db.LeaveApproval, len(decided))
+		for i, step := range decided {
+			approvals[i] = db.LeaveApproval{
+				LeaveID:    leave.ID,
+				Stage:      leave.Stage,
+				Role:       step,
+				ApproverID: uid,
+				Approved:   *req.Approved,
+				Remarks:    req.Remarks,
+			}
+		}
+
+		var remaining []db.UserRole
+		for _, step := range awaiting {
+			if !containsRole(decided, step) {
+				remaining = append(remaining, step)
+			}
+		}
+		next := db.StatusInReview
+		switch {
+		case !*req.Approved:
+			next = db.StatusRejected
+			remaining = nil
+		case len(remaining) > 0:
+			// Other steps of the stage still have to decide
+		case leave.Stage+1 < len(leave.Stages()):
+			leave.Stage++
+			remaining = leave.Stages()[leave.Stage]
+			advanced = true
+		default:
+			next = db.StatusApproved
+		}
+		if err := leave.MoveTo(next, uid, req.Remarks); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return errLeaveRejected
+		}
+		leave.SetAwaiting(remaining)
+
+		if !leave.AwaitingDecision() {
+			approvedBy := uid
+			leave.ApprovedBy = &approvedBy
+			leave.Remarks = req.Remarks
+		}
+
+		if err := tx.Create(&approvals).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Approvals", "Student", "Approver").Save(&leave).Error; err != nil {
+			return err
+		}
+		if leave.Status != db.StatusApproved {
+			return nil
+		}
+		var err error
+		daysMarked, conflicts, err = applyLeaveAttendance(tx, &leave, uid)
+		return err
+	})
+	if errors.Is(err, errLeaveRejected) {
+		return
 	}
-
-	if err := h.DB.Save(&leave).Error; err != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update leave request"})
 		return
 	}
+	leave.Approvals = append(leave.Approvals, approvals...)
+
+	if leave.AwaitingDecision() {
+		if advanced {
+			queueApprovalNotification(c, leave.ID, notifications.LeaveAdvanced)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":       "Decision recorded",
+			"data":          leave,
+			"pending_roles": leave.Awaiting(),
+		})
+		return
+	}
 
 	// Queue async notification
 	notifService := notifications.GetNotificationService()
@@ -254,10 +434,102 @@ func (h *LeaveHandler) ApproveLeave(c *gin.Context) {
 		log.Printf("Failed to queue notification: %v", err)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message": "Leave request " + string(leave.Status),
 		"data":    leave,
+	}
+	if leave.Status == db.StatusApproved {
+		response["attendance"] = gin.H{
+			"days_marked_on_leave": daysMarked,
+			"conflicts":            conflicts,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func containsRole(roles []db.UserRole, role db.UserRole) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func roleNames(roles []db.UserRole) []string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return names
+}
+
+// RevokeLeave godoc
+// @Summary      Revoke approved leave
+// @Description  Cancel an approved leave and remove the on_leave attendance it created (admin/faculty/warden only)
+// @Tags         leaves
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      int                     true   "Leave ID"
+// @Param        request body      dto.RevokeLeaveRequest  false  "Remarks"
+// @Success      200     {object}  object{message=string,data=object}
+// @Failure      400     {object}  object{error=string}
+// @Failure      404     {object}  object{error=string}
+// @Router       /leaves/{id}/revoke [put]
+func (h *LeaveHandler) RevokeLeave(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave ID"})
+		return
+	}
+
+	var req dto.RevokeLeaveRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var leave db.LeaveRequest
+	if err := h.DB.First(&leave, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave request not found"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+	if err := leave.MoveTo(db.StatusCancelled, uid, req.Remarks); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only approved leave requests can be revoked"})
+		return
+	}
+	if req.Remarks != "" {
+		leave.Remarks = req.Remarks
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&leave).Error; err != nil {
+			return err
+		}
+		return revertLeaveAttendance(tx, &leave, uid)
 	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke leave request"})
+		return
+	}
+
+	notifService := notifications.GetNotificationService()
+	if err := notifService.QueueLeaveStatusNotification(c.Request.Context(), notifications.LeaveStatusUpdatePayload{
+		LeaveID:   leave.ID,
+		StudentID: leave.StudentID,
+		Status:    string(leave.Status),
+		Remarks:   leave.Remarks,
+	}); err != nil {
+		log.Printf("Failed to queue notification: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Leave request cancelled", "data": leave})
 }
 
 // GetAllLeaves godoc
@@ -286,7 +558,7 @@ func (h *LeaveHandler) GetAllLeaves(c *gin.Context) {
 	status := c.Query("status")
 
 	offset := (page - 1) * limit
-	query := h.DB.Preload("Student")
+	query := h.DB.Model(&db.LeaveRequest{})
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -294,7 +566,7 @@ func (h *LeaveHandler) GetAllLeaves(c *gin.Context) {
 
 	var total int64
 	query.Count(&total)
-	query.Limit(limit).Offset(offset).Find(&leaves)
+	query.Preload("Student").Preload("Approvals").Limit(limit).Offset(offset).Find(&leaves)
 
 	c.JSON(http.StatusOK, gin.H{
 		"data":        leaves,
@@ -307,7 +579,7 @@ func (h *LeaveHandler) GetAllLeaves(c *gin.Context) {
 
 // DeleteLeave godoc
 // @Summary      Delete leave
-// @Description  Delete a leave request (admin only)
+// @Description  Delete a leave request with its approvals and status history (admin only)
 // @Tags         leaves
 // @Accept       json
 // @Produce      json
@@ -324,10 +596,27 @@ func (h *LeaveHandler) DeleteLeave(c *gin.Context) {
 		return
 	}
 
-	if err := h.DB.Delete(&db.LeaveRequest{}, id).Error; err != nil {
+	var leave db.LeaveRequest
+	if err := h.DB.First(&leave, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Leave request not found"})
 		return
 	}
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := revertLeaveAttendance(tx, &leave, uid); err != nil {
+			return err
+		}
+		if err := tx.Where("leave_id = ?", leave.ID).Delete(&db.LeaveApproval{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&leave).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete leave request"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Leave request deleted successfully"})
 }
@@ -0,0 +1,110 @@
+package leaves
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimelineEntry is a status change of a leave, or the decision of one step
+// of its approval path
+type TimelineEntry struct {
+	At       time.Time      `json:"at"`
+	Event    string         `json:"event"` // transition or decision
+	From     db.LeaveStatus `json:"from,omitempty"`
+	To       db.LeaveStatus `json:"to,omitempty"`
+	Stage    *int           `json:"stage,omitempty"`
+	Role     db.UserRole    `json:"role,omitempty"`
+	Approved *bool          `json:"approved,omitempty"`
+	ActorID  *uint          `json:"actor_id,omitempty"` // nil for scheduled jobs
+	Actor    *db.User       `json:"actor,omitempty"`
+	Reason   string         `json:"reason,omitempty"`
+}
+
+// GetLeaveTimeline godoc
+// @Summary      Get leave timeline
+// @Description  Every status change of a leave and every approval step decision, oldest first, with who made it. Students see their own leaves, staff those of students they approve for, admins every leave.
+// @Tags         leaves
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Leave ID"
+// @Success      200  {object}  object{data=object,timeline=[]TimelineEntry}
+// @Failure      400  {object}  object{error=string}
+// @Failure      403  {object}  object{error=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /leaves/{id}/timeline [get]
+func (h *LeaveHandler) GetLeaveTimeline(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+	role, _ := c.Get("role")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave ID"})
+		return
+	}
+
+	var leave db.LeaveRequest
+	if err := h.DB.Preload("Student").First(&leave, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave request not found"})
+		return
+	}
+	switch role {
+	case string(db.RoleAdmin):
+	case string(db.RoleStudent):
+		if leave.StudentID != uid {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own leave requests"})
+			return
+		}
+	default:
+		if !h.isApprover(uid, db.UserRole(role.(string)), &leave) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not approve leaves of this student"})
+			return
+		}
+	}
+
+	var transitions []db.LeaveTransition
+	h.DB.Preload("Actor").Where("leave_id = ?", leave.ID).Order("created_at, id").Find(&transitions)
+	var approvals []db.LeaveApproval
+	h.DB.Preload("Approver").Where("leave_id = ?", leave.ID).Order("created_at, id").Find(&approvals)
+
+	timeline := make([]TimelineEntry, 0, len(transitions)+len(approvals))
+	for i := range approvals {
+		approval := &approvals[i]
+		timeline = append(timeline, TimelineEntry{
+			At:       approval.CreatedAt,
+			Event:    "decision",
+			Stage:    &approval.Stage,
+			Role:     approval.Role,
+			Approved: &approval.Approved,
+			ActorID:  &approval.ApproverID,
+			Actor:    &approval.Approver,
+			Reason:   approval.Remarks,
+		})
+	}
+	for _, transition := range transitions {
+		timeline = append(timeline, TimelineEntry{
+			At:      transition.CreatedAt,
+			Event:   "transition",
+			From:    transition.From,
+			To:      transition.To,
+			ActorID: transition.ActorID,
+			Actor:   transition.Actor,
+			Reason:  transition.Reason,
+		})
+	}
+	// A decision and the status change it causes share a transaction; the
+	// decision comes first
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].At.Before(timeline[j].At)
+	})
+
+	c.JSON(http.StatusOK, gin.H{"data": clock.In(leave, auth.Zone(c)), "timeline": clock.In(timeline, auth.Zone(c))})
+}
@@ -0,0 +1,227 @@
+package leaves
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/internal/notifications"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// approverRoles can be named in an approval path
+var approverRoles = map[db.UserRole]bool{
+	db.RoleFaculty: true,
+	db.RoleWarden:  true,
+	db.RoleHOD:     true,
+	db.RoleAdmin:   true,
+}
+
+// GetWorkflows godoc
+// @Summary      List leave workflows
+// @Description  List the leave approval workflows, highest priority first (admin only)
+// @Tags         leaves
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array,default_approval_path=string}
+// @Router       /leaves/workflows [get]
+func (h *LeaveHandler) GetWorkflows(c *gin.Context) {
+	var workflows []db.LeaveWorkflow
+	h.DB.Order("priority DESC, id").Find(&workflows)
+	c.JSON(http.StatusOK, gin.H{"data": workflows, "default_approval_path": db.DefaultLeaveApprovalPath()})
+}
+
+// CreateWorkflow godoc
+// @Summary      Create leave workflow
+// @Description  Add an approval workflow for the leaves matching its leave type, minimum working days, department and hostel residence. Stages of approval_path are separated by commas and run in order; roles joined by "+" decide in parallel (admin only)
+// @Tags         leaves
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.LeaveWorkflowRequest  true  "Workflow"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /leaves/workflows [post]
+func (h *LeaveHandler) CreateWorkflow(c *gin.Context) {
+	var workflow db.LeaveWorkflow
+	if !bindWorkflow(c, &workflow) {
+		return
+	}
+	if err := h.DB.Create(&workflow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create leave workflow"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Leave workflow created", "data": workflow})
+}
+
+// UpdateWorkflow godoc
+// @Summary      Update leave workflow
+// @Description  Replace a leave approval workflow. Leaves already submitted keep their approval path (admin only)
+// @Tags         leaves
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                       true  "Workflow ID"
+// @Param        request  body      dto.LeaveWorkflowRequest  true  "Workflow"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      404      {object}  object{error=string}
+// @Router       /leaves/workflows/{id} [put]
+func (h *LeaveHandler) UpdateWorkflow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID"})
+		return
+	}
+
+	var workflow db.LeaveWorkflow
+	if err := h.DB.First(&workflow, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave workflow not found"})
+		return
+	}
+	if !bindWorkflow(c, &workflow) {
+		return
+	}
+	if err := h.DB.Save(&workflow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update leave workflow"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Leave workflow updated", "data": workflow})
+}
+
+// DeleteWorkflow godoc
+// @Summary      Delete leave workflow
+// @Description  Delete a leave approval workflow (admin only)
+// @Tags         leaves
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Workflow ID"
+// @Success      200  {object}  object{message=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /leaves/workflows/{id} [delete]
+func (h *LeaveHandler) DeleteWorkflow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow ID"})
+		return
+	}
+	result := h.DB.Delete(&db.LeaveWorkflow{}, id)
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave workflow not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Leave workflow deleted successfully"})
+}
+
+func bindWorkflow(c *gin.Context, workflow *db.LeaveWorkflow) bool {
+	var req dto.LeaveWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	stages := db.ApprovalStages(req.ApprovalPath)
+	if len(stages) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "approval_path needs at least one role"})
+		return false
+	}
+	for _, stage := range stages {
+		seen := make(map[db.UserRole]bool)
+		for _, role := range stage {
+			if !approverRoles[role] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown approver role " + string(role) + ", use faculty, warden, hod or admin"})
+				return false
+			}
+			if seen[role] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Role " + string(role) + " appears twice in one stage"})
+				return false
+			}
+			seen[role] = true
+		}
+	}
+
+	workflow.Name = req.Name
+	workflow.LeaveType = db.LeaveType(req.LeaveType)
+	workflow.MinDays = req.MinDays
+	workflow.Dept = req.Dept
+	workflow.HostelResident = req.HostelResident
+	workflow.Priority = req.Priority
+	workflow.ApprovalPath = req.ApprovalPath
+	return true
+}
+
+// startApproval picks the leave's workflow and puts it on the first stage
+func startApproval(tx *gorm.DB, leave *db.LeaveRequest) {
+	var student db.User
+	tx.Select("id", "dept").First(&student, leave.StudentID)
+	var residents int64
+	tx.Model(&db.HostelResident{}).Where("student_id = ?", leave.StudentID).Count(&residents)
+
+	leave.WorkflowID = nil
+	leave.ApprovalPath = db.DefaultLeaveApprovalPath()
+	var workflows []db.LeaveWorkflow
+	tx.Order("priority DESC, id").Find(&workflows)
+	for i := range workflows {
+		if workflows[i].Matches(leave, student.Dept, residents > 0) {
+			leave.WorkflowID = &workflows[i].ID
+			leave.ApprovalPath = workflows[i].ApprovalPath
+			break
+		}
+	}
+	leave.Stage = 0
+	leave.SetAwaiting(leave.Stages()[0])
+}
+
+// approverStudents returns the students whose leaves uid decides for role:
+// faculty those they teach, HODs those of their department and wardens the
+// residents of their hostels and of hostels without a warden. Students
+// nobody of the role is assigned to, such as those without class sessions,
+// are decided by everyone of the role so their leaves never get stuck. It
+// is nil for roles that decide for every student.
+func (h *LeaveHandler) approverStudents(uid uint, role db.UserRole) *gorm.DB {
+	students := h.DB.Model(&db.User{}).Select("id AS student_id").Where("role = ?", db.RoleStudent)
+	switch role {
+	case db.RoleFaculty:
+		taught := func() *gorm.DB {
+			return h.DB.Model(&db.Enrollment{}).Select("enrollments.student_id").
+				Joins("JOIN class_sessions ON class_sessions.course_id = enrollments.course_id AND class_sessions.section = enrollments.section")
+		}
+		return students.Where("id IN (?) OR id NOT IN (?)", taught().Where("class_sessions.faculty_id = ?", uid), taught())
+	case db.RoleHOD:
+		var hod db.User
+		h.DB.Select("id", "dept").First(&hod, uid)
+		headed := h.DB.Model(&db.User{}).Select("dept").Where("role = ? AND dept <> ''", db.RoleHOD)
+		return students.Where("(dept = ? AND dept <> '') OR dept NOT IN (?)", hod.Dept, headed)
+	case db.RoleWarden:
+		residents := h.DB.Model(&db.HostelResident{}).Select("hostel_residents.student_id")
+		watched := h.DB.Model(&db.HostelResident{}).Select("hostel_residents.student_id").
+			Joins("JOIN hostels ON hostels.id = hostel_residents.hostel_id").
+			Where("hostels.warden_id = ? OR hostels.warden_id IS NULL", uid)
+		return students.Where("id IN (?) OR id NOT IN (?)", watched, residents)
+	}
+	return nil
+}
+
+// isApprover reports whether uid, who has role, decides that step of leave
+func (h *LeaveHandler) isApprover(uid uint, role db.UserRole, leave *db.LeaveRequest) bool {
+	students := h.approverStudents(uid, role)
+	if students == nil {
+		return true
+	}
+	var count int64
+	h.DB.Table("(?) AS approver_students", students).Where("student_id = ?", leave.StudentID).Count(&count)
+	return count > 0
+}
+
+func queueApprovalNotification(c *gin.Context, leaveID uint, event string) {
+	notifService := notifications.GetNotificationService()
+	if err := notifService.QueueLeaveApprovalNotification(c.Request.Context(), notifications.LeaveApprovalPayload{
+		LeaveID: leaveID,
+		Event:   event,
+	}); err != nil {
+		log.Printf("Failed to queue leave approval notification: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"attendance-workflow/pkg/db"
+
+	"github.com/hibiken/asynq"
+)
+
+// Condonation events
+const (
+	CondonationSubmitted = "submitted"
+	CondonationAdvanced  = "advanced" // approved at one step, waiting for the next
+	CondonationApproved  = "approved"
+	CondonationRejected  = "rejected"
+)
+
+type CondonationPayload struct {
+	CondonationID uint   `json:"condonation_id"`
+	Event         string `json:"event"`
+}
+
+func (s *NotificationService) QueueCondonationNotification(ctx context.Context, payload CondonationPayload) error {
+	taskBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal condonation payload: %v", err)
+	}
+
+	task := asynq.NewTask(TypeCondonationUpdate, taskBytes)
+	_, err = s.client.EnqueueContext(ctx, task)
+	return err
+}
+
+// handleCondonationUpdate asks the approvers of the current step for a
+// decision and keeps the student informed of each step
+func (s *NotificationService) handleCondonationUpdate(ctx context.Context, t *asynq.Task) error {
+	var payload CondonationPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal condonation payload: %v", err)
+	}
+
+	var condonation db.Condonation
+	if err := s.DB.Preload("Student").Preload("Course").First(&condonation, payload.CondonationID).Error; err != nil {
+		return fmt.Errorf("failed to load condonation %d: %v", payload.CondonationID, err)
+	}
+
+	var notifications []db.Notification
+	notify := func(userID uint, title, message string) {
+		notifications = append(notifications, db.Notification{
+			UserID:  userID,
+			Type:    "condonation",
+			Title:   title,
+			Message: message,
+			IsRead:  false,
+		})
+	}
+
+	if role := condonation.PendingRole(); role != "" {
+		for _, approverID := range s.condonationApprovers(&condonation, role) {
+			notify(approverID, "Condonation Awaiting Decision",
+				fmt.Sprintf("%s asks to condone %d sessions of %s (%s): %s",
+					condonation.Student.Name, condonation.RequestedSessions, condonation.Course.Code, condonation.Category, condonation.Reason))
+		}
+	}
+
+	switch payload.Event {
+	case CondonationAdvanced:
+		notify(condonation.StudentID, "Condonation Update",
+			fmt.Sprintf("Your condonation request for %s was approved at one step and now waits for the %s",
+				condonation.Course.Code, strings.ToUpper(string(condonation.PendingRole()))))
+	case CondonationApproved:
+		notify(condonation.StudentID, "Condonation Update",
+			fmt.Sprintf("Your condonation request for %s has been approved, %d sessions are condoned",
+				condonation.Course.Code, condonation.GrantedSessions))
+	case CondonationRejected:
+		notify(condonation.StudentID, "Condonation Update",
+			fmt.Sprintf("Your condonation request for %s has been rejected", condonation.Course.Code))
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := s.DB.Create(&notifications).Error; err != nil {
+		return fmt.Errorf("failed to create notifications: %v", err)
+	}
+	return nil
+}
+
+// condonationApprovers returns the users who decide for role: the faculty
+// teaching the student's section, the HODs of the student's department, or
+// everyone with the role
+func (s *NotificationService) condonationApprovers(condonation *db.Condonation, role db.UserRole) []uint {
+	var ids []uint
+	switch role {
+	case db.RoleFaculty:
+		s.DB.Model(&db.ClassSession{}).
+			Joins("JOIN enrollments ON enrollments.course_id = class_sessions.course_id AND enrollments.section = class_sessions.section").
+			Where("class_sessions.course_id = ? AND enrollments.student_id = ?", condonation.CourseID, condonation.StudentID).
+			Distinct("class_sessions.faculty_id").Pluck("class_sessions.faculty_id", &ids)
+	case db.RoleHOD:
+		s.DB.Model(&db.User{}).Where("role = ? AND dept = ?", db.RoleHOD, condonation.Student.Dept).Pluck("id", &ids)
+	default:
+		s.DB.Model(&db.User{}).Where("role = ?", role).Pluck("id", &ids)
+	}
+	return ids
+}
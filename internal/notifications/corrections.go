@@ -0,0 +1,109 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"attendance-workflow/pkg/db"
+
+	"github.com/hibiken/asynq"
+)
+
+// Correction events, one per step of the workflow
+const (
+	CorrectionRaised    = "raised"
+	CorrectionApproved  = "approved"
+	CorrectionRejected  = "rejected"
+	CorrectionCancelled = "cancelled"
+)
+
+type CorrectionPayload struct {
+	CorrectionID uint   `json:"correction_id"`
+	Event        string `json:"event"`
+}
+
+func (s *NotificationService) QueueCorrectionNotification(ctx context.Context, payload CorrectionPayload) error {
+	taskBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal correction payload: %v", err)
+	}
+
+	task := asynq.NewTask(TypeCorrectionUpdate, taskBytes)
+	_, err = s.client.EnqueueContext(ctx, task)
+	return err
+}
+
+// handleCorrectionUpdate tells the approvers about raised and withdrawn
+// requests, and the requester and student about decisions
+func (s *NotificationService) handleCorrectionUpdate(ctx context.Context, t *asynq.Task) error {
+	var payload CorrectionPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal correction payload: %v", err)
+	}
+
+	var correction db.AttendanceCorrection
+	if err := s.DB.Preload("Student").Preload("Requester").First(&correction, payload.CorrectionID).Error; err != nil {
+		return fmt.Errorf("failed to load correction %d: %v", payload.CorrectionID, err)
+	}
+
+	day := correction.Date.Format("2006-01-02")
+	var recipients []uint
+	var title, message string
+	switch payload.Event {
+	case CorrectionRaised, CorrectionCancelled:
+		recipients = s.correctionApprovers(correction.Student.Dept)
+		title = "Attendance Correction Request"
+		message = fmt.Sprintf("%s %s a correction of %s's attendance on %s to %s",
+			correction.Requester.Name, payload.Event, correction.Student.Name, day, statusLabel(correction.RequestedStatus))
+		if payload.Event == CorrectionRaised && correction.RequestedBy != correction.StudentID {
+			recipients = append(recipients, correction.StudentID)
+		}
+	case CorrectionApproved, CorrectionRejected:
+		recipients = []uint{correction.RequestedBy}
+		if correction.RequestedBy != correction.StudentID {
+			recipients = append(recipients, correction.StudentID)
+		}
+		title = "Attendance Correction Update"
+		message = fmt.Sprintf("The correction of attendance on %s to %s has been %s. %s",
+			day, statusLabel(correction.RequestedStatus), payload.Event, correction.ReviewRemarks)
+	default:
+		return fmt.Errorf("unknown correction event %q", payload.Event)
+	}
+
+	notifications := make([]db.Notification, 0, len(recipients))
+	for _, userID := range recipients {
+		notifications = append(notifications, db.Notification{
+			UserID:  userID,
+			Type:    "attendance_correction",
+			Title:   title,
+			Message: strings.TrimSpace(message),
+			IsRead:  false,
+		})
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := s.DB.Create(&notifications).Error; err != nil {
+		return fmt.Errorf("failed to create notifications: %v", err)
+	}
+	return nil
+}
+
+// correctionApprovers returns the HODs of dept, or the admins when the
+// department has none
+func (s *NotificationService) correctionApprovers(dept string) []uint {
+	var ids []uint
+	if dept != "" {
+		s.DB.Model(&db.User{}).Where("role = ? AND dept = ?", db.RoleHOD, dept).Pluck("id", &ids)
+	}
+	if len(ids) == 0 {
+		s.DB.Model(&db.User{}).Where("role = ?", db.RoleAdmin).Pluck("id", &ids)
+	}
+	return ids
+}
+
+func statusLabel(status db.AttendanceStatus) string {
+	return attendanceStatusLabel(string(status), status.Attended())
+}
@@ -1,9 +1,8 @@
 package notifications
 
 import (
+	"attendance-workflow/pkg/clock"
 	"attendance-workflow/pkg/config"
-	"log"
-	"time"
 
 	"github.com/hibiken/asynq"
 )
@@ -13,10 +12,8 @@ type CronService struct {
 }
 
 func NewCronService() *CronService {
-	loc, err := time.LoadLocation("Local")
-	if err != nil {
-		log.Fatalf("Failed to load timezone: %v", err)
-	}
+	// Jobs run at the institution's wall clock times
+	loc := clock.Location()
 
 	redisOpt := asynq.RedisClientOpt{
 		Addr:     config.AppConfig.Redis.Addr,
@@ -40,6 +37,16 @@ func (s *CronService) Start() error {
 		return err
 	}
 
+	// Expire leaves left undecided every night at 1 AM
+	if _, err := s.scheduler.Register("0 1 * * *", asynq.NewTask(TypeLeaveExpiry, nil)); err != nil {
+		return err
+	}
+
+	// Check exam eligibility bands every evening at 7 PM
+	if _, err := s.scheduler.Register("0 19 * * *", asynq.NewTask(TypeEligibilityCheck, nil)); err != nil {
+		return err
+	}
+
 	// Schedule absentee report generation every Sunday at 6 PM
 	if _, err := s.scheduler.Register("0 18 * * 0", asynq.NewTask(
 		"report:absentee",
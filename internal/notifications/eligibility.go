@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"attendance-workflow/internal/eligibility"
+	"attendance-workflow/pkg/db"
+
+	"github.com/hibiken/asynq"
+)
+
+// handleEligibilityCheck re-evaluates every enrollment and alerts students
+// whose attendance has dropped into the warning or critical band since the
+// last check. Course faculty are told about students turning critical.
+func (s *NotificationService) handleEligibilityCheck(ctx context.Context, t *asynq.Task) error {
+	results, err := eligibility.NewService(s.DB).All()
+	if err != nil {
+		return fmt.Errorf("failed to evaluate eligibility: %v", err)
+	}
+
+	var previous []db.EligibilityStatus
+	if err := s.DB.Find(&previous).Error; err != nil {
+		return fmt.Errorf("failed to load eligibility statuses: %v", err)
+	}
+	type key struct{ student, course uint }
+	known := make(map[key]db.EligibilityStatus, len(previous))
+	for _, status := range previous {
+		known[key{status.StudentID, status.CourseID}] = status
+	}
+
+	alerts := 0
+	for _, result := range results {
+		status, seen := known[key{result.StudentID, result.CourseID}]
+		crossed := result.Band != db.BandEligible && (!seen || status.Band != result.Band) &&
+			!(seen && status.Band == db.BandCritical && result.Band == db.BandWarning)
+
+		status.StudentID, status.CourseID = result.StudentID, result.CourseID
+		status.Band, status.Percentage = result.Band, result.Percentage
+		if err := s.DB.Save(&status).Error; err != nil {
+			log.Printf("Failed to save eligibility status of student %d in course %d: %v", result.StudentID, result.CourseID, err)
+			continue
+		}
+		if !crossed {
+			continue
+		}
+
+		if err := s.DB.Create(&db.Notification{
+			UserID:  result.StudentID,
+			Type:    "eligibility",
+			Title:   "Attendance Shortfall Warning",
+			Message: eligibilityMessage(result),
+			IsRead:  false,
+		}).Error; err != nil {
+			log.Printf("Failed to create eligibility notification for student %d: %v", result.StudentID, err)
+			continue
+		}
+		alerts++
+
+		if result.Band == db.BandCritical {
+			s.notifyCourseFaculty(result)
+		}
+	}
+
+	log.Printf("Eligibility check: %d enrollments, %d alerts", len(results), alerts)
+	return nil
+}
+
+func eligibilityMessage(r eligibility.CourseEligibility) string {
+	switch {
+	case !r.Reachable:
+		return fmt.Sprintf("Your attendance in %s is %.1f%%, below the required %.0f%%, and can no longer reach it this term. Contact your faculty about condonation.",
+			r.Code, r.Percentage, r.Threshold.Minimum)
+	case r.Band == db.BandCritical:
+		return fmt.Sprintf("Your attendance in %s is %.1f%%, below the required %.0f%%. Attend the next %d classes to become eligible.",
+			r.Code, r.Percentage, r.Threshold.Minimum, r.MustAttend)
+	default:
+		return fmt.Sprintf("Your attendance in %s is %.1f%%, close to the required %.0f%%. You can miss at most %d more classes.",
+			r.Code, r.Percentage, r.Threshold.Minimum, r.CanMiss)
+	}
+}
+
+// notifyCourseFaculty tells the faculty teaching the student's section that
+// the student is no longer eligible
+func (s *NotificationService) notifyCourseFaculty(r eligibility.CourseEligibility) {
+	var facultyIDs []uint
+	s.DB.Model(&db.ClassSession{}).
+		Where("course_id = ? AND section = ?", r.CourseID, r.Section).
+		Distinct("faculty_id").Pluck("faculty_id", &facultyIDs)
+
+	for _, facultyID := range facultyIDs {
+		if err := s.DB.Create(&db.Notification{
+			UserID: facultyID,
+			Type:   "eligibility",
+			Title:  "Student Below Attendance Minimum",
+			Message: fmt.Sprintf("%s (section %s) has %.1f%% attendance in %s, below the required %.0f%%",
+				r.StudentName, r.Section, r.Percentage, r.Code, r.Threshold.Minimum),
+			IsRead: false,
+		}).Error; err != nil {
+			log.Printf("Failed to notify faculty %d about student %d: %v", facultyID, r.StudentID, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"attendance-workflow/pkg/db"
+
+	"github.com/hibiken/asynq"
+)
+
+// RollCallAlertPayload names the residents found unaccounted for at a roll call
+type RollCallAlertPayload struct {
+	RollCallID uint   `json:"roll_call_id"`
+	StudentIDs []uint `json:"student_ids"`
+}
+
+func (s *NotificationService) QueueRollCallAlert(ctx context.Context, payload RollCallAlertPayload) error {
+	taskBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal roll call alert payload: %v", err)
+	}
+
+	task := asynq.NewTask(TypeRollCallAlert, taskBytes)
+	_, err = s.client.EnqueueContext(ctx, task)
+	return err
+}
+
+// handleRollCallAlert tells the hostel's warden, or every warden when it has
+// none, and emails each resident's guardian. Guardians are not users, so
+// their email goes out directly rather than through the email queue.
+func (s *NotificationService) handleRollCallAlert(ctx context.Context, t *asynq.Task) error {
+	var payload RollCallAlertPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal roll call alert payload: %v", err)
+	}
+
+	var rollCall db.RollCall
+	if err := s.DB.Preload("Hostel").First(&rollCall, payload.RollCallID).Error; err != nil {
+		return fmt.Errorf("failed to load roll call %d: %v", payload.RollCallID, err)
+	}
+
+	var residents []db.HostelResident
+	if err := s.DB.Preload("Student").Where("student_id IN ?", payload.StudentIDs).Find(&residents).Error; err != nil {
+		return fmt.Errorf("failed to load residents: %v", err)
+	}
+
+	var wardens []uint
+	if rollCall.Hostel.WardenID != nil {
+		wardens = []uint{*rollCall.Hostel.WardenID}
+	} else {
+		s.DB.Model(&db.User{}).Where("role = ?", db.RoleWarden).Pluck("id", &wardens)
+	}
+
+	night := rollCall.Date.Format("2006-01-02")
+	emailConfig := loadEmailConfig()
+	var notifications []db.Notification
+	for _, resident := range residents {
+		message := fmt.Sprintf("%s (room %s) of %s was not accounted for at the roll call of %s",
+			resident.Student.Name, roomLabel(resident), rollCall.Hostel.Name, night)
+		for _, wardenID := range wardens {
+			notifications = append(notifications, db.Notification{
+				UserID:  wardenID,
+				Type:    "roll_call",
+				Title:   "Resident Unaccounted For",
+				Message: message,
+				IsRead:  false,
+			})
+		}
+
+		switch {
+		case resident.GuardianEmail == "":
+			log.Printf("No guardian email for student %d, guardian phone %q", resident.StudentID, resident.GuardianPhone)
+		case emailConfig.Host == "":
+			log.Printf("Sending roll call alert email to guardian %s of student %d", resident.GuardianEmail, resident.StudentID)
+		default:
+			body := fmt.Sprintf("Dear %s,\n\n%s. Please contact the hostel warden.", resident.GuardianName, message)
+			if err := NewSMTPEmailSender(emailConfig).Send(resident.GuardianEmail, "Hostel Roll Call Alert", body); err != nil {
+				log.Printf("Failed to email guardian of student %d: %v", resident.StudentID, err)
+			}
+		}
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := s.DB.Create(&notifications).Error; err != nil {
+		return fmt.Errorf("failed to create notifications: %v", err)
+	}
+	return nil
+}
+
+func roomLabel(resident db.HostelResident) string {
+	room := resident.Room
+	if room == "" {
+		room = "-"
+	}
+	if resident.Block != "" {
+		room = resident.Block + "/" + room
+	}
+	return room
+}
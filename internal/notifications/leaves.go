@@ -0,0 +1,209 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/config"
+	"attendance-workflow/pkg/db"
+
+	"github.com/hibiken/asynq"
+)
+
+// Leave approval events. Final decisions go through TypeLeaveStatusUpdate.
+const (
+	LeaveSubmitted             = "submitted"
+	LeaveAdvanced              = "advanced" // a stage passed, waiting for the next
+	LeaveEdited                = "edited"
+	LeaveWithdrawn             = "withdrawn"
+	LeaveCancellationRequested = "cancellation_requested"
+	LeaveCancellationDeclined  = "cancellation_declined"
+)
+
+type LeaveApprovalPayload struct {
+	LeaveID uint   `json:"leave_id"`
+	Event   string `json:"event"`
+}
+
+func (s *NotificationService) QueueLeaveApprovalNotification(ctx context.Context, payload LeaveApprovalPayload) error {
+	taskBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal leave approval payload: %v", err)
+	}
+
+	task := asynq.NewTask(TypeLeaveApproval, taskBytes)
+	_, err = s.client.EnqueueContext(ctx, task)
+	return err
+}
+
+// handleLeaveApproval asks the approvers of the leave's current stage for a
+// decision, tells them when a leave they were asked about is withdrawn, asks
+// every role of the path to confirm a cancellation and keeps the student
+// informed
+func (s *NotificationService) handleLeaveApproval(ctx context.Context, t *asynq.Task) error {
+	var payload LeaveApprovalPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal leave approval payload: %v", err)
+	}
+
+	var leave db.LeaveRequest
+	if err := s.DB.Preload("Student").First(&leave, payload.LeaveID).Error; err != nil {
+		return fmt.Errorf("failed to load leave %d: %v", payload.LeaveID, err)
+	}
+
+	var notifications []db.Notification
+	notify := func(userID uint, title, message string) {
+		notifications = append(notifications, db.Notification{
+			UserID:  userID,
+			Type:    "leave_approval",
+			Title:   title,
+			Message: message,
+			IsRead:  false,
+		})
+	}
+	notifyRoles := func(roles []db.UserRole, title, message string) {
+		for _, role := range roles {
+			for _, approverID := range s.leaveApprovers(&leave, role) {
+				notify(approverID, title, message)
+			}
+		}
+	}
+	span := fmt.Sprintf("%s leave from %s to %s (%d working days",
+		leave.LeaveType, leave.StartDate.Format("2006-01-02"), leave.EndDate.Format("2006-01-02"), leave.Days)
+	if leave.ExceedsBalance {
+		span += ", more than the student's remaining balance"
+	}
+	if leave.CoversEvents {
+		span += ", covering exams or mandatory events"
+	}
+	span += ")"
+
+	switch payload.Event {
+	case LeaveSubmitted, LeaveEdited, LeaveAdvanced:
+		if !leave.AwaitingDecision() {
+			return nil
+		}
+		verb := "asks for"
+		if payload.Event == LeaveEdited {
+			verb = "changed their request to"
+		}
+		notifyRoles(leave.Awaiting(), "Leave Awaiting Decision",
+			fmt.Sprintf("%s %s %s: %s", leave.Student.Name, verb, span, leave.Reason))
+	case LeaveWithdrawn:
+		if stages := leave.Stages(); leave.Stage < len(stages) {
+			notifyRoles(stages[leave.Stage], "Leave Withdrawn",
+				fmt.Sprintf("%s withdrew their request for %s", leave.Student.Name, span))
+		}
+	case LeaveCancellationRequested:
+		var roles []db.UserRole
+		seen := make(map[db.UserRole]bool)
+		for _, stage := range leave.Stages() {
+			for _, role := range stage {
+				if !seen[role] {
+					seen[role] = true
+					roles = append(roles, role)
+				}
+			}
+		}
+		notifyRoles(roles, "Leave Cancellation Requested",
+			fmt.Sprintf("%s asks to cancel their approved %s: %s", leave.Student.Name, span, leave.CancellationReason))
+	case LeaveCancellationDeclined:
+		notify(leave.StudentID, "Leave Request Update",
+			fmt.Sprintf("Your request to cancel your leave from %s was declined, the leave stands. %s",
+				leave.StartDate.Format("2006-01-02"), leave.Remarks))
+	}
+
+	if payload.Event == LeaveAdvanced {
+		awaiting := leave.Awaiting()
+		roles := make([]string, len(awaiting))
+		for i, role := range awaiting {
+			roles[i] = strings.ToUpper(string(role))
+		}
+		notify(leave.StudentID, "Leave Request Update",
+			fmt.Sprintf("Your leave request was approved at one stage and now waits for the %s", strings.Join(roles, " and ")))
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := s.DB.Create(&notifications).Error; err != nil {
+		return fmt.Errorf("failed to create notifications: %v", err)
+	}
+	return nil
+}
+
+// leaveApprovers returns the users who decide for role: the faculty teaching
+// the student, the HODs of the student's department, the warden of the
+// student's hostel, or everyone with the role when none of these exist
+func (s *NotificationService) leaveApprovers(leave *db.LeaveRequest, role db.UserRole) []uint {
+	var ids []uint
+	switch role {
+	case db.RoleFaculty:
+		s.DB.Model(&db.ClassSession{}).
+			Joins("JOIN enrollments ON enrollments.course_id = class_sessions.course_id AND enrollments.section = class_sessions.section").
+			Where("enrollments.student_id = ?", leave.StudentID).
+			Distinct("class_sessions.faculty_id").Pluck("class_sessions.faculty_id", &ids)
+	case db.RoleHOD:
+		s.DB.Model(&db.User{}).Where("role = ? AND dept = ? AND dept <> ''", db.RoleHOD, leave.Student.Dept).Pluck("id", &ids)
+	case db.RoleWarden:
+		var hostel db.Hostel
+		s.DB.Joins("JOIN hostel_residents ON hostel_residents.hostel_id = hostels.id").
+			Where("hostel_residents.student_id = ?", leave.StudentID).First(&hostel)
+		if hostel.WardenID != nil {
+			return []uint{*hostel.WardenID}
+		}
+	}
+	// Like the leaves package, everyone of the role decides when nobody is
+	// assigned to the student
+	if len(ids) == 0 {
+		s.DB.Model(&db.User{}).Where("role = ?", role).Pluck("id", &ids)
+	}
+	return ids
+}
+
+// handleLeaveExpiry expires leaves still undecided LEAVE_EXPIRE_AFTER after
+// both their end date and their last change, and tells the students
+func (s *NotificationService) handleLeaveExpiry(ctx context.Context, t *asynq.Task) error {
+	after := config.AppConfig.Attendance.LeaveExpireAfter
+	if after <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-after)
+
+	var leaves []db.LeaveRequest
+	if err := s.DB.Where("status IN ? AND end_date < ? AND updated_at < ?", db.LeaveAwaitingDecision, clock.DateOf(cutoff), cutoff).
+		Find(&leaves).Error; err != nil {
+		return fmt.Errorf("failed to fetch undecided leaves: %v", err)
+	}
+
+	for i := range leaves {
+		leave := &leaves[i]
+		if err := leave.MoveTo(db.StatusExpired, 0, "Not decided in time"); err != nil {
+			log.Printf("Failed to expire leave %d: %v", leave.ID, err)
+			continue
+		}
+		leave.SetAwaiting(nil)
+		if err := s.DB.Save(leave).Error; err != nil {
+			log.Printf("Failed to expire leave %d: %v", leave.ID, err)
+			continue
+		}
+
+		notification := db.Notification{
+			UserID: leave.StudentID,
+			Type:   "leave_status",
+			Title:  "Leave Request Expired",
+			Message: fmt.Sprintf("Your leave request from %s to %s expired without a decision",
+				leave.StartDate.Format("2006-01-02"), leave.EndDate.Format("2006-01-02")),
+			IsRead: false,
+		}
+		if err := s.DB.Create(&notification).Error; err != nil {
+			log.Printf("Failed to create expiry notification for leave %d: %v", leave.ID, err)
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,7 +18,15 @@ import (
 const (
 	TypeLeaveStatusUpdate = "leave:status_update"
 	TypeAttendanceMarked  = "attendance:marked"
+	TypeAttendanceBulk    = "attendance:bulk_marked"
 	TypeReminderEmail     = "email:reminder"
+	TypeCorrectionUpdate  = "attendance:correction"
+	TypeEligibilityCheck  = "attendance:eligibility_check"
+	TypeCondonationUpdate = "attendance:condonation"
+	TypeRollCallAlert     = "hostel:roll_call_alert"
+	TypeSessionUpdate     = "session:update"
+	TypeLeaveApproval     = "leave:approval"
+	TypeLeaveExpiry       = "leave:expire"
 )
 
 type NotificationService struct {
@@ -63,7 +72,15 @@ func (s *NotificationService) startProcessor() {
 	// Handler for leave status updates
 	mux.HandleFunc(TypeLeaveStatusUpdate, s.handleLeaveStatusUpdate)
 	mux.HandleFunc(TypeAttendanceMarked, s.handleAttendanceMarked)
+	mux.HandleFunc(TypeAttendanceBulk, s.handleBulkAttendanceMarked)
 	mux.HandleFunc(TypeReminderEmail, s.handleReminderEmail)
+	mux.HandleFunc(TypeCorrectionUpdate, s.handleCorrectionUpdate)
+	mux.HandleFunc(TypeEligibilityCheck, s.handleEligibilityCheck)
+	mux.HandleFunc(TypeCondonationUpdate, s.handleCondonationUpdate)
+	mux.HandleFunc(TypeRollCallAlert, s.handleRollCallAlert)
+	mux.HandleFunc(TypeSessionUpdate, s.handleSessionUpdate)
+	mux.HandleFunc(TypeLeaveApproval, s.handleLeaveApproval)
+	mux.HandleFunc(TypeLeaveExpiry, s.handleLeaveExpiry)
 
 	s.wg.Add(1)
 	go func() {
@@ -92,9 +109,23 @@ type AttendancePayload struct {
 	StudentID uint      `json:"student_id"`
 	Date      time.Time `json:"date"`
 	Present   bool      `json:"present"`
+	Status    string    `json:"status,omitempty"`
 	MarkedBy  string    `json:"marked_by"`
 }
 
+// BulkAttendancePayload carries every student marked in one roster submission
+type BulkAttendancePayload struct {
+	Date     time.Time         `json:"date"`
+	MarkedBy string            `json:"marked_by"`
+	Entries  []AttendanceEntry `json:"entries"`
+}
+
+type AttendanceEntry struct {
+	StudentID uint   `json:"student_id"`
+	Present   bool   `json:"present"`
+	Status    string `json:"status,omitempty"`
+}
+
 func (s *NotificationService) QueueLeaveStatusNotification(ctx context.Context, payload LeaveStatusUpdatePayload) error {
 	taskBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -117,6 +148,18 @@ func (s *NotificationService) QueueAttendanceNotification(ctx context.Context, p
 	return err
 }
 
+// QueueBulkAttendanceNotification enqueues a single task for a whole roster
+func (s *NotificationService) QueueBulkAttendanceNotification(ctx context.Context, payload BulkAttendancePayload) error {
+	taskBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bulk attendance payload: %v", err)
+	}
+
+	task := asynq.NewTask(TypeAttendanceBulk, taskBytes)
+	_, err = s.client.EnqueueContext(ctx, task)
+	return err
+}
+
 func (s *NotificationService) handleLeaveStatusUpdate(ctx context.Context, t *asynq.Task) error {
 	var payload LeaveStatusUpdatePayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
@@ -150,10 +193,7 @@ func (s *NotificationService) handleAttendanceMarked(ctx context.Context, t *asy
 		return fmt.Errorf("failed to unmarshal attendance payload: %v", err)
 	}
 
-	status := "present"
-	if !payload.Present {
-		status = "absent"
-	}
+	status := attendanceStatusLabel(payload.Status, payload.Present)
 
 	notification := db.Notification{
 		UserID: payload.StudentID,
@@ -178,10 +218,51 @@ func (s *NotificationService) handleAttendanceMarked(ctx context.Context, t *asy
 	return nil
 }
 
+func (s *NotificationService) handleBulkAttendanceMarked(ctx context.Context, t *asynq.Task) error {
+	var payload BulkAttendancePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal bulk attendance payload: %v", err)
+	}
+
+	notifications := make([]db.Notification, 0, len(payload.Entries))
+	for _, entry := range payload.Entries {
+		status := attendanceStatusLabel(entry.Status, entry.Present)
+		notifications = append(notifications, db.Notification{
+			UserID: entry.StudentID,
+			Type:   "attendance",
+			Title:  "Attendance Update",
+			Message: fmt.Sprintf("Your attendance has been marked as %s for %s by %s",
+				status, payload.Date.Format("2006-01-02"), payload.MarkedBy),
+			IsRead: false,
+		})
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := s.DB.CreateInBatches(&notifications, 100).Error; err != nil {
+		return fmt.Errorf("failed to create notifications: %v", err)
+	}
+
+	return nil
+}
+
+// attendanceStatusLabel describes a status for messages, supporting payloads
+// queued before statuses were introduced
+func attendanceStatusLabel(status string, present bool) string {
+	if status != "" {
+		return strings.ReplaceAll(status, "_", " ")
+	}
+	if present {
+		return "present"
+	}
+	return "absent"
+}
+
 func (s *NotificationService) handleReminderEmail(ctx context.Context, t *asynq.Task) error {
 	// Send reminders for pending leave requests
 	var pendingLeaves []db.LeaveRequest
-	if err := s.DB.Where("status = ?", db.StatusPending).Find(&pendingLeaves).Error; err != nil {
+	if err := s.DB.Where("status IN ?", db.LeaveAwaitingDecision).Find(&pendingLeaves).Error; err != nil {
 		return fmt.Errorf("failed to fetch pending leaves: %v", err)
 	}
 
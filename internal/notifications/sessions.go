@@ -0,0 +1,87 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"attendance-workflow/pkg/db"
+
+	"github.com/hibiken/asynq"
+)
+
+// Session events
+const (
+	SessionCancelled   = "cancelled"
+	SessionSubstituted = "substituted"
+)
+
+type SessionPayload struct {
+	SessionID uint   `json:"session_id"`
+	Event     string `json:"event"`
+}
+
+func (s *NotificationService) QueueSessionNotification(ctx context.Context, payload SessionPayload) error {
+	taskBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session payload: %v", err)
+	}
+
+	task := asynq.NewTask(TypeSessionUpdate, taskBytes)
+	_, err = s.client.EnqueueContext(ctx, task)
+	return err
+}
+
+// handleSessionUpdate tells the section's students about a cancelled or
+// substituted class, and the substitute about the class they now take
+func (s *NotificationService) handleSessionUpdate(ctx context.Context, t *asynq.Task) error {
+	var payload SessionPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal session payload: %v", err)
+	}
+
+	var session db.ClassSession
+	if err := s.DB.Preload("Course").Preload("Faculty").First(&session, payload.SessionID).Error; err != nil {
+		return fmt.Errorf("failed to load session %d: %v", payload.SessionID, err)
+	}
+
+	class := fmt.Sprintf("%s (section %s) on %s, period %d",
+		session.Course.Code, session.Section, session.Date.Format("2006-01-02"), session.Period)
+	var title, message string
+	switch payload.Event {
+	case SessionCancelled:
+		title = "Class Cancelled"
+		message = fmt.Sprintf("The class of %s has been cancelled. %s", class, session.Remarks)
+	case SessionSubstituted:
+		title = "Class Substitution"
+		message = fmt.Sprintf("The class of %s will be taken by %s", class, session.Faculty.Name)
+	default:
+		return fmt.Errorf("unknown session event %q", payload.Event)
+	}
+
+	var recipients []uint
+	s.DB.Model(&db.Enrollment{}).
+		Where("course_id = ? AND section = ?", session.CourseID, session.Section).
+		Pluck("student_id", &recipients)
+	if payload.Event == SessionSubstituted && session.OriginalFacultyID != nil {
+		recipients = append(recipients, session.FacultyID)
+	}
+
+	notifications := make([]db.Notification, 0, len(recipients))
+	for _, userID := range recipients {
+		notifications = append(notifications, db.Notification{
+			UserID:  userID,
+			Type:    "session",
+			Title:   title,
+			Message: message,
+			IsRead:  false,
+		})
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := s.DB.CreateInBatches(&notifications, 100).Error; err != nil {
+		return fmt.Errorf("failed to create notifications: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+package punches
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPunchBatch bounds a single ingestion request or file
+const maxPunchBatch = 10000
+
+type PunchHandler struct {
+	DB        db.GormDB
+	Processor *Processor
+}
+
+func NewPunchHandler() *PunchHandler {
+	return &PunchHandler{DB: db.DB, Processor: NewProcessor(db.DB)}
+}
+
+// IngestPunches godoc
+// @Summary      Ingest punches
+// @Description  Store a batch of RFID or biometric punches and turn them into attendance according to each terminal's mode
+// @Tags         punches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.IngestPunchesRequest  true  "Punches"
+// @Success      200      {object}  object{message=string,summary=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /punches [post]
+func (h *PunchHandler) IngestPunches(c *gin.Context) {
+	var req dto.IngestPunchesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Punches) > maxPunchBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many punches in one request"})
+		return
+	}
+
+	raw := make([]RawPunch, 0, len(req.Punches))
+	for i, punch := range req.Punches {
+		terminal := punch.TerminalCode
+		if terminal == "" {
+			terminal = req.TerminalCode
+		}
+		if terminal == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "punches[" + strconv.Itoa(i) + "] has no terminal_code"})
+			return
+		}
+		raw = append(raw, RawPunch{CardCode: punch.CardCode, TerminalCode: terminal, PunchedAt: punch.Timestamp})
+	}
+
+	h.process(c, raw)
+}
+
+// ImportPunches godoc
+// @Summary      Import punch file
+// @Description  Import a CSV file (header with card code, timestamp and optional terminal columns) or a terminal attendance log ("<code> <YYYY-MM-DD> <HH:MM:SS> ...")
+// @Tags         punches
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file      formData  file    true   "Punch file"
+// @Param        format    formData  string  false  "csv (default) or log"
+// @Param        terminal  formData  string  false  "Terminal code for records without one"
+// @Success      200       {object}  object{message=string,summary=object}
+// @Failure      400       {object}  object{error=string}
+// @Router       /punches/import [post]
+func (h *PunchHandler) ImportPunches(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Punch file is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	raw, err := Parse(file, c.PostForm("format"), c.PostForm("terminal"))
+	if err == nil && len(raw) == 0 {
+		err = ErrNoPunches
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid punch file: " + err.Error()})
+		return
+	}
+	if len(raw) > maxPunchBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many punches in one file, split it or use the import command"})
+		return
+	}
+
+	h.process(c, raw)
+}
+
+func (h *PunchHandler) process(c *gin.Context, raw []RawPunch) {
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+
+	summary, err := h.Processor.Process(raw, uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process punches", "summary": summary})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Punches processed", "summary": summary})
+}
+
+// GetPunches godoc
+// @Summary      List punches
+// @Tags         punches
+// @Produce      json
+// @Security     BearerAuth
+// @Param        date      query     string  false  "Date (YYYY-MM-DD)"
+// @Param        result    query     string  false  "applied, ignored, duplicate or unmapped"
+// @Param        card      query     string  false  "Card code"
+// @Param        terminal  query     string  false  "Terminal code"
+// @Param        page      query     int     false  "Page number" default(1)
+// @Param        limit     query     int     false  "Items per page" default(50)
+// @Success      200       {object}  object{data=array,page=int,limit=int,total=int64}
+// @Failure      400       {object}  object{error=string}
+// @Router       /punches [get]
+func (h *PunchHandler) GetPunches(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	query := h.DB.Model(&db.Punch{})
+	if date := c.Query("date"); date != "" {
+		day, err := time.ParseInLocation("2006-01-02", date, clock.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+			return
+		}
+		query = query.Where("punched_at >= ? AND punched_at < ?", day, day.AddDate(0, 0, 1))
+	}
+	if result := c.Query("result"); result != "" {
+		query = query.Where("result = ?", result)
+	}
+	if card := c.Query("card"); card != "" {
+		query = query.Where("card_code = ?", card)
+	}
+	if terminal := c.Query("terminal"); terminal != "" {
+		query = query.Where("terminal_code = ?", terminal)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var punches []db.Punch
+	query.Order("punched_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&punches)
+
+	c.JSON(http.StatusOK, gin.H{"data": clock.In(punches, auth.Zone(c)), "page": page, "limit": limit, "total": total})
+}
+
+// GetCards godoc
+// @Summary      List cards
+// @Tags         punches
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id  query     int  false  "User ID"
+// @Success      200      {object}  object{data=array}
+// @Router       /cards [get]
+func (h *PunchHandler) GetCards(c *gin.Context) {
+	query := h.DB.Preload("User").Order("code")
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var cards []db.Card
+	query.Find(&cards)
+
+	c.JSON(http.StatusOK, gin.H{"data": cards})
+}
+
+// CreateCard godoc
+// @Summary      Map a card to a user
+// @Tags         punches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateCardRequest  true  "Card"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /cards [post]
+func (h *PunchHandler) CreateCard(c *gin.Context) {
+	var req dto.CreateCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kind := db.CardRFID
+	if req.Kind != "" {
+		kind = db.CardKind(req.Kind)
+	}
+	if kind != db.CardRFID && kind != db.CardBiometric {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be rfid or biometric"})
+		return
+	}
+
+	var user db.User
+	if err := h.DB.First(&user, req.UserID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		return
+	}
+
+	card := db.Card{Code: req.Code, Kind: kind, UserID: user.ID}
+	if err := h.DB.Create(&card).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Card code is already mapped"})
+		return
+	}
+	card.User = user
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Card mapped successfully", "data": card})
+}
+
+// DeleteCard godoc
+// @Summary      Remove a card mapping
+// @Tags         punches
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Card ID"
+// @Success      200  {object}  object{message=string}
+// @Failure      404  {object}  object{error=string}
+// @Router       /cards/{id} [delete]
+func (h *PunchHandler) DeleteCard(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+
+	result := h.DB.Delete(&db.Card{}, id)
+	if result.Error != nil || result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Card removed successfully"})
+}
+
+// GetTerminals godoc
+// @Summary      List terminals
+// @Tags         punches
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array}
+// @Router       /terminals [get]
+func (h *PunchHandler) GetTerminals(c *gin.Context) {
+	var terminals []db.Terminal
+	h.DB.Order("code").Find(&terminals)
+
+	c.JSON(http.StatusOK, gin.H{"data": terminals})
+}
+
+// CreateTerminal godoc
+// @Summary      Register terminal
+// @Description  Register a punch terminal; daily terminals mark daily attendance, session terminals mark the running class session
+// @Tags         punches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateTerminalRequest  true  "Terminal"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Router       /terminals [post]
+func (h *PunchHandler) CreateTerminal(c *gin.Context) {
+	var req dto.CreateTerminalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode := db.TerminalDaily
+	if req.Mode != "" {
+		mode = db.TerminalMode(req.Mode)
+	}
+	if mode != db.TerminalDaily && mode != db.TerminalSession {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be daily or session"})
+		return
+	}
+
+	terminal := db.Terminal{Code: req.Code, Name: req.Name, Location: req.Location, Mode: mode}
+	if err := h.DB.Create(&terminal).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Terminal code already exists"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Terminal registered successfully", "data": terminal})
+}
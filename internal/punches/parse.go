@@ -0,0 +1,158 @@
+package punches
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"attendance-workflow/pkg/clock"
+)
+
+const (
+	FormatCSV = "csv"
+	FormatLog = "log"
+)
+
+// RawPunch is one punch as read from a terminal, before card mapping
+type RawPunch struct {
+	CardCode     string    `json:"card_code"`
+	TerminalCode string    `json:"terminal_code"`
+	PunchedAt    time.Time `json:"punched_at"`
+}
+
+// timestampLayouts are tried in order; zone-less values are terminal local time
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, clock.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+// Parse reads punches in the given format. terminal is the terminal code to
+// use for records that do not carry one.
+func Parse(r io.Reader, format, terminal string) ([]RawPunch, error) {
+	switch format {
+	case FormatCSV, "":
+		return ParseCSV(r, terminal)
+	case FormatLog:
+		return ParseLog(r, terminal)
+	}
+	return nil, fmt.Errorf("unknown format %q, expected csv or log", format)
+}
+
+// ParseCSV reads a CSV file with a header row naming the columns. The card
+// column may be called code, card, card_code or user_code, the timestamp
+// column timestamp or time, and the optional terminal column terminal or
+// terminal_id.
+func ParseCSV(r io.Reader, terminal string) ([]RawPunch, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("empty file")
+		}
+		return nil, err
+	}
+	columns := map[string]int{"card": -1, "terminal": -1, "timestamp": -1}
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "code", "card", "card_code", "user_code":
+			columns["card"] = i
+		case "terminal", "terminal_id", "terminal_code":
+			columns["terminal"] = i
+		case "timestamp", "time", "punched_at":
+			columns["timestamp"] = i
+		}
+	}
+	if columns["card"] < 0 || columns["timestamp"] < 0 {
+		return nil, errors.New("header must name a card code and a timestamp column")
+	}
+	if columns["terminal"] < 0 && terminal == "" {
+		return nil, errors.New("no terminal column, a terminal must be given")
+	}
+
+	var punches []RawPunch
+	line := 1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		field := func(column string) string {
+			i := columns[column]
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		punch := RawPunch{CardCode: field("card"), TerminalCode: field("terminal")}
+		if punch.TerminalCode == "" {
+			punch.TerminalCode = terminal
+		}
+		if punch.CardCode == "" {
+			return nil, fmt.Errorf("line %d: missing card code", line)
+		}
+		if punch.PunchedAt, err = parseTimestamp(field("timestamp")); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		punches = append(punches, punch)
+	}
+	return punches, nil
+}
+
+// ParseLog reads the attendance log most fingerprint and RFID terminals
+// export: one punch per line as "<user code> <YYYY-MM-DD> <HH:MM:SS>"
+// separated by tabs or spaces, optionally followed by device specific
+// columns (verify mode, in/out state, work code) which are ignored. Blank
+// lines and lines starting with # are skipped.
+func ParseLog(r io.Reader, terminal string) ([]RawPunch, error) {
+	if terminal == "" {
+		return nil, errors.New("a terminal must be given for log files")
+	}
+
+	var punches []RawPunch
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected user code, date and time", line)
+		}
+		punchedAt, err := parseTimestamp(fields[1] + " " + fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		punches = append(punches, RawPunch{CardCode: fields[0], TerminalCode: terminal, PunchedAt: punchedAt})
+	}
+	return punches, scanner.Err()
+}
@@ -0,0 +1,248 @@
+package punches
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"attendance-workflow/internal/calendar"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/config"
+	"attendance-workflow/pkg/db"
+
+	"gorm.io/gorm"
+)
+
+// earlyPunch is how long before a session starts a lab punch still counts
+// for it
+const earlyPunch = 15 * time.Minute
+
+// Processor stores punches and turns them into attendance according to the
+// terminal's mode:
+//
+//   - daily terminals mark the student's daily attendance with their first
+//     punch of the day, late after PUNCH_DAILY_LATE_AFTER
+//   - session terminals mark the enrolled session running at punch time,
+//     late once past the session's grace period
+//
+// Punches never overwrite attendance that is already recorded, so manual
+// marks and earlier punches win, and are ignored on locked dates.
+type Processor struct {
+	DB       db.GormDB
+	Calendar *calendar.Service
+}
+
+func NewProcessor(database db.GormDB) *Processor {
+	return &Processor{DB: database, Calendar: calendar.NewService(database)}
+}
+
+// Summary counts punches by result
+type Summary struct {
+	Received int                    `json:"received"`
+	Results  map[db.PunchResult]int `json:"results"`
+	Punches  []db.Punch             `json:"punches"`
+}
+
+// Process stores and applies punches, oldest first. markedBy is recorded as
+// the marker of attendance created from them.
+func (p *Processor) Process(punches []RawPunch, markedBy uint) (Summary, error) {
+	summary := Summary{Received: len(punches), Results: make(map[db.PunchResult]int)}
+
+	sorted := make([]RawPunch, len(punches))
+	copy(sorted, punches)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PunchedAt.Before(sorted[j].PunchedAt)
+	})
+
+	cards, terminals := p.lookups(sorted)
+
+	for _, raw := range sorted {
+		punch := db.Punch{CardCode: raw.CardCode, TerminalCode: raw.TerminalCode, PunchedAt: raw.PunchedAt}
+
+		err := p.DB.Transaction(func(tx *gorm.DB) error {
+			var exists int64
+			tx.Model(&db.Punch{}).
+				Where("card_code = ? AND terminal_code = ? AND punched_at = ?", punch.CardCode, punch.TerminalCode, punch.PunchedAt).
+				Count(&exists)
+			if exists > 0 {
+				// Re-sent punch, nothing to store
+				punch.Result = db.PunchDuplicate
+				punch.Detail = "Already received"
+				return nil
+			}
+
+			card, cardOK := cards[raw.CardCode]
+			terminal, terminalOK := terminals[raw.TerminalCode]
+			switch {
+			case !terminalOK:
+				punch.Result, punch.Detail = db.PunchUnmapped, "Unknown terminal"
+			case !cardOK:
+				punch.Result, punch.Detail = db.PunchUnmapped, "Unknown card"
+			default:
+				punch.UserID = &card.UserID
+				if p.isRepeat(tx, punch) {
+					punch.Result, punch.Detail = db.PunchDuplicate, "Repeat punch within the dedup window"
+				} else if err := p.apply(tx, &punch, terminal, markedBy); err != nil {
+					return err
+				}
+			}
+
+			return tx.Create(&punch).Error
+		})
+		if err != nil {
+			return summary, fmt.Errorf("punch %s at %s: %w", raw.CardCode, raw.PunchedAt.Format(time.RFC3339), err)
+		}
+
+		summary.Results[punch.Result]++
+		summary.Punches = append(summary.Punches, punch)
+	}
+
+	return summary, nil
+}
+
+// lookups loads the cards and terminals referenced by punches
+func (p *Processor) lookups(punches []RawPunch) (map[string]db.Card, map[string]db.Terminal) {
+	var cardCodes, terminalCodes []string
+	for _, punch := range punches {
+		cardCodes = append(cardCodes, punch.CardCode)
+		terminalCodes = append(terminalCodes, punch.TerminalCode)
+	}
+
+	cards := make(map[string]db.Card)
+	terminals := make(map[string]db.Terminal)
+	if len(punches) == 0 {
+		return cards, terminals
+	}
+
+	var cardRows []db.Card
+	p.DB.Where("code IN ?", cardCodes).Find(&cardRows)
+	for _, card := range cardRows {
+		cards[card.Code] = card
+	}
+	var terminalRows []db.Terminal
+	p.DB.Where("code IN ?", terminalCodes).Find(&terminalRows)
+	for _, terminal := range terminalRows {
+		terminals[terminal.Code] = terminal
+	}
+	return cards, terminals
+}
+
+// isRepeat reports whether the card already punched at the terminal within
+// the dedup window before this punch
+func (p *Processor) isRepeat(tx *gorm.DB, punch db.Punch) bool {
+	window := config.AppConfig.Attendance.PunchDedupWindow
+	if window <= 0 {
+		return false
+	}
+	var count int64
+	tx.Model(&db.Punch{}).
+		Where("card_code = ? AND terminal_code = ? AND punched_at >= ? AND punched_at < ? AND result <> ?",
+			punch.CardCode, punch.TerminalCode, punch.PunchedAt.Add(-window), punch.PunchedAt, db.PunchDuplicate).
+		Count(&count)
+	return count > 0
+}
+
+// apply runs the terminal's rule for a mapped punch and sets its result
+func (p *Processor) apply(tx *gorm.DB, punch *db.Punch, terminal db.Terminal, markedBy uint) error {
+	var student db.User
+	if err := tx.First(&student, *punch.UserID).Error; err != nil {
+		punch.Result, punch.Detail = db.PunchUnmapped, "Card user no longer exists"
+		return nil
+	}
+	if student.Role != db.RoleStudent {
+		punch.Result, punch.Detail = db.PunchIgnored, "Card does not belong to a student"
+		return nil
+	}
+
+	day := clock.DateOf(punch.PunchedAt)
+	if p.Calendar.Locked(day) {
+		punch.Result, punch.Detail = db.PunchIgnored, calendar.LockedMessage
+		return nil
+	}
+	if working, reason := p.Calendar.IsWorkingDay(student.Dept, day); !working {
+		punch.Result, punch.Detail = db.PunchIgnored, reason
+		return nil
+	}
+
+	var onLeave int64
+	tx.Model(&db.LeaveRequest{}).
+		Where("student_id = ? AND status IN ? AND start_date <= ? AND end_date >= ?", student.ID, db.LeaveInEffect, day, day).
+		Count(&onLeave)
+	if onLeave > 0 {
+		punch.Result, punch.Detail = db.PunchIgnored, "Student is on approved leave"
+		return nil
+	}
+
+	record := db.Attendance{StudentID: student.ID, Date: day, Source: db.SourcePunch, MarkedBy: markedBy}
+	var existing *gorm.DB
+	switch terminal.Mode {
+	case db.TerminalSession:
+		session, ok := p.runningSession(tx, student.ID, day, punch.PunchedAt)
+		if !ok {
+			punch.Result, punch.Detail = db.PunchIgnored, "No enrolled session running at punch time"
+			return nil
+		}
+		record.SessionID = &session.ID
+		record.RecordArrival(session, punch.PunchedAt)
+		existing = tx.Model(&db.Attendance{}).Where("student_id = ? AND session_id = ?", student.ID, session.ID)
+	default:
+		punchedAt := punch.PunchedAt
+		record.CheckedInAt = &punchedAt
+		record.SetStatus(db.AttendancePresent)
+		if lateAfter := timeOfDay(day, config.AppConfig.Attendance.PunchDailyLateAfter); !lateAfter.IsZero() && punch.PunchedAt.After(lateAfter) {
+			record.SetStatus(db.AttendanceLate)
+			record.MinutesLate = int(punch.PunchedAt.Sub(lateAfter).Minutes())
+		}
+		existing = tx.Model(&db.Attendance{}).Where("student_id = ? AND date = ? AND session_id IS NULL", student.ID, day)
+	}
+
+	var count int64
+	existing.Count(&count)
+	if count > 0 {
+		punch.Result, punch.Detail = db.PunchIgnored, "Attendance already recorded"
+		return nil
+	}
+
+	if err := tx.Create(&record).Error; err != nil {
+		return err
+	}
+	punch.Result = db.PunchApplied
+	punch.Detail = string(record.Status)
+	punch.AttendanceID = &record.ID
+	return nil
+}
+
+// runningSession finds the enrolled session of the student whose time slot
+// contains at, allowing punches shortly before the start
+func (p *Processor) runningSession(tx *gorm.DB, studentID uint, day, at time.Time) (*db.ClassSession, bool) {
+	var sessions []db.ClassSession
+	tx.Joins("JOIN enrollments ON enrollments.course_id = class_sessions.course_id AND enrollments.section = class_sessions.section").
+		Where("enrollments.student_id = ? AND class_sessions.date = ?", studentID, day).
+		Order("class_sessions.period").
+		Find(&sessions)
+
+	for i := range sessions {
+		start, end := timeOfDay(day, sessions[i].StartTime), timeOfDay(day, sessions[i].EndTime)
+		if start.IsZero() || end.IsZero() {
+			continue
+		}
+		if !at.Before(start.Add(-earlyPunch)) && !at.After(end) {
+			return &sessions[i], true
+		}
+	}
+	return nil, false
+}
+
+// timeOfDay places an HH:MM time of day on day in the institution's
+// timezone, zero when unset or invalid
+func timeOfDay(day time.Time, hhmm string) time.Time {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return time.Time{}
+	}
+	return clock.At(day, t.Hour(), t.Minute())
+}
+
+// ErrNoPunches is returned when an upload contains no punch records
+var ErrNoPunches = errors.New("no punches found")
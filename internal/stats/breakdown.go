@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"attendance-workflow/pkg/db"
+)
+
+// PeriodStats summarizes the derived days of a week or month. The late
+// penalty only applies to the totals, not to individual periods.
+type PeriodStats struct {
+	Period       string    `json:"period"` // 2006-W01 or 2006-01
+	Start        time.Time `json:"start"`
+	PresentDays  int64     `json:"present_days"`
+	TotalDays    int64     `json:"total_days"`
+	WeightedDays float64   `json:"weighted_days"`
+	Percentage   float64   `json:"attendance_percentage"`
+}
+
+// Streak is a run of consecutive working days on which a student was absent
+type Streak struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Days  int       `json:"days"`
+}
+
+// Streaks lists runs of two or more absent days, newest first. Days that
+// are not working days do not break a run; working days that are unmarked
+// or not absent do.
+type Streaks struct {
+	Current int      `json:"current"` // run ending on the latest recorded day
+	Longest int      `json:"longest"`
+	Runs    []Streak `json:"runs"`
+}
+
+func weeklyBreakdown(days []DailyStatus) []PeriodStats {
+	return periodBreakdown(days, func(date time.Time) (string, time.Time) {
+		year, week := date.ISOWeek()
+		start := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+		return fmt.Sprintf("%d-W%02d", year, week), start
+	})
+}
+
+func monthlyBreakdown(days []DailyStatus) []PeriodStats {
+	return periodBreakdown(days, func(date time.Time) (string, time.Time) {
+		return date.Format("2006-01"), time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	})
+}
+
+// periodBreakdown groups days by the period returned by bucket, oldest first
+func periodBreakdown(days []DailyStatus, bucket func(time.Time) (string, time.Time)) []PeriodStats {
+	periods := make(map[string]*PeriodStats)
+	for _, day := range days {
+		key, start := bucket(day.Date)
+		period, ok := periods[key]
+		if !ok {
+			period = &PeriodStats{Period: key, Start: start}
+			periods[key] = period
+		}
+		period.TotalDays++
+		period.WeightedDays += day.Weight
+		if day.Present {
+			period.PresentDays++
+		}
+	}
+
+	result := make([]PeriodStats, 0, len(periods))
+	for _, period := range periods {
+		period.Percentage = period.WeightedDays / float64(period.TotalDays) * 100
+		result = append(result, *period)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result
+}
+
+// absenceStreaks walks the working days between the first and last recorded
+// day. days are sorted newest first and only hold working days.
+func absenceStreaks(days []DailyStatus, nonWorking map[string]bool) Streaks {
+	streaks := Streaks{Runs: []Streak{}}
+	if len(days) == 0 {
+		return streaks
+	}
+	absent := make(map[string]bool, len(days))
+	for _, day := range days {
+		if day.Status == db.AttendanceAbsent {
+			absent[day.Date.Format("2006-01-02")] = true
+		}
+	}
+
+	var run *Streak
+	closeRun := func() {
+		if run != nil && run.Days >= 2 {
+			streaks.Runs = append(streaks.Runs, *run)
+			if run.Days > streaks.Longest {
+				streaks.Longest = run.Days
+			}
+		}
+		run = nil
+	}
+	last := days[0].Date
+	for date := days[len(days)-1].Date; !date.After(last); date = date.AddDate(0, 0, 1) {
+		key := date.Format("2006-01-02")
+		if nonWorking[key] {
+			continue
+		}
+		if !absent[key] {
+			closeRun()
+			continue
+		}
+		if run == nil {
+			run = &Streak{Start: date}
+		}
+		run.End = date
+		run.Days++
+	}
+	if run != nil {
+		streaks.Current = run.Days
+	}
+	closeRun()
+
+	sort.Slice(streaks.Runs, func(i, j int) bool {
+		return streaks.Runs[i].Start.After(streaks.Runs[j].Start)
+	})
+	return streaks
+}
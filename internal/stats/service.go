@@ -0,0 +1,118 @@
+// Package stats computes a student's attendance statistics over a date
+// range: derived days, percentages, course, weekly and monthly breakdowns,
+// punctuality and absence streaks.
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"attendance-workflow/internal/calendar"
+	"attendance-workflow/pkg/db"
+)
+
+// Range bounds statistics by date. A nil bound leaves that side open.
+type Range struct {
+	From *time.Time `json:"start_date,omitempty"`
+	To   *time.Time `json:"end_date,omitempty"`
+}
+
+// ParseDate reads a YYYY-MM-DD query value, naming param in the error
+func ParseDate(param, value string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid %s %q. Use YYYY-MM-DD", param, value)
+	}
+	return date, nil
+}
+
+// ParseRange reads optional start_date and end_date query values
+func ParseRange(start, end string) (Range, error) {
+	var r Range
+	if start != "" {
+		from, err := ParseDate("start_date", start)
+		if err != nil {
+			return r, err
+		}
+		r.From = &from
+	}
+	if end != "" {
+		to, err := ParseDate("end_date", end)
+		if err != nil {
+			return r, err
+		}
+		r.To = &to
+	}
+	if r.From != nil && r.To != nil && r.To.Before(*r.From) {
+		return r, fmt.Errorf("end_date must not be before start_date")
+	}
+	return r, nil
+}
+
+// Report is a student's attendance in a range: the records, newest first,
+// the derived day of each date and the statistics over working days
+type Report struct {
+	Records []db.Attendance
+	Daily   []DailyStatus
+	Stats   Stats
+}
+
+type Service struct {
+	DB       db.GormDB
+	Calendar *calendar.Service
+}
+
+func NewService(database db.GormDB) *Service {
+	return &Service{DB: database, Calendar: calendar.NewService(database)}
+}
+
+// ForStudent builds the report of a student's attendance in r
+func (s *Service) ForStudent(studentID uint, r Range) (*Report, error) {
+	query := s.DB.Preload("Session.Course").Where("student_id = ?", studentID)
+	if r.From != nil {
+		query = query.Where("date >= ?", *r.From)
+	}
+	if r.To != nil {
+		query = query.Where("date <= ?", *r.To)
+	}
+	var records []db.Attendance
+	if err := query.Order("date DESC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	var student db.User
+	s.DB.Select("id", "dept").First(&student, studentID)
+
+	daily := deriveDaily(records)
+	nonWorking := s.nonWorking(student.Dept, daily)
+	working := make([]DailyStatus, 0, len(daily))
+	for _, day := range daily {
+		if !nonWorking[day.Date.Format("2006-01-02")] {
+			working = append(working, day)
+		}
+	}
+
+	stats := dayStats(working)
+	stats.Courses = courseBreakdown(records)
+	stats.Punctuality = punctuality(records)
+	stats.Weekly = weeklyBreakdown(working)
+	stats.Monthly = monthlyBreakdown(working)
+	stats.Streaks = absenceStreaks(working, nonWorking)
+	stats.Range = r
+
+	return &Report{Records: records, Daily: daily, Stats: stats}, nil
+}
+
+// nonWorking lists the non-working days in the span of days, which are
+// sorted newest first, so holidays and weekly offs never count towards the
+// denominator
+func (s *Service) nonWorking(dept string, days []DailyStatus) map[string]bool {
+	result := make(map[string]bool)
+	if len(days) == 0 {
+		return result
+	}
+	for _, day := range s.Calendar.NonWorkingDays(dept, days[len(days)-1].Date, days[0].Date) {
+		result[day.Format("2006-01-02")] = true
+	}
+	return result
+}
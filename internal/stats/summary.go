@@ -0,0 +1,215 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"attendance-workflow/pkg/db"
+)
+
+// DailyStatus is the attendance of a student on one day. Days with class
+// sessions are derived from those sessions, other days use the daily record.
+type DailyStatus struct {
+	Date             time.Time           `json:"date"`
+	Status           db.AttendanceStatus `json:"status"`
+	Present          bool                `json:"present"`
+	Weight           float64             `json:"weight"`
+	SessionsHeld     int                 `json:"sessions_held,omitempty"`
+	SessionsAttended int                 `json:"sessions_attended,omitempty"`
+}
+
+// CourseStats is a student's attendance in the sessions of one course
+type CourseStats struct {
+	CourseID         uint    `json:"course_id"`
+	Code             string  `json:"code"`
+	Name             string  `json:"name"`
+	SessionsHeld     int     `json:"sessions_held"`
+	SessionsAttended int     `json:"sessions_attended"`
+	WeightedSessions float64 `json:"weighted_sessions"`
+	Lates            int     `json:"lates"`
+	LatePenalty      float64 `json:"late_penalty,omitempty"` // weight lost to LATES_PER_ABSENCE
+	Percentage       float64 `json:"attendance_percentage"`
+}
+
+// deriveDaily collapses attendance records into one status per day, newest
+// first. Session records take precedence over a daily record. A day with
+// sessions earns the average weight of its sessions and keeps their status
+// when they all agree; otherwise it is present at full weight, absent at
+// zero and half_day in between.
+func deriveDaily(records []db.Attendance) []DailyStatus {
+	type day struct {
+		daily    *db.Attendance
+		sessions []db.Attendance
+	}
+	days := make(map[string]*day)
+	for i := range records {
+		record := records[i]
+		key := record.Date.Format("2006-01-02")
+		d, ok := days[key]
+		if !ok {
+			d = &day{}
+			days[key] = d
+		}
+		if record.SessionID == nil {
+			d.daily = &record
+		} else {
+			d.sessions = append(d.sessions, record)
+		}
+	}
+
+	result := make([]DailyStatus, 0, len(days))
+	for _, d := range days {
+		if len(d.sessions) == 0 {
+			result = append(result, DailyStatus{
+				Date:    d.daily.Date,
+				Status:  d.daily.Status,
+				Present: d.daily.Status.Attended(),
+				Weight:  d.daily.Status.Weight(),
+			})
+			continue
+		}
+
+		status := DailyStatus{Date: d.sessions[0].Date, SessionsHeld: len(d.sessions), Status: d.sessions[0].Status}
+		var weight float64
+		for _, session := range d.sessions {
+			weight += session.Status.Weight()
+			if session.Status.Attended() {
+				status.SessionsAttended++
+			}
+			if session.Status != status.Status {
+				status.Status = ""
+			}
+		}
+		status.Weight = weight / float64(len(d.sessions))
+
+		if status.Status == "" {
+			switch {
+			case status.Weight >= 1:
+				status.Status = db.AttendancePresent
+			case status.Weight == 0:
+				status.Status = db.AttendanceAbsent
+			default:
+				status.Status = db.AttendanceHalfDay
+			}
+		}
+		status.Present = status.SessionsAttended*2 >= status.SessionsHeld
+		result = append(result, status)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date.After(result[j].Date)
+	})
+	return result
+}
+
+// courseBreakdown groups session records by course. Records must have
+// Session.Course preloaded.
+func courseBreakdown(records []db.Attendance) []CourseStats {
+	courses := make(map[uint]*CourseStats)
+	for _, record := range records {
+		if record.Session == nil {
+			continue
+		}
+		course := record.Session.Course
+		stats, ok := courses[course.ID]
+		if !ok {
+			stats = &CourseStats{CourseID: course.ID, Code: course.Code, Name: course.Name}
+			courses[course.ID] = stats
+		}
+		stats.SessionsHeld++
+		stats.WeightedSessions += record.Status.Weight()
+		if record.Status.Attended() {
+			stats.SessionsAttended++
+		}
+		if record.Status == db.AttendanceLate {
+			stats.Lates++
+		}
+	}
+
+	result := make([]CourseStats, 0, len(courses))
+	for _, stats := range courses {
+		stats.LatePenalty = db.LatePenalty(stats.Lates)
+		stats.WeightedSessions -= stats.LatePenalty
+		stats.Percentage = stats.WeightedSessions / float64(stats.SessionsHeld) * 100
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Code < result[j].Code
+	})
+	return result
+}
+
+// Stats summarizes a student's derived days. The percentage is the sum of
+// day weights, less the late penalty, over the number of days.
+type Stats struct {
+	PresentDays  int64                         `json:"present_days"`
+	TotalDays    int64                         `json:"total_days"`
+	WeightedDays float64                       `json:"weighted_days"`
+	LatePenalty  float64                       `json:"late_penalty,omitempty"` // weight lost to LATES_PER_ABSENCE
+	Percentage   float64                       `json:"attendance_percentage"`
+	ByStatus     map[db.AttendanceStatus]int64 `json:"by_status"`
+	Courses      []CourseStats                 `json:"courses"`
+	Punctuality  Punctuality                   `json:"punctuality"`
+	Weekly       []PeriodStats                 `json:"weekly"`
+	Monthly      []PeriodStats                 `json:"monthly"`
+	Streaks      Streaks                       `json:"absence_streaks"`
+	Range        Range                         `json:"range"`
+}
+
+func dayStats(days []DailyStatus) Stats {
+	stats := Stats{ByStatus: make(map[db.AttendanceStatus]int64)}
+	for _, day := range days {
+		stats.TotalDays++
+		stats.WeightedDays += day.Weight
+		stats.ByStatus[day.Status]++
+		if day.Present {
+			stats.PresentDays++
+		}
+	}
+	stats.LatePenalty = db.LatePenalty(int(stats.ByStatus[db.AttendanceLate]))
+	stats.WeightedDays -= stats.LatePenalty
+	if stats.TotalDays > 0 {
+		stats.Percentage = stats.WeightedDays / float64(stats.TotalDays) * 100
+	}
+	return stats
+}
+
+// Punctuality describes how often a student arrives on time. Only arrivals
+// count: records that are present or late.
+type Punctuality struct {
+	Arrivals           int        `json:"arrivals"`
+	OnTime             int        `json:"on_time"`
+	Late               int        `json:"late"`
+	Percentage         float64    `json:"punctuality_percentage"` // on time share of arrivals
+	AverageMinutesLate float64    `json:"average_minutes_late"`   // over late arrivals with a known time
+	LastLateAt         *time.Time `json:"last_late_at,omitempty"`
+}
+
+func punctuality(records []db.Attendance) Punctuality {
+	var p Punctuality
+	var minutes, timed int
+	for _, record := range records {
+		switch record.Status {
+		case db.AttendancePresent:
+			p.OnTime++
+		case db.AttendanceLate:
+			p.Late++
+			if record.CheckedInAt != nil {
+				minutes += record.MinutesLate
+				timed++
+			}
+			if p.LastLateAt == nil || record.Date.After(*p.LastLateAt) {
+				date := record.Date
+				p.LastLateAt = &date
+			}
+		}
+	}
+	p.Arrivals = p.OnTime + p.Late
+	if p.Arrivals > 0 {
+		p.Percentage = float64(p.OnTime) / float64(p.Arrivals) * 100
+	}
+	if timed > 0 {
+		p.AverageMinutesLate = float64(minutes) / float64(timed)
+	}
+	return p
+}
@@ -0,0 +1,263 @@
+package timetable
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/calendar"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TimetableHandler struct {
+	DB       db.GormDB
+	Calendar *calendar.Service
+}
+
+func NewTimetableHandler() *TimetableHandler {
+	return &TimetableHandler{DB: db.DB, Calendar: calendar.NewService(db.DB)}
+}
+
+// GetTimetable godoc
+// @Summary      List timetable
+// @Description  List weekly timetable entries, ordered by weekday and period
+// @Tags         timetable
+// @Produce      json
+// @Security     BearerAuth
+// @Param        term_id     query     int     false  "Term ID"
+// @Param        course_id   query     int     false  "Course ID"
+// @Param        section     query     string  false  "Section"
+// @Param        faculty_id  query     int     false  "Faculty ID"
+// @Param        room        query     string  false  "Room"
+// @Success      200         {object}  object{data=array}
+// @Router       /timetable [get]
+func (h *TimetableHandler) GetTimetable(c *gin.Context) {
+	query := h.DB.Preload("Course").Preload("Faculty").Preload("Term")
+	for _, filter := range []string{"term_id", "course_id", "section", "faculty_id", "room"} {
+		if value := c.Query(filter); value != "" {
+			query = query.Where(filter+" = ?", value)
+		}
+	}
+
+	var entries []db.TimetableEntry
+	query.Order("weekday, period, section").Find(&entries)
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// GetMyTimetable godoc
+// @Summary      Get my timetable
+// @Description  The timetable of the current term: the slots a faculty member teaches, or those of a student's enrolled sections
+// @Tags         timetable
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  object{data=array,term=object}
+// @Router       /timetable/my [get]
+func (h *TimetableHandler) GetMyTimetable(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+
+	term, found := h.Calendar.TermOn(clock.Today())
+	if !found {
+		c.JSON(http.StatusOK, gin.H{"data": []db.TimetableEntry{}, "term": nil})
+		return
+	}
+
+	query := h.DB.Preload("Course").Preload("Faculty").Where("term_id = ?", term.ID)
+	if role == string(db.RoleStudent) {
+		query = query.Where("EXISTS (SELECT 1 FROM enrollments WHERE enrollments.student_id = ? AND enrollments.course_id = timetable_entries.course_id AND enrollments.section = timetable_entries.section)", uid)
+	} else {
+		query = query.Where("faculty_id = ?", uid)
+	}
+
+	var entries []db.TimetableEntry
+	query.Order("weekday, period").Find(&entries)
+
+	c.JSON(http.StatusOK, gin.H{"data": entries, "term": term})
+}
+
+// CreateTimetableEntry godoc
+// @Summary      Add timetable entry
+// @Description  Add a weekly slot for a course section. The section, faculty and room must each be free in that weekday and period while the entry is valid. (admin only)
+// @Tags         timetable
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.CreateTimetableEntryRequest  true  "Timetable entry"
+// @Success      201      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string,data=object}
+// @Router       /timetable [post]
+func (h *TimetableHandler) CreateTimetableEntry(c *gin.Context) {
+	var req dto.CreateTimetableEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validSlot(req.StartTime, req.EndTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_time and end_time must be HH:MM with start before end"})
+		return
+	}
+
+	var term db.AcademicTerm
+	if err := h.DB.First(&term, req.TermID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Term not found"})
+		return
+	}
+	var course db.Course
+	if err := h.DB.First(&course, req.CourseID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Course not found"})
+		return
+	}
+	var faculty db.User
+	if err := h.DB.Where("id = ? AND role = ?", req.FacultyID, db.RoleFaculty).First(&faculty).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Faculty not found"})
+		return
+	}
+	if req.GeofenceID != nil {
+		var fence db.Geofence
+		if err := h.DB.First(&fence, *req.GeofenceID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Geofence not found"})
+			return
+		}
+	}
+
+	validFrom, validTo := term.StartDate, term.EndDate
+	if !req.ValidFrom.IsZero() {
+		validFrom = req.ValidFrom.Time
+	}
+	if !req.ValidTo.IsZero() {
+		validTo = req.ValidTo.Time
+	}
+	if validTo.Before(validFrom) || validFrom.Before(term.StartDate) || validTo.After(term.EndDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "valid_from and valid_to must be in order and within the term"})
+		return
+	}
+
+	entry := db.TimetableEntry{
+		TermID:       term.ID,
+		CourseID:     course.ID,
+		Section:      req.Section,
+		FacultyID:    faculty.ID,
+		Room:         req.Room,
+		GeofenceID:   req.GeofenceID,
+		Weekday:      *req.Weekday,
+		Period:       req.Period,
+		StartTime:    req.StartTime,
+		EndTime:      req.EndTime,
+		GraceMinutes: req.GraceMinutes,
+		ValidFrom:    validFrom,
+		ValidTo:      validTo,
+	}
+
+	if clash, reason := h.findClash(&entry); clash != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": reason, "data": clash})
+		return
+	}
+
+	if err := h.DB.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create timetable entry"})
+		return
+	}
+	entry.Term, entry.Course, entry.Faculty = term, course, faculty
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Timetable entry created successfully", "data": entry})
+}
+
+// DeleteTimetableEntry godoc
+// @Summary      Delete timetable entry
+// @Description  Remove a weekly slot along with its upcoming sessions that have no attendance yet. Past sessions are kept. (admin only)
+// @Tags         timetable
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Timetable entry ID"
+// @Success      200  {object}  object{message=string,removed_sessions=int}
+// @Failure      404  {object}  object{error=string}
+// @Router       /timetable/{id} [delete]
+func (h *TimetableHandler) DeleteTimetableEntry(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timetable entry ID"})
+		return
+	}
+
+	var removed int64
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var entry db.TimetableEntry
+		if err := tx.First(&entry, id).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("timetable_entry_id = ? AND date >= ?", entry.ID, today()).
+			Where("NOT EXISTS (SELECT 1 FROM attendances WHERE attendances.session_id = class_sessions.id)").
+			Delete(&db.ClassSession{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected
+
+		if err := tx.Model(&db.ClassSession{}).Where("timetable_entry_id = ?", entry.ID).Update("timetable_entry_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entry).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Timetable entry not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Timetable entry deleted successfully", "removed_sessions": removed})
+}
+
+// findClash returns an entry that overlaps the new one in weekday, period
+// and validity and shares its section, faculty or room
+func (h *TimetableHandler) findClash(entry *db.TimetableEntry) (*db.TimetableEntry, string) {
+	overlapping := func() *gorm.DB {
+		return h.DB.Where("weekday = ? AND period = ? AND valid_from <= ? AND valid_to >= ?",
+			entry.Weekday, entry.Period, entry.ValidTo, entry.ValidFrom)
+	}
+
+	var clash db.TimetableEntry
+	if err := overlapping().Where("course_id = ? AND section = ?", entry.CourseID, entry.Section).First(&clash).Error; err == nil {
+		return &clash, "This section already has a class in this slot"
+	}
+	if err := overlapping().Where("faculty_id = ?", entry.FacultyID).First(&clash).Error; err == nil {
+		return &clash, "The faculty already teaches in this slot"
+	}
+	if entry.Room != "" {
+		if err := overlapping().Where("room = ?", entry.Room).First(&clash).Error; err == nil {
+			return &clash, fmt.Sprintf("Room %s is already taken in this slot", entry.Room)
+		}
+	}
+	return nil, ""
+}
+
+func today() time.Time {
+	return clock.Today()
+}
+
+// validSlot checks optional HH:MM start and end times
+func validSlot(start, end string) bool {
+	if start == "" && end == "" {
+		return true
+	}
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return false
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return false
+	}
+	return startTime.Before(endTime)
+}
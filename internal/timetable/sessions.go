@@ -0,0 +1,387 @@
+package timetable
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/internal/dto"
+	"attendance-workflow/internal/notifications"
+	"attendance-workflow/pkg/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GenerateSessions godoc
+// @Summary      Generate sessions
+// @Description  Create the expected class sessions of a term's timetable on every working day of the academic calendar. Sessions already scheduled are kept. Generated sessions without attendance that now fall on a non-working day, e.g. a newly declared holiday, are removed. Safe to run again. (admin only)
+// @Tags         timetable
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      dto.GenerateSessionsRequest  true  "Range"
+// @Success      200      {object}  object{message=string,created=int,existing=int,removed=int}
+// @Failure      400      {object}  object{error=string}
+// @Router       /timetable/generate [post]
+func (h *TimetableHandler) GenerateSessions(c *gin.Context) {
+	var req dto.GenerateSessionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var term db.AcademicTerm
+	if err := h.DB.First(&term, req.TermID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Term not found"})
+		return
+	}
+	from, to := term.StartDate, term.EndDate
+	if !req.From.IsZero() && req.From.After(from) {
+		from = req.From.Time
+	}
+	if !req.To.IsZero() && req.To.Before(to) {
+		to = req.To.Time
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	query := h.DB.Preload("Course").Where("term_id = ? AND valid_from <= ? AND valid_to >= ?", term.ID, to, from)
+	if req.CourseID != nil {
+		query = query.Where("course_id = ?", *req.CourseID)
+	}
+	if req.Section != "" {
+		query = query.Where("section = ?", req.Section)
+	}
+	var entries []db.TimetableEntry
+	query.Find(&entries)
+
+	// Classify the range once per department
+	days := make(map[string]map[string]bool)
+	for _, entry := range entries {
+		if _, ok := days[entry.Course.Dept]; ok {
+			continue
+		}
+		working := make(map[string]bool)
+		for _, day := range h.Calendar.Days(entry.Course.Dept, from, to) {
+			working[day.Date.Format("2006-01-02")] = day.Working
+		}
+		days[entry.Course.Dept] = working
+	}
+
+	var created, expected, removed int64
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			start, end := entry.ValidFrom, entry.ValidTo
+			if from.After(start) {
+				start = from
+			}
+			if to.Before(end) {
+				end = to
+			}
+
+			var sessions []db.ClassSession
+			var closed []time.Time
+			for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+				if int(day.Weekday()) != entry.Weekday {
+					continue
+				}
+				if !days[entry.Course.Dept][day.Format("2006-01-02")] {
+					closed = append(closed, day)
+					continue
+				}
+				entryID := entry.ID
+				sessions = append(sessions, db.ClassSession{
+					CourseID:         entry.CourseID,
+					Section:          entry.Section,
+					Date:             day,
+					Period:           entry.Period,
+					StartTime:        entry.StartTime,
+					EndTime:          entry.EndTime,
+					GraceMinutes:     entry.GraceMinutes,
+					FacultyID:        entry.FacultyID,
+					GeofenceID:       entry.GeofenceID,
+					Room:             entry.Room,
+					TimetableEntryID: &entryID,
+					Status:           db.SessionScheduled,
+				})
+			}
+
+			if len(sessions) > 0 {
+				result := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&sessions, 100)
+				if result.Error != nil {
+					return result.Error
+				}
+				created += result.RowsAffected
+				expected += int64(len(sessions))
+			}
+			if len(closed) > 0 {
+				result := tx.Where("timetable_entry_id = ? AND date IN ?", entry.ID, closed).
+					Where("NOT EXISTS (SELECT 1 FROM attendances WHERE attendances.session_id = class_sessions.id)").
+					Delete(&db.ClassSession{})
+				if result.Error != nil {
+					return result.Error
+				}
+				removed += result.RowsAffected
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Sessions generated",
+		"created":  created,
+		"existing": expected - created,
+		"removed":  removed,
+	})
+}
+
+// SubstituteSession godoc
+// @Summary      Substitute faculty
+// @Description  Hand a single session to another faculty member, who then takes its attendance. Substituting the original faculty back undoes the substitution.
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                           true  "Session ID"
+// @Param        request  body      dto.SubstituteSessionRequest  true  "Substitute"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string}
+// @Router       /sessions/{id}/substitute [put]
+func (h *TimetableHandler) SubstituteSession(c *gin.Context) {
+	var req dto.SubstituteSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, ok := h.ownSession(c)
+	if !ok {
+		return
+	}
+
+	var substitute db.User
+	if err := h.DB.Where("id = ? AND role = ?", req.FacultyID, db.RoleFaculty).First(&substitute).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Faculty not found"})
+		return
+	}
+	var busy int64
+	h.DB.Model(&db.ClassSession{}).
+		Where("faculty_id = ? AND date = ? AND period = ? AND status = ? AND id <> ?", substitute.ID, session.Date, session.Period, db.SessionScheduled, session.ID).
+		Count(&busy)
+	if busy > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The substitute already teaches in this period"})
+		return
+	}
+
+	original := session.FacultyID
+	if session.OriginalFacultyID != nil {
+		original = *session.OriginalFacultyID
+	}
+	session.FacultyID = substitute.ID
+	session.OriginalFacultyID = &original
+	session.Remarks = req.Remarks
+	if substitute.ID == original {
+		session.OriginalFacultyID = nil
+	}
+
+	if err := h.DB.Model(session).Select("faculty_id", "original_faculty_id", "remarks").Updates(session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to substitute faculty"})
+		return
+	}
+	session.Faculty = substitute
+	queueSessionNotification(c, session.ID, notifications.SessionSubstituted)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session handed to " + substitute.Name, "data": session})
+}
+
+// CancelSession godoc
+// @Summary      Cancel session
+// @Description  Cancel a single session that has no attendance yet. Cancelled sessions are not expected to have attendance and do not count towards eligibility projections.
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                       true  "Session ID"
+// @Param        request  body      dto.CancelSessionRequest  true  "Reason"
+// @Success      200      {object}  object{message=string,data=object}
+// @Failure      400      {object}  object{error=string}
+// @Failure      403      {object}  object{error=string}
+// @Failure      409      {object}  object{error=string}
+// @Router       /sessions/{id}/cancel [put]
+func (h *TimetableHandler) CancelSession(c *gin.Context) {
+	var req dto.CancelSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, ok := h.ownSession(c)
+	if !ok {
+		return
+	}
+
+	var marked int64
+	h.DB.Model(&db.Attendance{}).Where("session_id = ?", session.ID).Count(&marked)
+	if marked > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attendance has already been taken for this session"})
+		return
+	}
+
+	session.Status = db.SessionCancelled
+	session.Remarks = req.Reason
+	if session.CheckinOpen() {
+		now := time.Now()
+		session.CheckinClosedAt = &now
+	}
+	if err := h.DB.Model(session).Select("status", "remarks", "checkin_closed_at").Updates(session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel session"})
+		return
+	}
+	queueSessionNotification(c, session.ID, notifications.SessionCancelled)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session cancelled", "data": session})
+}
+
+// GetUnmarkedSessions godoc
+// @Summary      Unmarked sessions report
+// @Description  Scheduled sessions with no attendance at all, by default over the 30 days up to yesterday. Faculty only see their own sessions.
+// @Tags         sessions
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from        query     string  false  "Start date (YYYY-MM-DD)"
+// @Param        to          query     string  false  "End date (YYYY-MM-DD)"
+// @Param        course_id   query     int     false  "Course ID"
+// @Param        section     query     string  false  "Section"
+// @Param        faculty_id  query     int     false  "Faculty ID (admin only)"
+// @Success      200         {object}  object{data=array,total=int,by_faculty=array,from=string,to=string}
+// @Failure      400         {object}  object{error=string}
+// @Router       /sessions/unmarked [get]
+func (h *TimetableHandler) GetUnmarkedSessions(c *gin.Context) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("role")
+
+	to := today().AddDate(0, 0, -1)
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be in YYYY-MM-DD format"})
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -29)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be in YYYY-MM-DD format"})
+			return
+		}
+		from = parsed
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	query := h.DB.Preload("Course").Preload("Faculty").
+		Where("date BETWEEN ? AND ? AND status = ?", from, to, db.SessionScheduled).
+		Where("NOT EXISTS (SELECT 1 FROM attendances WHERE attendances.session_id = class_sessions.id)")
+	if role != string(db.RoleAdmin) {
+		query = query.Where("faculty_id = ?", uid)
+	} else if facultyID := c.Query("faculty_id"); facultyID != "" {
+		query = query.Where("faculty_id = ?", facultyID)
+	}
+	if courseID := c.Query("course_id"); courseID != "" {
+		query = query.Where("course_id = ?", courseID)
+	}
+	if section := c.Query("section"); section != "" {
+		query = query.Where("section = ?", section)
+	}
+
+	var sessions []db.ClassSession
+	query.Order("date, period").Find(&sessions)
+
+	type facultyCount struct {
+		FacultyID uint   `json:"faculty_id"`
+		Name      string `json:"name"`
+		Sessions  int    `json:"sessions"`
+	}
+	counts := make(map[uint]*facultyCount)
+	for _, session := range sessions {
+		if counts[session.FacultyID] == nil {
+			counts[session.FacultyID] = &facultyCount{FacultyID: session.FacultyID, Name: session.Faculty.Name}
+		}
+		counts[session.FacultyID].Sessions++
+	}
+	byFaculty := make([]facultyCount, 0, len(counts))
+	for _, count := range counts {
+		byFaculty = append(byFaculty, *count)
+	}
+	sort.Slice(byFaculty, func(i, j int) bool { return byFaculty[i].Sessions > byFaculty[j].Sessions })
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       sessions,
+		"total":      len(sessions),
+		"by_faculty": byFaculty,
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+	})
+}
+
+// ownSession loads the scheduled session in the path for its faculty, the
+// faculty it was substituted from, or an admin
+func (h *TimetableHandler) ownSession(c *gin.Context) (*db.ClassSession, bool) {
+	uid, ok := auth.CurrentUserID(c)
+	if !ok {
+		return nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return nil, false
+	}
+
+	var session db.ClassSession
+	if err := h.DB.Preload("Course").First(&session, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+
+	role, _ := c.Get("role")
+	owner := session.FacultyID == uid || (session.OriginalFacultyID != nil && *session.OriginalFacultyID == uid)
+	if !owner && role != string(db.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the session's faculty can change it"})
+		return nil, false
+	}
+	if session.Status == db.SessionCancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session has been cancelled"})
+		return nil, false
+	}
+	return &session, true
+}
+
+func queueSessionNotification(c *gin.Context, sessionID uint, event string) {
+	notifService := notifications.GetNotificationService()
+	if err := notifService.QueueSessionNotification(c.Request.Context(), notifications.SessionPayload{
+		SessionID: sessionID,
+		Event:     event,
+	}); err != nil {
+		log.Printf("Failed to queue session notification: %v", err)
+	}
+}
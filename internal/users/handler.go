@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strconv"
 
+	"attendance-workflow/internal/auth"
+	"attendance-workflow/pkg/clock"
 	"attendance-workflow/pkg/db"
 
 	"github.com/gin-gonic/gin"
@@ -69,13 +71,13 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 
 // GetUserByID godoc
 // @Summary      Get user by ID
-// @Description  Get user details by user ID
+// @Description  Get user details by user ID, with the zone their times are shown in
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        id      path      int     true  "User ID"
-// @Success      200     {object}  object{data=object}
+// @Success      200     {object}  object{data=object,timezone=string}
 // @Failure      404     {object}  object{error=string}
 // @Failure      401     {object}  object{error=string}
 // @Router       /users/{id} [get]
@@ -92,12 +94,12 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": user})
+	c.JSON(http.StatusOK, gin.H{"data": clock.In(user, auth.Zone(c)), "timezone": auth.DisplayZone(&user)})
 }
 
 // UpdateUser godoc
 // @Summary      Update user
-// @Description  Update user information
+// @Description  Update user information. Users changing their own timezone get a new token carrying it.
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -122,16 +124,32 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	previousZone := user.Timezone
 	if err := c.ShouldBindJSON(&user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if _, err := clock.ParseZone(user.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown timezone, use an IANA name such as Asia/Kolkata"})
+		return
+	}
 
 	if err := h.DB.Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully", "data": user})
+
+	response := gin.H{"message": "User updated successfully", "data": clock.In(user, auth.Zone(c)), "timezone": auth.DisplayZone(&user)}
+	// Tokens carry the display zone, so users changing their own get a new one
+	if uid, _ := c.Get("user_id"); uid == user.ID && user.Timezone != previousZone {
+		token, err := auth.GenerateToken(user.ID, user.Email, string(user.Role), user.Timezone)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		response["token"] = token
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteUser godoc
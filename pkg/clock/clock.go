@@ -0,0 +1,76 @@
+// Package clock reads dates and clock times in the institution's timezone
+// (INSTITUTION_TIMEZONE), so attendance lands on the same day wherever the
+// server runs.
+//
+// Date-only values, such as attendance and session dates, are kept as
+// midnight UTC of their calendar date. Use DateOf to turn an instant into
+// such a date and Date to normalize a value that already is one.
+package clock
+
+import (
+	"time"
+
+	"attendance-workflow/pkg/config"
+)
+
+// Location is the institution's timezone
+func Location() *time.Location {
+	if loc := config.AppConfig.Server.Timezone; loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// Now is the current time in the institution's timezone
+func Now() time.Time {
+	return time.Now().In(Location())
+}
+
+// Today is the institution's current date
+func Today() time.Time {
+	return DateOf(time.Now())
+}
+
+// DateOf is the institution's date at the instant t
+func DateOf(t time.Time) time.Time {
+	return Date(t.In(Location()))
+}
+
+// Date keeps the calendar date of t as read in t's own location
+func Date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// At is the instant of the wall clock time hour:min on date in the
+// institution's timezone. A time skipped by a DST change is moved forward
+// by the length of the gap; a repeated time is its first occurrence.
+func At(date time.Time, hour, min int) time.Time {
+	t := time.Date(date.Year(), date.Month(), date.Day(), hour, min, 0, 0, Location())
+	if t.Hour() != hour || t.Minute() != min {
+		// The time falls in a gap; read it with the offset before the gap
+		_, offset := t.Zone()
+		wall := time.Date(date.Year(), date.Month(), date.Day(), hour, min, 0, 0, time.UTC)
+		t = wall.Add(-time.Duration(offset) * time.Second).In(Location())
+	}
+	return t
+}
+
+// StartOfDay is the first instant of date in the institution's timezone
+func StartOfDay(date time.Time) time.Time {
+	return At(date, 0, 0)
+}
+
+// EndOfDay is the first instant after date in the institution's timezone,
+// which is not always 24 hours after its start
+func EndOfDay(date time.Time) time.Time {
+	return At(date.AddDate(0, 0, 1), 0, 0)
+}
+
+// ParseZone resolves an IANA zone name such as "Asia/Kolkata". An empty
+// name is the institution's timezone.
+func ParseZone(name string) (*time.Location, error) {
+	if name == "" {
+		return Location(), nil
+	}
+	return time.LoadLocation(name)
+}
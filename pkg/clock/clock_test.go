@@ -0,0 +1,139 @@
+package clock
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"attendance-workflow/pkg/config"
+)
+
+// useZone makes name the institution's timezone for the rest of the test
+func useZone(t *testing.T, name string) {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	previous := config.AppConfig.Server.Timezone
+	config.AppConfig.Server.Timezone = loc
+	t.Cleanup(func() { config.AppConfig.Server.Timezone = previous })
+}
+
+func utc(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func day(value string) time.Time {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestDateOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		zone    string
+		instant string
+		want    string
+	}{
+		{"UTC+5:30 just before midnight", "Asia/Kolkata", "2026-03-10T18:29:59Z", "2026-03-10"},
+		{"UTC+5:30 at midnight", "Asia/Kolkata", "2026-03-10T18:30:00Z", "2026-03-11"},
+		{"UTC+14 just before midnight", "Pacific/Kiritimati", "2026-01-09T09:59:59Z", "2026-01-09"},
+		{"UTC+14 at midnight", "Pacific/Kiritimati", "2026-01-09T10:00:00Z", "2026-01-10"},
+		{"UTC-5 just before midnight", "America/New_York", "2026-01-10T04:59:59Z", "2026-01-09"},
+		{"UTC-5 at midnight", "America/New_York", "2026-01-10T05:00:00Z", "2026-01-10"},
+		{"spring forward, before the gap", "America/New_York", "2026-03-08T06:59:59Z", "2026-03-08"},
+		{"spring forward, after the gap", "America/New_York", "2026-03-08T07:00:00Z", "2026-03-08"},
+		{"fall back, first 01:30", "America/New_York", "2026-11-01T05:30:00Z", "2026-11-01"},
+		{"fall back, second 01:30", "America/New_York", "2026-11-01T06:30:00Z", "2026-11-01"},
+		{"fall back, midnight after", "America/New_York", "2026-11-02T04:59:59Z", "2026-11-01"},
+		{"fall back, next day", "America/New_York", "2026-11-02T05:00:00Z", "2026-11-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useZone(t, tt.zone)
+			got := DateOf(utc(tt.instant))
+			if !got.Equal(day(tt.want)) || got.Location() != time.UTC {
+				t.Errorf("DateOf(%s) = %v, want %s UTC", tt.instant, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		zone      string
+		date      string
+		hour, min int
+		want      string
+	}{
+		{"UTC+5:30 morning", "Asia/Kolkata", "2026-03-10", 9, 0, "2026-03-10T03:30:00Z"},
+		{"UTC+5:30 midnight", "Asia/Kolkata", "2026-03-11", 0, 0, "2026-03-10T18:30:00Z"},
+		{"UTC+5:30 last minute", "Asia/Kolkata", "2026-03-10", 23, 59, "2026-03-10T18:29:00Z"},
+		{"UTC+14 midnight", "Pacific/Kiritimati", "2026-01-10", 0, 0, "2026-01-09T10:00:00Z"},
+		{"spring forward, before the gap", "America/New_York", "2026-03-08", 1, 59, "2026-03-08T06:59:00Z"},
+		{"spring forward, inside the gap", "America/New_York", "2026-03-08", 2, 30, "2026-03-08T07:30:00Z"},
+		{"spring forward, after the gap", "America/New_York", "2026-03-08", 3, 0, "2026-03-08T07:00:00Z"},
+		{"fall back, repeated time is the first", "America/New_York", "2026-11-01", 1, 30, "2026-11-01T05:30:00Z"},
+		{"fall back, after the overlap", "America/New_York", "2026-11-01", 2, 0, "2026-11-01T07:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useZone(t, tt.zone)
+			got := At(day(tt.date), tt.hour, tt.min)
+			if !got.Equal(utc(tt.want)) {
+				t.Errorf("At(%s, %02d:%02d) = %v, want %s", tt.date, tt.hour, tt.min, got.UTC(), tt.want)
+			}
+			if got.Location() != Location() {
+				t.Errorf("At(%s, %02d:%02d) is in %v, want %v", tt.date, tt.hour, tt.min, got.Location(), Location())
+			}
+		})
+	}
+}
+
+func TestStartAndEndOfDay(t *testing.T) {
+	tests := []struct {
+		name  string
+		zone  string
+		date  string
+		start string
+		end   string
+		hours float64
+	}{
+		{"UTC+5:30", "Asia/Kolkata", "2026-03-10", "2026-03-09T18:30:00Z", "2026-03-10T18:30:00Z", 24},
+		{"UTC+14", "Pacific/Kiritimati", "2026-01-10", "2026-01-09T10:00:00Z", "2026-01-10T10:00:00Z", 24},
+		{"spring forward day is 23 hours", "America/New_York", "2026-03-08", "2026-03-08T05:00:00Z", "2026-03-09T04:00:00Z", 23},
+		{"fall back day is 25 hours", "America/New_York", "2026-11-01", "2026-11-01T04:00:00Z", "2026-11-02T05:00:00Z", 25},
+		// Midnight itself is skipped on the spring-forward day in Santiago
+		{"spring forward at midnight", "America/Santiago", "2026-09-06", "2026-09-06T04:00:00Z", "2026-09-07T03:00:00Z", 23},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useZone(t, tt.zone)
+			start, end := StartOfDay(day(tt.date)), EndOfDay(day(tt.date))
+			if !start.Equal(utc(tt.start)) {
+				t.Errorf("StartOfDay(%s) = %v, want %s", tt.date, start.UTC(), tt.start)
+			}
+			if !end.Equal(utc(tt.end)) {
+				t.Errorf("EndOfDay(%s) = %v, want %s", tt.date, end.UTC(), tt.end)
+			}
+			if hours := end.Sub(start).Hours(); hours != tt.hours {
+				t.Errorf("%s lasts %v hours, want %v", tt.date, hours, tt.hours)
+			}
+			if got := DateOf(end.Add(-time.Nanosecond)); !got.Equal(day(tt.date)) {
+				t.Errorf("last instant before EndOfDay(%s) falls on %v", tt.date, got)
+			}
+			if got := DateOf(end); got.Equal(day(tt.date)) {
+				t.Errorf("EndOfDay(%s) still falls on %v", tt.date, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package clock
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+	// instantTypes caches whether a type can hold an instant In converts
+	instantTypes sync.Map
+)
+
+// In returns a copy of v with every instant in it read in loc, for showing
+// to a user. Calendar dates, which are fields tagged gorm:"type:date", and
+// values with their own JSON encoding are left as they are.
+func In(v interface{}, loc *time.Location) interface{} {
+	if v == nil || loc == nil {
+		return v
+	}
+	return inZone(reflect.ValueOf(v), loc).Interface()
+}
+
+func inZone(v reflect.Value, loc *time.Location) reflect.Value {
+	if !hasInstants(v.Type(), nil) {
+		return v
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return reflect.ValueOf(v.Interface().(time.Time).In(loc))
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.IsExported() && !isDate(field) {
+				out.Field(i).Set(inZone(v.Field(i), loc))
+			}
+		}
+		return out
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		if v.Kind() == reflect.Ptr {
+			out := reflect.New(v.Type().Elem())
+			out.Elem().Set(inZone(v.Elem(), loc))
+			return out
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(inZone(v.Elem(), loc))
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(inZone(v.Index(i), loc))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(inZone(v.Index(i), loc))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), inZone(iter.Value(), loc))
+		}
+		return out
+	}
+	return v
+}
+
+// hasInstants reports whether values of t can hold a time.Time that In
+// converts. visiting guards against types that refer to themselves.
+func hasInstants(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if cached, ok := instantTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	if visiting[t] {
+		return false
+	}
+	// Only the outermost answer is cached, the others may be cut short by
+	// a cycle
+	outermost := visiting == nil
+	if outermost {
+		visiting = make(map[reflect.Type]bool)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	found := false
+	switch {
+	case t == timeType:
+		found = true
+	case t.Kind() == reflect.Ptr:
+		found = hasInstants(t.Elem(), visiting)
+	case t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+	case t.Kind() == reflect.Interface:
+		found = true
+	case t.Kind() == reflect.Struct:
+		for i := 0; i < t.NumField() && !found; i++ {
+			field := t.Field(i)
+			found = field.IsExported() && !isDate(field) && hasInstants(field.Type, visiting)
+		}
+	case t.Kind() == reflect.Slice, t.Kind() == reflect.Array, t.Kind() == reflect.Map:
+		found = hasInstants(t.Elem(), visiting)
+	}
+	if outermost {
+		instantTypes.Store(t, found)
+	}
+	return found
+}
+
+func isDate(field reflect.StructField) bool {
+	for _, setting := range strings.Split(field.Tag.Get("gorm"), ";") {
+		if strings.EqualFold(strings.TrimSpace(setting), "type:date") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package clock
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type displayDate struct{ time.Time }
+
+func (d displayDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format("2006-01-02"))
+}
+
+type displayNode struct {
+	ID        uint
+	Date      time.Time  `gorm:"type:date"`
+	CreatedAt time.Time  `gorm:"index"`
+	SeenAt    *time.Time `gorm:"index"`
+	Due       displayDate
+	Parent    *displayNode
+	Children  []displayNode
+	hidden    time.Time
+}
+
+func TestIn(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := day("2026-03-10")
+	at := utc("2026-03-10T03:30:00Z")
+	seen := at.Add(time.Hour)
+	node := displayNode{
+		ID:        1,
+		Date:      date,
+		CreatedAt: at,
+		SeenAt:    &seen,
+		Due:       displayDate{date},
+		Parent:    &displayNode{ID: 2, CreatedAt: at},
+		Children:  []displayNode{{ID: 3, CreatedAt: at}},
+		hidden:    at,
+	}
+
+	check := func(name string, got displayNode) {
+		t.Helper()
+		if got.CreatedAt.Location() != loc || !got.CreatedAt.Equal(at) {
+			t.Errorf("%s: CreatedAt = %v, want %v in %v", name, got.CreatedAt, at, loc)
+		}
+		if got.SeenAt == &seen || got.SeenAt.Location() != loc || !got.SeenAt.Equal(seen) {
+			t.Errorf("%s: SeenAt = %v, want a copy of %v in %v", name, got.SeenAt, seen, loc)
+		}
+		if got.Date != date || got.Due.Time != date {
+			t.Errorf("%s: dates changed to %v and %v", name, got.Date, got.Due.Time)
+		}
+		if got.Parent.CreatedAt.Location() != loc || got.Children[0].CreatedAt.Location() != loc {
+			t.Errorf("%s: nested instants were not converted", name)
+		}
+		if got.hidden != at {
+			t.Errorf("%s: unexported field changed to %v", name, got.hidden)
+		}
+	}
+
+	check("struct", In(node, loc).(displayNode))
+	check("pointer", *In(&node, loc).(*displayNode))
+	check("slice", In([]displayNode{node}, loc).([]displayNode)[0])
+	check("map", In(map[string]interface{}{"data": node}, loc).(map[string]interface{})["data"].(displayNode))
+
+	// The value handed in is left as it was
+	if node.CreatedAt.Location() != time.UTC || node.SeenAt != &seen || seen.Location() != time.UTC || node.Parent.CreatedAt.Location() != time.UTC {
+		t.Error("In changed its argument")
+	}
+
+	if got := In(at, loc).(time.Time); got.Location() != loc {
+		t.Errorf("In(%v) is in %v, want %v", at, got.Location(), loc)
+	}
+	if got := In(map[string]interface{}{"count": 3, "ids": []uint{1}}, loc).(map[string]interface{}); got["count"] != 3 {
+		t.Errorf("In changed values without instants: %v", got)
+	}
+	if In(nil, loc) != nil {
+		t.Error("In(nil) is not nil")
+	}
+}
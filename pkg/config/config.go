@@ -3,15 +3,20 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	JWT      JWTConfig
-	Redis    RedisConfig
+	Database   DatabaseConfig
+	Server     ServerConfig
+	JWT        JWTConfig
+	Redis      RedisConfig
+	Storage    StorageConfig
+	Attendance AttendanceConfig
 }
 
 type RedisConfig struct {
@@ -32,6 +37,27 @@ type DatabaseConfig struct {
 type ServerConfig struct {
 	Port    string
 	GinMode string
+	// Timezone is the institution's zone. Attendance days, "today" and clock
+	// times such as session slots are all read in it.
+	Timezone *time.Location
+}
+
+type StorageConfig struct {
+	Backend        string // local or s3
+	LocalPath      string
+	MaxUploadBytes int64
+	URLExpiry      time.Duration
+	SigningSecret  string
+	S3             S3Config
+}
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
 }
 
 type JWTConfig struct {
@@ -39,6 +65,52 @@ type JWTConfig struct {
 	Expiry string
 }
 
+type AttendanceConfig struct {
+	// StatusWeights is the credit each attendance status earns towards the
+	// attendance percentage, from 0 (absent) to 1 (present)
+	StatusWeights map[string]float64
+	// CheckinTokenTTL is how long a rotating QR check-in token stays valid
+	CheckinTokenTTL time.Duration
+	// CheckinWindow is how long a session accepts check-ins after opening
+	CheckinWindow time.Duration
+	// GeofenceRadius is the default tolerance in meters around a geofence
+	GeofenceRadius float64
+	// MaxStudentsPerDevice is how many students may check in to one session
+	// from the same device before those check-ins are flagged
+	MaxStudentsPerDevice int64
+	// PunchDedupWindow suppresses repeat punches of a card at a terminal
+	PunchDedupWindow time.Duration
+	// LateGrace is the default time after a session starts before an arrival
+	// is late; sessions can set their own
+	LateGrace time.Duration
+	// LatesPerAbsence counts every so many lates as one absence, 0 to disable
+	LatesPerAbsence int64
+	// PunchDailyLateAfter is the time of day (HH:MM) after which the first
+	// punch of the day at a daily terminal counts as late
+	PunchDailyLateAfter string
+	// LockAfter is how long after the end of its day attendance can still be
+	// changed directly, 0 to disable
+	LockAfter time.Duration
+	// LockAtTermEnd locks a term's attendance once the term is over
+	LockAtTermEnd bool
+	// EligibilityMinPercent is the default attendance needed to sit exams
+	EligibilityMinPercent float64
+	// EligibilityWarningPercent is the default line below which students
+	// are warned that they are close to the minimum
+	EligibilityWarningPercent float64
+	// CondonationApprovalPath lists the roles that approve a condonation,
+	// in order
+	CondonationApprovalPath []string
+	// LeaveApprovalPath is the approval path of leaves no workflow matches
+	LeaveApprovalPath []string
+	// LeaveExpireAfter is how long after both its end date and its last
+	// change an undecided leave expires, 0 to disable
+	LeaveExpireAfter time.Duration
+	// RollCallTime is the time of day (HH:MM) of the hostel night roll call,
+	// the moment leaves and gate passes are checked against
+	RollCallTime string
+}
+
 var AppConfig Config
 
 func Load() {
@@ -54,8 +126,9 @@ func Load() {
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 		},
 		Server: ServerConfig{
-			Port:    getEnv("PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Port:     getEnv("PORT", "8080"),
+			GinMode:  getEnv("GIN_MODE", "debug"),
+			Timezone: getEnvLocation("INSTITUTION_TIMEZONE", time.UTC),
 		},
 		JWT: JWTConfig{
 			Secret: getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
@@ -66,6 +139,48 @@ func Load() {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       0,
 		},
+		Storage: StorageConfig{
+			Backend:        getEnv("STORAGE_BACKEND", "local"),
+			LocalPath:      getEnv("STORAGE_LOCAL_PATH", "./uploads"),
+			MaxUploadBytes: getEnvInt64("STORAGE_MAX_UPLOAD_BYTES", 5<<20),
+			URLExpiry:      getEnvDuration("STORAGE_URL_EXPIRY", 15*time.Minute),
+			SigningSecret:  getEnv("STORAGE_SIGNING_SECRET", getEnv("JWT_SECRET", "your-secret-key-change-in-production")),
+			S3: S3Config{
+				Endpoint:  getEnv("S3_ENDPOINT", "localhost:9000"),
+				Region:    getEnv("S3_REGION", "us-east-1"),
+				Bucket:    getEnv("S3_BUCKET", "attendance"),
+				AccessKey: getEnv("S3_ACCESS_KEY", ""),
+				SecretKey: getEnv("S3_SECRET_KEY", ""),
+				UseSSL:    getEnv("S3_USE_SSL", "false") == "true",
+			},
+		},
+		Attendance: AttendanceConfig{
+			StatusWeights: getEnvWeights("ATTENDANCE_STATUS_WEIGHTS", map[string]float64{
+				"present":  1,
+				"late":     1,
+				"on_duty":  1,
+				"half_day": 0.5,
+				"excused":  0,
+				"on_leave": 0,
+				"absent":   0,
+			}),
+			CheckinTokenTTL:           getEnvDuration("CHECKIN_TOKEN_TTL", 20*time.Second),
+			CheckinWindow:             getEnvDuration("CHECKIN_WINDOW", 15*time.Minute),
+			GeofenceRadius:            float64(getEnvInt64("GEOFENCE_RADIUS_METERS", 50)),
+			MaxStudentsPerDevice:      getEnvInt64("CHECKIN_MAX_STUDENTS_PER_DEVICE", 1),
+			PunchDedupWindow:          getEnvDuration("PUNCH_DEDUP_WINDOW", 2*time.Minute),
+			LateGrace:                 getEnvDuration("LATE_GRACE_PERIOD", getEnvDuration("PUNCH_LATE_AFTER", 10*time.Minute)),
+			LatesPerAbsence:           getEnvInt64("LATES_PER_ABSENCE", 0),
+			PunchDailyLateAfter:       getEnv("PUNCH_DAILY_LATE_AFTER", "09:30"),
+			LockAfter:                 getEnvDuration("ATTENDANCE_LOCK_AFTER", 48*time.Hour),
+			LockAtTermEnd:             getEnv("ATTENDANCE_LOCK_AT_TERM_END", "true") == "true",
+			EligibilityMinPercent:     getEnvFloat("ELIGIBILITY_MIN_PERCENT", 75),
+			EligibilityWarningPercent: getEnvFloat("ELIGIBILITY_WARNING_PERCENT", 80),
+			CondonationApprovalPath:   getEnvApprovalPath("CONDONATION_APPROVAL_PATH", "faculty,hod", condonationApprovers, false),
+			LeaveApprovalPath:         getEnvApprovalPath("LEAVE_APPROVAL_PATH", "faculty", leaveApprovers, true),
+			LeaveExpireAfter:          getEnvDuration("LEAVE_EXPIRE_AFTER", 30*24*time.Hour),
+			RollCallTime:              getEnv("HOSTEL_ROLL_CALL_TIME", "21:30"),
+		},
 	}
 
 	log.Println("Configuration loaded successfully")
@@ -77,3 +192,115 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+		log.Printf("Invalid value for %s, using default", key)
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+		log.Printf("Invalid value for %s, using default", key)
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+		log.Printf("Invalid value for %s, using default", key)
+	}
+	return defaultValue
+}
+
+func getEnvLocation(key string, defaultValue *time.Location) *time.Location {
+	if value := os.Getenv(key); value != "" {
+		if loc, err := time.LoadLocation(value); err == nil {
+			return loc
+		}
+		log.Printf("Invalid value for %s, using default", key)
+	}
+	return defaultValue
+}
+
+// leaveApprovers and condonationApprovers are the roles each approval path
+// may name
+var (
+	leaveApprovers       = map[string]bool{"faculty": true, "warden": true, "hod": true, "admin": true}
+	condonationApprovers = map[string]bool{"faculty": true, "hod": true, "admin": true}
+)
+
+// getEnvApprovalPath reads an approval path such as "faculty,warden+hod" as
+// its comma-separated stages; parallel allows several roles in a stage. A
+// path naming a role not in roles, or a role twice in one stage, is replaced
+// by the default so no stage waits for a role nobody has.
+func getEnvApprovalPath(key, defaultValue string, roles map[string]bool, parallel bool) []string {
+	value := getEnv(key, defaultValue)
+	var stages []string
+	for _, stage := range strings.Split(value, ",") {
+		seen := make(map[string]bool)
+		var stageRoles []string
+		for _, role := range strings.Split(stage, "+") {
+			role = strings.TrimSpace(role)
+			if role == "" {
+				continue
+			}
+			if !roles[role] {
+				log.Printf("Invalid role %q in %s, using default", role, key)
+				return strings.Split(defaultValue, ",")
+			}
+			if seen[role] {
+				log.Printf("Role %q appears twice in one stage of %s, using default", role, key)
+				return strings.Split(defaultValue, ",")
+			}
+			seen[role] = true
+			stageRoles = append(stageRoles, role)
+		}
+		if len(stageRoles) > 1 && !parallel {
+			log.Printf("Stage %q of %s has more than one role, using default", stage, key)
+			return strings.Split(defaultValue, ",")
+		}
+		if len(stageRoles) > 0 {
+			stages = append(stages, strings.Join(stageRoles, "+"))
+		}
+	}
+	if len(stages) == 0 {
+		log.Printf("Invalid value for %s, using default", key)
+		return strings.Split(defaultValue, ",")
+	}
+	return stages
+}
+
+// getEnvWeights reads overrides in the form "late=0.75,on_leave=1"
+func getEnvWeights(key string, defaults map[string]float64) map[string]float64 {
+	weights := make(map[string]float64, len(defaults))
+	for status, weight := range defaults {
+		weights[status] = weight
+	}
+
+	value := os.Getenv(key)
+	if value == "" {
+		return weights
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		status, raw, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		weight, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if !ok || err != nil || weight < 0 || weight > 1 {
+			log.Printf("Invalid weight %q in %s, ignoring", pair, key)
+			continue
+		}
+		weights[strings.TrimSpace(status)] = weight
+	}
+	return weights
+}
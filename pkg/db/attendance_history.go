@@ -0,0 +1,125 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type HistoryAction string
+
+const (
+	HistoryCreated HistoryAction = "created"
+	HistoryUpdated HistoryAction = "updated"
+	HistoryDeleted HistoryAction = "deleted"
+)
+
+// ErrHistoryImmutable is returned when code tries to change history
+var ErrHistoryImmutable = errors.New("attendance history is append-only")
+
+// AttendanceHistory is an append-only log of changes to attendance records.
+// Entries are written by the Attendance hooks below, so every create,
+// status change and delete made through a loaded record is captured.
+type AttendanceHistory struct {
+	ID             uint             `gorm:"primaryKey" json:"id"`
+	AttendanceID   uint             `gorm:"not null;index" json:"attendance_id"`
+	StudentID      uint             `gorm:"not null;index" json:"student_id"`
+	SessionID      *uint            `json:"session_id,omitempty"`
+	Date           time.Time        `gorm:"type:date;not null" json:"date"`
+	Action         HistoryAction    `gorm:"type:varchar(20);not null" json:"action"`
+	PreviousStatus AttendanceStatus `gorm:"type:varchar(20)" json:"previous_status,omitempty"`
+	NewStatus      AttendanceStatus `gorm:"type:varchar(20)" json:"new_status,omitempty"`
+	Source         AttendanceSource `gorm:"type:varchar(20)" json:"source"`
+	ActorID        *uint            `gorm:"index" json:"actor_id,omitempty"` // nil for system imports
+	Actor          *User            `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Reason         string           `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt      time.Time        `gorm:"index" json:"created_at"`
+}
+
+func (AttendanceHistory) TableName() string {
+	return "attendance_history"
+}
+
+func (h *AttendanceHistory) BeforeUpdate(tx *gorm.DB) error {
+	return ErrHistoryImmutable
+}
+
+func (h *AttendanceHistory) BeforeDelete(tx *gorm.DB) error {
+	return ErrHistoryImmutable
+}
+
+// Audit sets who is changing the record and why. Without it the history
+// entry is attributed to MarkedBy with no reason.
+func (a *Attendance) Audit(actorID uint, reason string) {
+	a.auditActor = actorID
+	a.auditReason = reason
+}
+
+func (a *Attendance) AfterFind(tx *gorm.DB) error {
+	a.loadedStatus = a.Status
+	return nil
+}
+
+func (a *Attendance) AfterCreate(tx *gorm.DB) error {
+	if a.ID == 0 {
+		// Skipped by an ON CONFLICT DO NOTHING insert
+		return nil
+	}
+	a.loadedStatus = a.Status
+	return a.logHistory(tx, HistoryCreated, "", a.Status)
+}
+
+// BeforeUpdate bumps Version on every update, whether it saves a loaded
+// record or applies a column map to a query
+func (a *Attendance) BeforeUpdate(tx *gorm.DB) error {
+	if _, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		tx.Statement.SetColumn("version", gorm.Expr("version + 1"))
+	} else {
+		tx.Statement.SetColumn("version", a.Version+1)
+	}
+	return nil
+}
+
+// AfterUpdate logs status changes of loaded records. Column-map updates of
+// queries carry no previous status and must not be used to change status.
+func (a *Attendance) AfterUpdate(tx *gorm.DB) error {
+	if _, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		return nil
+	}
+	if a.ID == 0 || a.Status == a.loadedStatus {
+		return nil
+	}
+	previous := a.loadedStatus
+	a.loadedStatus = a.Status
+	return a.logHistory(tx, HistoryUpdated, previous, a.Status)
+}
+
+func (a *Attendance) AfterDelete(tx *gorm.DB) error {
+	if a.ID == 0 {
+		return nil
+	}
+	return a.logHistory(tx, HistoryDeleted, a.Status, "")
+}
+
+func (a *Attendance) logHistory(tx *gorm.DB, action HistoryAction, previous, next AttendanceStatus) error {
+	actor := a.auditActor
+	if actor == 0 {
+		actor = a.MarkedBy
+	}
+	entry := AttendanceHistory{
+		AttendanceID:   a.ID,
+		StudentID:      a.StudentID,
+		SessionID:      a.SessionID,
+		Date:           a.Date,
+		Action:         action,
+		PreviousStatus: previous,
+		NewStatus:      next,
+		Source:         a.Source,
+		Reason:         a.auditReason,
+	}
+	if actor != 0 {
+		entry.ActorID = &actor
+	}
+	return tx.Session(&gorm.Session{NewDB: true}).Create(&entry).Error
+}
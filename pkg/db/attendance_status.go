@@ -0,0 +1,129 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"attendance-workflow/pkg/config"
+)
+
+type AttendanceStatus string
+
+const (
+	AttendancePresent AttendanceStatus = "present"
+	AttendanceAbsent  AttendanceStatus = "absent"
+	AttendanceLate    AttendanceStatus = "late"
+	AttendanceExcused AttendanceStatus = "excused"
+	AttendanceOnLeave AttendanceStatus = "on_leave"
+	AttendanceOnDuty  AttendanceStatus = "on_duty"
+	AttendanceHalfDay AttendanceStatus = "half_day"
+)
+
+var AttendanceStatuses = []AttendanceStatus{
+	AttendancePresent,
+	AttendanceAbsent,
+	AttendanceLate,
+	AttendanceExcused,
+	AttendanceOnLeave,
+	AttendanceOnDuty,
+	AttendanceHalfDay,
+}
+
+func (s AttendanceStatus) Valid() bool {
+	for _, status := range AttendanceStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Weight is the credit the status earns towards the attendance percentage,
+// as configured in ATTENDANCE_STATUS_WEIGHTS
+func (s AttendanceStatus) Weight() float64 {
+	return config.AppConfig.Attendance.StatusWeights[string(s)]
+}
+
+// Attended reports whether the student was physically in attendance
+func (s AttendanceStatus) Attended() bool {
+	switch s {
+	case AttendancePresent, AttendanceLate, AttendanceOnDuty, AttendanceHalfDay:
+		return true
+	}
+	return false
+}
+
+// StatusFromPresent maps the legacy present flag to a status
+func StatusFromPresent(present bool) AttendanceStatus {
+	if present {
+		return AttendancePresent
+	}
+	return AttendanceAbsent
+}
+
+// SetStatus updates the status and keeps the legacy Present flag in sync
+func (a *Attendance) SetStatus(status AttendanceStatus) {
+	a.Status = status
+	a.Present = status.Attended()
+}
+
+// AttendanceWeightSQL returns a SQL expression evaluating to the configured
+// weight of the status stored in column, for use in aggregate queries
+func AttendanceWeightSQL(column string) string {
+	statuses := make([]string, 0, len(config.AppConfig.Attendance.StatusWeights))
+	for status := range config.AppConfig.Attendance.StatusWeights {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "(CASE %s", column)
+	for _, status := range statuses {
+		if !AttendanceStatus(status).Valid() {
+			continue
+		}
+		fmt.Fprintf(&b, " WHEN '%s' THEN %g", status, config.AppConfig.Attendance.StatusWeights[status])
+	}
+	b.WriteString(" ELSE 0 END)")
+	return b.String()
+}
+
+// migrateAttendanceStatus backfills the status of rows recorded before
+// statuses existed, which only carried the present flag
+func migrateAttendanceStatus() error {
+	return DB.Model(&Attendance{}).
+		Where("present = ? AND status = ?", true, AttendanceAbsent).
+		Update("status", AttendancePresent).Error
+}
+
+// migrateDailyAttendanceIndex makes daily records (no session) unique per
+// student and date. Duplicates left by concurrent submissions are not
+// removed: they are listed and the migration fails until an admin keeps one
+// record of each day.
+func migrateDailyAttendanceIndex() error {
+	var duplicates []struct {
+		StudentID uint
+		Date      time.Time
+		IDs       string
+	}
+	if err := DB.Model(&Attendance{}).
+		Select("student_id, date, string_agg(id::text, ', ' ORDER BY id) AS ids").
+		Where("session_id IS NULL").
+		Group("student_id, date").
+		Having("COUNT(*) > 1").
+		Order("student_id, date").
+		Scan(&duplicates).Error; err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		for _, d := range duplicates {
+			log.Printf("Duplicate daily attendance for student %d on %s: records %s", d.StudentID, d.Date.Format("2006-01-02"), d.IDs)
+		}
+		return fmt.Errorf("%d student days have more than one daily attendance record; keep one of each and restart", len(duplicates))
+	}
+	return DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_attendance_daily
+		ON attendances (student_id, date) WHERE session_id IS NULL`).Error
+}
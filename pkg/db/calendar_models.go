@@ -0,0 +1,61 @@
+package db
+
+import (
+	"time"
+)
+
+// AcademicTerm is a teaching period, days outside every term are vacation
+type AcademicTerm struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	StartDate time.Time `gorm:"not null;type:date" json:"start_date"`
+	EndDate   time.Time `gorm:"not null;type:date" json:"end_date"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Holiday closes the institution, or a single department when Dept is set
+type Holiday struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Date      time.Time `gorm:"not null;type:date;uniqueIndex:idx_holiday_date_dept" json:"date"`
+	Dept      string    `gorm:"not null;default:'';uniqueIndex:idx_holiday_date_dept" json:"dept,omitempty"`
+	Name      string    `gorm:"not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WeeklyOff is a recurring non-working weekday (0 = Sunday). Departments
+// with their own weekly offs do not inherit the institution-wide ones.
+type WeeklyOff struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Dept      string    `gorm:"not null;default:'';uniqueIndex:idx_weekly_off_dept_weekday" json:"dept,omitempty"`
+	Weekday   int       `gorm:"not null;uniqueIndex:idx_weekly_off_dept_weekday" json:"weekday"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SpecialWorkingDay turns a weekly off (e.g. a Saturday) into a working day
+type SpecialWorkingDay struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Date      time.Time `gorm:"not null;type:date;uniqueIndex:idx_special_day_date_dept" json:"date"`
+	Dept      string    `gorm:"not null;default:'';uniqueIndex:idx_special_day_date_dept" json:"dept,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CalendarEventKind string
+
+const (
+	EventExam      CalendarEventKind = "exam"
+	EventMandatory CalendarEventKind = "mandatory" // convocation, orientation, lab assessments...
+)
+
+// CalendarEvent is an exam or mandatory event students should not miss.
+// Unlike holidays it does not change working days; leaves covering one are
+// flagged for the student and the approvers.
+type CalendarEvent struct {
+	ID        uint              `gorm:"primaryKey" json:"id"`
+	Name      string            `gorm:"not null" json:"name"`
+	Kind      CalendarEventKind `gorm:"type:varchar(20);not null" json:"kind"`
+	StartDate time.Time         `gorm:"not null;type:date;index" json:"start_date"`
+	EndDate   time.Time         `gorm:"not null;type:date" json:"end_date"`
+	Dept      string            `gorm:"not null;default:''" json:"dept,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
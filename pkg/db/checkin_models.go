@@ -0,0 +1,52 @@
+package db
+
+import (
+	"time"
+
+	"attendance-workflow/pkg/geo"
+)
+
+type GeofenceKind string
+
+const (
+	GeofenceCampus    GeofenceKind = "campus"
+	GeofenceClassroom GeofenceKind = "classroom"
+)
+
+// Geofence is an area check-ins must come from. Campus fences apply to every
+// session, classroom fences only to the sessions that reference them.
+type Geofence struct {
+	ID           uint         `gorm:"primaryKey" json:"id"`
+	Name         string       `gorm:"not null" json:"name"`
+	Kind         GeofenceKind `gorm:"type:varchar(20);not null;default:campus;index" json:"kind"`
+	Polygon      geo.Polygon  `gorm:"type:text;serializer:json;not null" json:"polygon"`
+	RadiusMeters float64      `gorm:"not null;default:0" json:"radius_meters"` // tolerance around the polygon
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// Device binds a student account to the device identifier it checks in from
+type Device struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+	DeviceID   string     `gorm:"not null;index" json:"device_id"`
+	Label      string     `json:"label,omitempty"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewAccepted ReviewStatus = "accepted"
+	ReviewRejected ReviewStatus = "rejected"
+)
+
+// Reasons a check-in is held for review
+const (
+	FlagOutsideGeofence = "outside_geofence"
+	FlagNoLocation      = "location_missing"
+	FlagNewDevice       = "new_device"
+	FlagSharedDevice    = "shared_device"
+)
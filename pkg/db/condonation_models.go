@@ -0,0 +1,82 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+type CondonationCategory string
+
+const (
+	CondonationMedical CondonationCategory = "medical"
+	CondonationSports  CondonationCategory = "sports"
+	CondonationOther   CondonationCategory = "other"
+)
+
+type CondonationStatus string
+
+const (
+	CondonationPending   CondonationStatus = "pending"
+	CondonationApproved  CondonationStatus = "approved"
+	CondonationRejected  CondonationStatus = "rejected"
+	CondonationCancelled CondonationStatus = "cancelled"
+)
+
+// Condonation asks to excuse part of a student's attendance shortfall in a
+// course for a term. It moves through the roles of ApprovalPath one Step at
+// a time; once approved, GrantedSessions count as attended in eligibility
+// without touching the attendance records.
+type Condonation struct {
+	ID                uint                  `gorm:"primaryKey" json:"id"`
+	StudentID         uint                  `gorm:"not null;index" json:"student_id"`
+	Student           User                  `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+	CourseID          uint                  `gorm:"not null;index" json:"course_id"`
+	Course            Course                `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	TermID            *uint                 `gorm:"index" json:"term_id,omitempty"`
+	Term              *AcademicTerm         `gorm:"foreignKey:TermID" json:"term,omitempty"`
+	Category          CondonationCategory   `gorm:"type:varchar(20);not null" json:"category"`
+	Reason            string                `gorm:"not null;type:text" json:"reason"`
+	RequestedSessions int                   `gorm:"not null" json:"requested_sessions"`
+	GrantedSessions   int                   `gorm:"default:0" json:"granted_sessions"`
+	Status            CondonationStatus     `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	ApprovalPath      string                `gorm:"not null" json:"approval_path"` // comma separated roles, fixed when submitted
+	Step              int                   `gorm:"default:0" json:"step"`         // index of the role the request waits for
+	Documents         []File                `gorm:"many2many:condonation_documents" json:"documents,omitempty"`
+	Approvals         []CondonationApproval `json:"approvals,omitempty"`
+	CreatedAt         time.Time             `json:"created_at"`
+	UpdatedAt         time.Time             `json:"updated_at"`
+}
+
+// Approvers returns the roles of the approval path in order
+func (c *Condonation) Approvers() []UserRole {
+	var roles []UserRole
+	for _, role := range strings.Split(c.ApprovalPath, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, UserRole(role))
+		}
+	}
+	return roles
+}
+
+// PendingRole is the role whose decision the request waits for, empty once
+// it is decided
+func (c *Condonation) PendingRole() UserRole {
+	roles := c.Approvers()
+	if c.Status != CondonationPending || c.Step >= len(roles) {
+		return ""
+	}
+	return roles[c.Step]
+}
+
+// CondonationApproval is one step's decision on a condonation
+type CondonationApproval struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	CondonationID uint      `gorm:"not null;index" json:"condonation_id"`
+	Step          int       `gorm:"not null" json:"step"`
+	Role          UserRole  `gorm:"not null" json:"role"`
+	ApproverID    uint      `gorm:"not null" json:"approver_id"`
+	Approver      User      `gorm:"foreignKey:ApproverID" json:"approver,omitempty"`
+	Approved      bool      `json:"approved"`
+	Remarks       string    `gorm:"type:text" json:"remarks,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
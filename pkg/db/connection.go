@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -50,12 +51,58 @@ func Connect() error {
 }
 
 func AutoMigrate() error {
-	return DB.AutoMigrate(
+	if err := DB.AutoMigrate(
 		&User{},
 		&LeaveRequest{},
+		&LeaveWorkflow{},
+		&LeaveApproval{},
+		&LeaveTransition{},
+		&LeaveQuota{},
+		&Course{},
+		&Enrollment{},
+		&Geofence{},
+		&TimetableEntry{},
+		&ClassSession{},
 		&Attendance{},
 		&Notification{},
 		&AnalyticsSummary{},
 		&EmailNotification{},
-	)
+		&File{},
+		&AcademicTerm{},
+		&Holiday{},
+		&WeeklyOff{},
+		&SpecialWorkingDay{},
+		&CalendarEvent{},
+		&Device{},
+		&Card{},
+		&Terminal{},
+		&Punch{},
+		&SyncReceipt{},
+		&AttendanceHistory{},
+		&AttendanceCorrection{},
+		&EligibilityRule{},
+		&EligibilityStatus{},
+		&Condonation{},
+		&CondonationApproval{},
+		&Hostel{},
+		&HostelResident{},
+		&GatePass{},
+		&RollCall{},
+		&RollCallEntry{},
+	); err != nil {
+		return err
+	}
+
+	if err := migrateAttendanceStatus(); err != nil {
+		return err
+	}
+	if err := migrateDailyAttendanceIndex(); err != nil {
+		return err
+	}
+	return migrateLeaveStatus()
+}
+
+// IsDuplicateKey reports whether err is a unique constraint violation
+func IsDuplicateKey(err error) bool {
+	return errors.Is(postgres.Dialector{}.Translate(err), gorm.ErrDuplicatedKey)
 }
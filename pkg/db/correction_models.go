@@ -0,0 +1,40 @@
+package db
+
+import (
+	"time"
+)
+
+type CorrectionStatus string
+
+const (
+	CorrectionPending   CorrectionStatus = "pending"
+	CorrectionApproved  CorrectionStatus = "approved"
+	CorrectionRejected  CorrectionStatus = "rejected"
+	CorrectionCancelled CorrectionStatus = "cancelled"
+)
+
+// AttendanceCorrection asks for a change to locked attendance. It targets an
+// existing record, or the student, date and session of a record that was
+// never marked. The change is applied when an HOD or admin approves it.
+type AttendanceCorrection struct {
+	ID              uint             `gorm:"primaryKey" json:"id"`
+	AttendanceID    *uint            `gorm:"index" json:"attendance_id,omitempty"`
+	StudentID       uint             `gorm:"not null;index" json:"student_id"`
+	Student         User             `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+	SessionID       *uint            `json:"session_id,omitempty"`
+	Date            time.Time        `gorm:"not null;type:date" json:"date"`
+	CurrentStatus   AttendanceStatus `gorm:"type:varchar(20)" json:"current_status,omitempty"` // when raised, empty if unmarked
+	RequestedStatus AttendanceStatus `gorm:"type:varchar(20);not null" json:"requested_status"`
+	Reason          string           `gorm:"not null;type:text" json:"reason"`
+	EvidenceFileID  *uint            `json:"evidence_file_id,omitempty"`
+	Evidence        *File            `gorm:"foreignKey:EvidenceFileID" json:"evidence,omitempty"`
+	RequestedBy     uint             `gorm:"not null;index" json:"requested_by"`
+	Requester       User             `gorm:"foreignKey:RequestedBy" json:"requester,omitempty"`
+	Status          CorrectionStatus `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	ReviewedBy      *uint            `json:"reviewed_by,omitempty"`
+	Reviewer        *User            `gorm:"foreignKey:ReviewedBy" json:"reviewer,omitempty"`
+	ReviewRemarks   string           `gorm:"type:text" json:"review_remarks,omitempty"`
+	ReviewedAt      *time.Time       `json:"reviewed_at,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"time"
+)
+
+// Course is a subject offered by a department
+type Course struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Code      string    `gorm:"uniqueIndex;not null" json:"code"`
+	Name      string    `gorm:"not null" json:"name"`
+	Dept      string    `gorm:"index" json:"dept,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Enrollment places a student in a section of a course
+type Enrollment struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CourseID  uint      `gorm:"not null;uniqueIndex:idx_enrollment_course_student" json:"course_id"`
+	Course    Course    `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	StudentID uint      `gorm:"not null;uniqueIndex:idx_enrollment_course_student;index" json:"student_id"`
+	Student   User      `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+	Section   string    `gorm:"not null;index" json:"section"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ClassSession is a single lecture of a course section taken by a faculty
+// member in a period of a given day
+type ClassSession struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CourseID  uint      `gorm:"not null;uniqueIndex:idx_session_slot" json:"course_id"`
+	Course    Course    `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	Section   string    `gorm:"not null;uniqueIndex:idx_session_slot" json:"section"`
+	Date      time.Time `gorm:"not null;type:date;uniqueIndex:idx_session_slot;index" json:"date"`
+	Period    int       `gorm:"not null;uniqueIndex:idx_session_slot" json:"period"`
+	StartTime string    `json:"start_time,omitempty"` // HH:MM
+	EndTime   string    `json:"end_time,omitempty"`   // HH:MM
+	// GraceMinutes overrides LATE_GRACE_PERIOD for this session
+	GraceMinutes *int `json:"grace_minutes,omitempty"`
+	FacultyID    uint `gorm:"not null;index" json:"faculty_id"`
+	Faculty      User `gorm:"foreignKey:FacultyID" json:"faculty,omitempty"`
+	// GeofenceID is the classroom fence; campus fences apply when it is nil
+	GeofenceID *uint     `json:"geofence_id,omitempty"`
+	Geofence   *Geofence `gorm:"foreignKey:GeofenceID" json:"geofence,omitempty"`
+	// QR check-in window, opened and closed by the faculty
+	CheckinOpenedAt *time.Time `json:"checkin_opened_at,omitempty"`
+	CheckinClosedAt *time.Time `json:"checkin_closed_at,omitempty"`
+	CheckinSecret   string     `json:"-"` // per-opening key for check-in tokens
+	// TimetableEntryID is the weekly slot the session was generated from
+	TimetableEntryID *uint         `gorm:"index" json:"timetable_entry_id,omitempty"`
+	Room             string        `json:"room,omitempty"`
+	Status           SessionStatus `gorm:"type:varchar(20);not null;default:scheduled;index" json:"status"`
+	// OriginalFacultyID is set when a substitute takes the session
+	OriginalFacultyID *uint     `json:"original_faculty_id,omitempty"`
+	Remarks           string    `json:"remarks,omitempty"` // substitution or cancellation note
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// CheckinOpen reports whether students can currently check in
+func (s *ClassSession) CheckinOpen() bool {
+	return s.CheckinOpenedAt != nil && s.CheckinClosedAt == nil
+}
+
+func (Enrollment) TableName() string {
+	return "enrollments"
+}
+
+func (ClassSession) TableName() string {
+	return "class_sessions"
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"time"
+)
+
+// EligibilityRule sets the minimum attendance needed to sit a course's
+// exams. A rule with CourseID applies to that course, one with only Dept to
+// every course of the program's students, and ELIGIBILITY_MIN_PERCENT
+// applies when neither exists. Below WarningPercentage students are warned
+// before they fall short.
+type EligibilityRule struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	Dept              string    `gorm:"not null;default:'';index" json:"dept,omitempty"`
+	CourseID          *uint     `gorm:"index" json:"course_id,omitempty"`
+	Course            *Course   `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	MinPercentage     float64   `gorm:"not null" json:"min_percentage"`
+	WarningPercentage float64   `gorm:"not null" json:"warning_percentage"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type EligibilityBand string
+
+const (
+	BandEligible EligibilityBand = "eligible"
+	BandWarning  EligibilityBand = "warning"  // above the minimum, below the warning line
+	BandCritical EligibilityBand = "critical" // below the minimum
+)
+
+// EligibilityStatus remembers the band a student was last seen in for a
+// course, so alerts go out only when the band changes
+type EligibilityStatus struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	StudentID  uint            `gorm:"not null;uniqueIndex:idx_eligibility_student_course" json:"student_id"`
+	CourseID   uint            `gorm:"not null;uniqueIndex:idx_eligibility_student_course" json:"course_id"`
+	Band       EligibilityBand `gorm:"type:varchar(20);not null" json:"band"`
+	Percentage float64         `json:"percentage"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
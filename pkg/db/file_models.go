@@ -0,0 +1,30 @@
+package db
+
+import (
+	"time"
+)
+
+type FileKind string
+
+const (
+	FileKindProfilePhoto FileKind = "profile_photo"
+	FileKindDocument     FileKind = "document"
+)
+
+// File represents an uploaded file kept in the configured storage backend
+type File struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	OwnerID      uint      `gorm:"not null;index" json:"owner_id"`
+	Owner        User      `gorm:"foreignKey:OwnerID" json:"-"`
+	Kind         FileKind  `gorm:"not null;index" json:"kind"`
+	FileName     string    `gorm:"not null" json:"file_name"`
+	ContentType  string    `gorm:"not null" json:"content_type"`
+	Size         int64     `json:"size"`
+	StorageKey   string    `gorm:"not null;uniqueIndex" json:"-"`
+	ThumbnailKey string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (File) TableName() string {
+	return "files"
+}
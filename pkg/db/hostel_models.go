@@ -0,0 +1,104 @@
+package db
+
+import (
+	"time"
+)
+
+type Hostel struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"uniqueIndex;not null" json:"name"`
+	WardenID  *uint     `gorm:"index" json:"warden_id,omitempty"`
+	Warden    *User     `gorm:"foreignKey:WardenID" json:"warden,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HostelResident places a student in a hostel. Guardians are not users, so
+// their contact details are kept here for alerts.
+type HostelResident struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	HostelID      uint      `gorm:"not null;index" json:"hostel_id"`
+	StudentID     uint      `gorm:"not null;uniqueIndex" json:"student_id"`
+	Student       User      `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+	Block         string    `gorm:"index" json:"block,omitempty"`
+	Room          string    `json:"room,omitempty"`
+	GuardianName  string    `json:"guardian_name,omitempty"`
+	GuardianPhone string    `json:"guardian_phone,omitempty"`
+	GuardianEmail string    `json:"guardian_email,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type GatePassStatus string
+
+const (
+	GatePassPending   GatePassStatus = "pending"
+	GatePassApproved  GatePassStatus = "approved"
+	GatePassRejected  GatePassStatus = "rejected"
+	GatePassCancelled GatePassStatus = "cancelled"
+)
+
+// GatePass lets a resident stay out of the hostel between OutAt and
+// ExpectedReturnAt. The warden records the actual return.
+type GatePass struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	StudentID        uint           `gorm:"not null;index" json:"student_id"`
+	Student          User           `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+	Destination      string         `json:"destination,omitempty"`
+	Reason           string         `gorm:"not null;type:text" json:"reason"`
+	OutAt            time.Time      `gorm:"not null;index" json:"out_at"`
+	ExpectedReturnAt time.Time      `gorm:"not null;index" json:"expected_return_at"`
+	ReturnedAt       *time.Time     `json:"returned_at,omitempty"`
+	Status           GatePassStatus `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	ApprovedBy       *uint          `json:"approved_by,omitempty"`
+	Approver         *User          `gorm:"foreignKey:ApprovedBy" json:"approver,omitempty"`
+	Remarks          string         `json:"remarks,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// Covers reports whether the pass accounts for the student being out at t
+func (p *GatePass) Covers(t time.Time) bool {
+	return p.Status == GatePassApproved && !p.OutAt.After(t) && !p.ExpectedReturnAt.Before(t) &&
+		(p.ReturnedAt == nil || p.ReturnedAt.After(t))
+}
+
+type RollCallStatus string
+
+const (
+	RollCallPresent   RollCallStatus = "present"
+	RollCallAbsent    RollCallStatus = "absent" // unaccounted for
+	RollCallOutOnPass RollCallStatus = "out_on_pass"
+	RollCallOnLeave   RollCallStatus = "on_leave"
+)
+
+// RollCall is the nightly headcount of a hostel, or of one of its blocks.
+// It is separate from class attendance and never touches Attendance.
+type RollCall struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	HostelID  uint            `gorm:"not null;uniqueIndex:idx_roll_call_night" json:"hostel_id"`
+	Hostel    Hostel          `gorm:"foreignKey:HostelID" json:"hostel,omitempty"`
+	Block     string          `gorm:"not null;default:'';uniqueIndex:idx_roll_call_night" json:"block,omitempty"` // empty for the whole hostel
+	Date      time.Time       `gorm:"not null;type:date;uniqueIndex:idx_roll_call_night" json:"date"`
+	TakenBy   uint            `gorm:"not null" json:"taken_by"`
+	ClosedAt  *time.Time      `json:"closed_at,omitempty"`
+	Entries   []RollCallEntry `json:"entries,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// RollCallEntry is one resident's status in a roll call, empty until marked
+// or reconciled with a leave or gate pass
+type RollCallEntry struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	RollCallID uint           `gorm:"not null;uniqueIndex:idx_roll_call_student" json:"roll_call_id"`
+	StudentID  uint           `gorm:"not null;uniqueIndex:idx_roll_call_student;index" json:"student_id"`
+	Student    User           `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+	Status     RollCallStatus `gorm:"type:varchar(20);index" json:"status"`
+	LeaveID    *uint          `json:"leave_id,omitempty"`
+	GatePassID *uint          `json:"gate_pass_id,omitempty"`
+	MarkedBy   uint           `json:"marked_by,omitempty"`
+	Remarks    string         `json:"remarks,omitempty"`
+	AlertedAt  *time.Time     `json:"alerted_at,omitempty"` // when warden and guardian were told the resident is unaccounted for
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
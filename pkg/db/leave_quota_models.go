@@ -0,0 +1,28 @@
+package db
+
+import (
+	"time"
+)
+
+// LeaveQuotaPolicy decides what happens to a leave that needs more days
+// than the student has left
+type LeaveQuotaPolicy string
+
+const (
+	QuotaReject LeaveQuotaPolicy = "reject" // the leave cannot be submitted
+	QuotaFlag   LeaveQuotaPolicy = "flag"   // submitted, marked for the approvers
+)
+
+// LeaveQuota caps the working days of a leave type a student can take per
+// academic term. A quota with Dept applies to the program's students and
+// overrides the institution's (Dept empty); leave types without a quota
+// are unlimited.
+type LeaveQuota struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	Dept      string           `gorm:"not null;default:'';uniqueIndex:idx_leave_quota_dept_type" json:"dept,omitempty"`
+	LeaveType LeaveType        `gorm:"not null;uniqueIndex:idx_leave_quota_dept_type" json:"leave_type"`
+	Days      int              `gorm:"not null" json:"days"`
+	OnExceed  LeaveQuotaPolicy `gorm:"type:varchar(10);not null;default:reject" json:"on_exceed"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
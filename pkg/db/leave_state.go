@@ -0,0 +1,180 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// leaveTransitions lists the statuses each status can move to. Rejected,
+// withdrawn, cancelled and expired leaves are final.
+var leaveTransitions = map[LeaveStatus][]LeaveStatus{
+	StatusDraft:                 {StatusSubmitted, StatusWithdrawn},
+	StatusSubmitted:             {StatusInReview, StatusApproved, StatusRejected, StatusWithdrawn, StatusExpired},
+	StatusInReview:              {StatusApproved, StatusRejected, StatusWithdrawn, StatusExpired},
+	StatusApproved:              {StatusCancellationRequested, StatusCancelled},
+	StatusCancellationRequested: {StatusApproved, StatusCancelled},
+}
+
+// ErrLeaveHistoryImmutable is returned when code tries to change a leave's
+// transition history
+var ErrLeaveHistoryImmutable = errors.New("leave transition history is append-only")
+
+// TransitionError reports a move the leave state machine does not allow
+type TransitionError struct {
+	From LeaveStatus
+	To   LeaveStatus
+}
+
+func (e *TransitionError) Error() string {
+	if e.From == "" {
+		return fmt.Sprintf("a leave cannot start as %s", e.To)
+	}
+	return fmt.Sprintf("a leave cannot move from %s to %s", e.From, e.To)
+}
+
+// CanMove reports whether a leave can move from one status to another
+func CanMove(from, to LeaveStatus) bool {
+	for _, next := range leaveTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// LeaveTransition is an append-only record of a leave changing status,
+// written by the LeaveRequest hooks below and deleted only with its leave
+type LeaveTransition struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	LeaveID   uint        `gorm:"not null;index" json:"leave_id"`
+	From      LeaveStatus `gorm:"type:varchar(30)" json:"from,omitempty"` // empty when the leave is created
+	To        LeaveStatus `gorm:"type:varchar(30);not null" json:"to"`
+	ActorID   *uint       `gorm:"index" json:"actor_id,omitempty"` // nil for scheduled jobs
+	Actor     *User       `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Reason    string      `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt time.Time   `gorm:"index" json:"created_at"`
+}
+
+func (t *LeaveTransition) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLeaveHistoryImmutable
+}
+
+func (t *LeaveTransition) BeforeDelete(tx *gorm.DB) error {
+	return ErrLeaveHistoryImmutable
+}
+
+// MoveTo changes the leave's status on behalf of actorID, 0 for the system.
+// Staying in the same status is not a transition and is always allowed.
+func (l *LeaveRequest) MoveTo(to LeaveStatus, actorID uint, reason string) error {
+	if to != l.Status && !CanMove(l.Status, to) {
+		return &TransitionError{From: l.Status, To: to}
+	}
+	l.Status = to
+	l.transitionActor = actorID
+	l.transitionReason = reason
+	return nil
+}
+
+// AwaitingDecision reports whether the leave is submitted and not decided
+func (l *LeaveRequest) AwaitingDecision() bool {
+	return l.Status == StatusSubmitted || l.Status == StatusInReview
+}
+
+func (l *LeaveRequest) AfterFind(tx *gorm.DB) error {
+	l.loadedStatus = l.Status
+	return nil
+}
+
+// BeforeCreate only lets leaves start as drafts or submitted
+func (l *LeaveRequest) BeforeCreate(tx *gorm.DB) error {
+	if l.Status != "" && l.Status != StatusDraft && l.Status != StatusSubmitted {
+		return &TransitionError{From: "", To: l.Status}
+	}
+	return nil
+}
+
+func (l *LeaveRequest) AfterCreate(tx *gorm.DB) error {
+	return l.logTransition(tx)
+}
+
+// BeforeUpdate rejects saving a loaded leave in a status it cannot reach.
+// Column-map updates, used by migrations, are not checked.
+func (l *LeaveRequest) BeforeUpdate(tx *gorm.DB) error {
+	if _, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		return nil
+	}
+	if l.Status != l.loadedStatus && !CanMove(l.loadedStatus, l.Status) {
+		return &TransitionError{From: l.loadedStatus, To: l.Status}
+	}
+	return nil
+}
+
+func (l *LeaveRequest) AfterUpdate(tx *gorm.DB) error {
+	if l.Status == l.loadedStatus {
+		return nil
+	}
+	return l.logTransition(tx)
+}
+
+// AfterDelete removes the history of a deleted leave with it, the only way
+// transitions are ever deleted
+func (l *LeaveRequest) AfterDelete(tx *gorm.DB) error {
+	if l.ID == 0 {
+		return nil
+	}
+	return tx.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Where("leave_id = ?", l.ID).Delete(&LeaveTransition{}).Error
+}
+
+func (l *LeaveRequest) logTransition(tx *gorm.DB) error {
+	entry := LeaveTransition{
+		LeaveID: l.ID,
+		From:    l.loadedStatus,
+		To:      l.Status,
+		Reason:  l.transitionReason,
+	}
+	if l.transitionActor != 0 {
+		actor := l.transitionActor
+		entry.ActorID = &actor
+	} else if l.loadedStatus == "" {
+		// The student creates their own leave
+		entry.ActorID = &l.StudentID
+	}
+	if err := tx.Session(&gorm.Session{NewDB: true}).Create(&entry).Error; err != nil {
+		return err
+	}
+	l.loadedStatus = l.Status
+	l.transitionActor, l.transitionReason = 0, ""
+	return nil
+}
+
+// migrateLeaveStatus moves leaves from the old pending status into the
+// state machine and puts those submitted before approval paths existed on
+// the default path
+func migrateLeaveStatus() error {
+	reviewed := DB.Model(&LeaveApproval{}).Select("leave_id")
+	if err := DB.Model(&LeaveRequest{}).
+		Where("status = 'pending' AND id IN (?)", reviewed).
+		Update("status", StatusInReview).Error; err != nil {
+		return err
+	}
+	if err := DB.Model(&LeaveRequest{}).
+		Where("status = 'pending'").
+		Update("status", StatusSubmitted).Error; err != nil {
+		return err
+	}
+
+	path := DefaultLeaveApprovalPath()
+	var first []UserRole
+	if stages := ApprovalStages(path); len(stages) > 0 {
+		first = stages[0]
+	}
+	leave := LeaveRequest{ApprovalPath: path}
+	leave.SetAwaiting(first)
+	return DB.Model(&LeaveRequest{}).
+		Where("status IN ? AND approval_path = ''", LeaveAwaitingDecision).
+		Updates(map[string]interface{}{"approval_path": path, "pending_roles": leave.PendingRoles}).Error
+}
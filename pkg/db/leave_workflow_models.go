@@ -0,0 +1,99 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"attendance-workflow/pkg/config"
+)
+
+// LeaveWorkflow sets who approves the leaves it matches. Empty criteria
+// match any leave. The matching workflow with the highest Priority applies,
+// and LEAVE_APPROVAL_PATH applies when none matches.
+type LeaveWorkflow struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Name           string    `gorm:"not null" json:"name"`
+	LeaveType      LeaveType `gorm:"not null;default:''" json:"leave_type,omitempty"`
+	MinDays        int       `gorm:"default:0" json:"min_days,omitempty"` // leaves of at least this many working days
+	Dept           string    `gorm:"not null;default:''" json:"dept,omitempty"`
+	HostelResident *bool     `json:"hostel_resident,omitempty"` // only hostel residents, or only day scholars
+	Priority       int       `gorm:"default:0" json:"priority"`
+	ApprovalPath   string    `gorm:"not null" json:"approval_path"` // see ApprovalStages
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Matches reports whether the workflow applies to a leave of a student of
+// dept, who lives in a hostel or not
+func (w *LeaveWorkflow) Matches(leave *LeaveRequest, dept string, resident bool) bool {
+	return (w.LeaveType == "" || w.LeaveType == leave.LeaveType) &&
+		leave.Days >= w.MinDays &&
+		(w.Dept == "" || w.Dept == dept) &&
+		(w.HostelResident == nil || *w.HostelResident == resident)
+}
+
+// LeaveApproval is the decision of one role at one stage of a leave
+type LeaveApproval struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	LeaveID    uint      `gorm:"not null;index" json:"leave_id"`
+	Stage      int       `gorm:"not null" json:"stage"`
+	Role       UserRole  `gorm:"not null" json:"role"` // the step decided, admins may decide any
+	ApproverID uint      `gorm:"not null" json:"approver_id"`
+	Approver   User      `gorm:"foreignKey:ApproverID" json:"approver,omitempty"`
+	Approved   bool      `json:"approved"`
+	Remarks    string    `gorm:"type:text" json:"remarks,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ApprovalStages splits an approval path such as "faculty,warden+hod" into
+// its stages. Stages are separated by commas and run in order; the roles of
+// a stage, joined by "+", decide in parallel and must all approve.
+func ApprovalStages(path string) [][]UserRole {
+	var stages [][]UserRole
+	for _, stage := range strings.Split(path, ",") {
+		var roles []UserRole
+		for _, role := range strings.Split(stage, "+") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, UserRole(role))
+			}
+		}
+		if len(roles) > 0 {
+			stages = append(stages, roles)
+		}
+	}
+	return stages
+}
+
+// DefaultLeaveApprovalPath is LEAVE_APPROVAL_PATH, or admin when it is empty
+func DefaultLeaveApprovalPath() string {
+	path := strings.Join(config.AppConfig.Attendance.LeaveApprovalPath, ",")
+	if len(ApprovalStages(path)) == 0 {
+		return string(RoleAdmin)
+	}
+	return path
+}
+
+// Stages returns the stages of the leave's approval path
+func (l *LeaveRequest) Stages() [][]UserRole {
+	return ApprovalStages(l.ApprovalPath)
+}
+
+// Awaiting returns the roles of the current stage that have not decided yet
+func (l *LeaveRequest) Awaiting() []UserRole {
+	var roles []UserRole
+	for _, role := range strings.Split(l.PendingRoles, ",") {
+		if role != "" {
+			roles = append(roles, UserRole(role))
+		}
+	}
+	return roles
+}
+
+// SetAwaiting records the roles the leave waits for
+func (l *LeaveRequest) SetAwaiting(roles []UserRole) {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	l.PendingRoles = strings.Join(names, ",")
+}
@@ -10,18 +10,21 @@ const (
 	RoleAdmin   UserRole = "admin"
 	RoleFaculty UserRole = "faculty"
 	RoleWarden  UserRole = "warden"
+	RoleHOD     UserRole = "hod" // head of department, approves corrections for their dept
 	RoleStudent UserRole = "student"
 )
 
 type User struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"not null" json:"name"`
-	Email     string    `gorm:"uniqueIndex;not null" json:"email"`
-	Password  string    `gorm:"not null" json:"-"`
-	Role      UserRole  `gorm:"not null" json:"role"`
-	Dept      string    `json:"dept,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Email       string    `gorm:"uniqueIndex;not null" json:"email"`
+	Password    string    `gorm:"not null" json:"-"`
+	Role        UserRole  `gorm:"not null" json:"role"`
+	Dept        string    `json:"dept,omitempty"`
+	PhotoFileID *uint     `json:"photo_file_id,omitempty"`
+	Timezone    string    `gorm:"type:varchar(64)" json:"timezone,omitempty"` // IANA display zone, the institution's when empty
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 
 	LeaveRequests []LeaveRequest `gorm:"foreignKey:StudentID" json:"-"`
 	Attendance    []Attendance   `gorm:"foreignKey:StudentID" json:"-"`
@@ -29,12 +32,27 @@ type User struct {
 
 type LeaveStatus string
 
+// Leave statuses, see leaveTransitions for how a leave moves between them
 const (
-	StatusPending  LeaveStatus = "pending"
-	StatusApproved LeaveStatus = "approved"
-	StatusRejected LeaveStatus = "rejected"
+	StatusDraft     LeaveStatus = "draft"     // saved by the student, not submitted
+	StatusSubmitted LeaveStatus = "submitted" // waiting for the first decision
+	StatusInReview  LeaveStatus = "in_review" // some steps approved, others pending
+	StatusApproved  LeaveStatus = "approved"
+	StatusRejected  LeaveStatus = "rejected"
+	StatusWithdrawn LeaveStatus = "withdrawn" // by the student before a decision
+	// StatusCancellationRequested is an approved leave the student asked to
+	// cancel; it stays in effect until an approver confirms
+	StatusCancellationRequested LeaveStatus = "cancellation_requested"
+	StatusCancelled             LeaveStatus = "cancelled"
+	StatusExpired               LeaveStatus = "expired" // not decided in time
 )
 
+// LeaveInEffect lists the statuses of leaves that excuse the student
+var LeaveInEffect = []LeaveStatus{StatusApproved, StatusCancellationRequested}
+
+// LeaveAwaitingDecision lists the statuses of submitted leaves not yet decided
+var LeaveAwaitingDecision = []LeaveStatus{StatusSubmitted, StatusInReview}
+
 type LeaveType string
 
 const (
@@ -52,25 +70,81 @@ type LeaveRequest struct {
 	Reason     string      `gorm:"not null;type:text" json:"reason"`
 	StartDate  time.Time   `gorm:"not null" json:"start_date"`
 	EndDate    time.Time   `gorm:"not null" json:"end_date"`
-	Status     LeaveStatus `gorm:"default:pending" json:"status"`
+	Days       int         `gorm:"default:0" json:"days"` // working days covered
+	Status     LeaveStatus `gorm:"default:submitted" json:"status"`
 	ApprovedBy *uint       `gorm:"index" json:"approved_by,omitempty"`
 	Approver   *User       `gorm:"foreignKey:ApprovedBy" json:"approver,omitempty"`
 	Remarks    string      `json:"remarks,omitempty"`
-	CreatedAt  time.Time   `json:"created_at"`
-	UpdatedAt  time.Time   `json:"updated_at"`
+	// ExceedsBalance marks a leave submitted with more days than left in
+	// its type's quota
+	ExceedsBalance bool `gorm:"default:false" json:"exceeds_balance,omitempty"`
+	// CoversEvents marks a leave spanning an exam or mandatory event
+	CoversEvents bool `gorm:"default:false" json:"covers_events,omitempty"`
+	// CancellationReason is why the student asked to cancel an approved leave
+	CancellationReason string `gorm:"type:text" json:"cancellation_reason,omitempty"`
+	// ApprovalPath is fixed when the leave is submitted, Stage indexes its
+	// current stage and PendingRoles lists the roles of that stage still to
+	// decide
+	WorkflowID   *uint           `gorm:"index" json:"workflow_id,omitempty"`
+	ApprovalPath string          `gorm:"not null;default:''" json:"approval_path"`
+	Stage        int             `gorm:"default:0" json:"stage"`
+	PendingRoles string          `gorm:"not null;default:''" json:"pending_roles"`
+	Approvals    []LeaveApproval `gorm:"foreignKey:LeaveID" json:"approvals,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+
+	loadedStatus     LeaveStatus
+	transitionActor  uint
+	transitionReason string
 }
 
+// Attendance is either a daily record (SessionID nil) or the record of a
+// single class session, in which case the daily status is derived from the
+// student's sessions on that date. A student has at most one record per
+// session, and one daily record per date (idx_attendance_daily).
 type Attendance struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	StudentID uint      `gorm:"not null;index" json:"student_id"`
-	Student   User      `gorm:"foreignKey:StudentID" json:"student,omitempty"`
-	Date      time.Time `gorm:"not null;type:date" json:"date"`
-	Present   bool      `gorm:"default:false" json:"present"`
-	MarkedBy  uint      `json:"marked_by,omitempty"`
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	StudentID uint             `gorm:"not null;index;uniqueIndex:idx_attendance_session_student" json:"student_id"`
+	Student   User             `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+	SessionID *uint            `gorm:"uniqueIndex:idx_attendance_session_student" json:"session_id,omitempty"`
+	Session   *ClassSession    `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+	Date      time.Time        `gorm:"not null;type:date;index" json:"date"`
+	Status    AttendanceStatus `gorm:"type:varchar(20);not null;default:absent;index" json:"status"`
+	Present   bool             `gorm:"default:false" json:"present"` // kept in sync with Status for older clients
+	Source    AttendanceSource `gorm:"type:varchar(20);not null;default:manual" json:"source"`
+	LeaveID   *uint            `gorm:"index" json:"leave_id,omitempty"` // approved leave that set this record to on_leave
+	MarkedBy  uint             `json:"marked_by,omitempty"`
+	// CheckedInAt is when the student arrived: their own check-in, a punch
+	// or the time given by the faculty
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+	MinutesLate int        `gorm:"not null;default:0" json:"minutes_late,omitempty"` // after the session start, when late
+	// Check-in evidence; flagged check-ins stay absent until reviewed
+	DeviceID     string       `json:"device_id,omitempty"`
+	Latitude     *float64     `json:"latitude,omitempty"`
+	Longitude    *float64     `json:"longitude,omitempty"`
+	Flags        string       `json:"flags,omitempty"` // comma-separated Flag* reasons
+	ReviewStatus ReviewStatus `gorm:"type:varchar(20);index" json:"review_status,omitempty"`
+	ReviewedBy   *uint        `json:"reviewed_by,omitempty"`
+	// Version increases on every update, for offline sync conflict detection
+	Version   int       `gorm:"not null;default:1" json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Audit state for the history hooks, see attendance_history.go
+	loadedStatus AttendanceStatus
+	auditActor   uint
+	auditReason  string
 }
 
+type AttendanceSource string
+
+const (
+	SourceManual  AttendanceSource = "manual"
+	SourceLeave   AttendanceSource = "leave"
+	SourceCheckin AttendanceSource = "checkin"
+	SourcePunch   AttendanceSource = "punch"
+)
+
 type Notification struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	UserID    uint      `gorm:"not null;index" json:"user_id"`
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"time"
+)
+
+type CardKind string
+
+const (
+	CardRFID      CardKind = "rfid"
+	CardBiometric CardKind = "biometric"
+)
+
+// Card maps the code a terminal reports (RFID card number or fingerprint
+// enrollment number) to a user
+type Card struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Code      string    `gorm:"not null;uniqueIndex" json:"code"`
+	Kind      CardKind  `gorm:"type:varchar(20);not null;default:rfid" json:"kind"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	User      User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TerminalMode string
+
+const (
+	// TerminalDaily punches mark daily attendance (hostel and campus gates)
+	TerminalDaily TerminalMode = "daily"
+	// TerminalSession punches mark the class session running at punch time (labs)
+	TerminalSession TerminalMode = "session"
+)
+
+// Terminal is a punch device and the rule its punches follow
+type Terminal struct {
+	ID        uint         `gorm:"primaryKey" json:"id"`
+	Code      string       `gorm:"not null;uniqueIndex" json:"code"`
+	Name      string       `json:"name,omitempty"`
+	Location  string       `json:"location,omitempty"`
+	Mode      TerminalMode `gorm:"type:varchar(20);not null;default:daily" json:"mode"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type PunchResult string
+
+const (
+	PunchApplied   PunchResult = "applied"   // turned into attendance
+	PunchIgnored   PunchResult = "ignored"   // valid but no rule applied (e.g. no session running)
+	PunchDuplicate PunchResult = "duplicate" // repeat within the dedup window
+	PunchUnmapped  PunchResult = "unmapped"  // unknown card or terminal
+)
+
+// Punch is a raw punch record as received from a terminal
+type Punch struct {
+	ID           uint        `gorm:"primaryKey" json:"id"`
+	CardCode     string      `gorm:"not null;uniqueIndex:idx_punch_identity" json:"card_code"`
+	TerminalCode string      `gorm:"not null;uniqueIndex:idx_punch_identity" json:"terminal_code"`
+	PunchedAt    time.Time   `gorm:"not null;uniqueIndex:idx_punch_identity;index" json:"punched_at"`
+	UserID       *uint       `gorm:"index" json:"user_id,omitempty"`
+	Result       PunchResult `gorm:"type:varchar(20);not null;index" json:"result"`
+	Detail       string      `json:"detail,omitempty"`
+	AttendanceID *uint       `json:"attendance_id,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
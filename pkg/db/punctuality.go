@@ -0,0 +1,52 @@
+package db
+
+import (
+	"time"
+
+	"attendance-workflow/pkg/clock"
+	"attendance-workflow/pkg/config"
+)
+
+// Grace is how long after its start a student may arrive without being late
+func (s *ClassSession) Grace() time.Duration {
+	if s.GraceMinutes != nil {
+		return time.Duration(*s.GraceMinutes) * time.Minute
+	}
+	return config.AppConfig.Attendance.LateGrace
+}
+
+// StartsAt is the start of the session in the institution's timezone, zero
+// when the session has no time slot
+func (s *ClassSession) StartsAt() time.Time {
+	t, err := time.Parse("15:04", s.StartTime)
+	if err != nil {
+		return time.Time{}
+	}
+	return clock.At(s.Date, t.Hour(), t.Minute())
+}
+
+// RecordArrival stores when the student arrived and marks them present, or
+// late when they arrived after the session's grace period. Sessions without
+// a time slot never make anyone late.
+func (a *Attendance) RecordArrival(session *ClassSession, at time.Time) {
+	a.CheckedInAt = &at
+	a.MinutesLate = 0
+	a.SetStatus(AttendancePresent)
+
+	start := session.StartsAt()
+	if start.IsZero() || !at.After(start.Add(session.Grace())) {
+		return
+	}
+	a.MinutesLate = int(at.Sub(start).Minutes())
+	a.SetStatus(AttendanceLate)
+}
+
+// LatePenalty is the weight lost to the "N lates count as one absence" rule
+// (LATES_PER_ABSENCE): every N lates turn one late into an absence
+func LatePenalty(lates int) float64 {
+	per := config.AppConfig.Attendance.LatesPerAbsence
+	if per <= 0 {
+		return 0
+	}
+	return float64(int64(lates)/per) * (AttendanceLate.Weight() - AttendanceAbsent.Weight())
+}
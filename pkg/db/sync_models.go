@@ -0,0 +1,18 @@
+package db
+
+import (
+	"time"
+)
+
+// SyncReceipt remembers the outcome of an offline record so that replaying a
+// sync batch returns the same result instead of applying it again
+type SyncReceipt struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null;uniqueIndex:idx_sync_receipt_client" json:"user_id"`
+	ClientID     string    `gorm:"not null;uniqueIndex:idx_sync_receipt_client" json:"client_id"`
+	Result       string    `gorm:"type:varchar(20);not null" json:"result"`
+	Error        string    `json:"error,omitempty"`
+	AttendanceID *uint     `json:"attendance_id,omitempty"`
+	RecordedAt   time.Time `json:"recorded_at"` // client clock when the record was taken
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"time"
+)
+
+// TimetableEntry is a weekly slot of a course section, valid for part or all
+// of a term. Class sessions are generated from it for each working day.
+type TimetableEntry struct {
+	ID           uint         `gorm:"primaryKey" json:"id"`
+	TermID       uint         `gorm:"not null;index" json:"term_id"`
+	Term         AcademicTerm `gorm:"foreignKey:TermID" json:"term,omitempty"`
+	CourseID     uint         `gorm:"not null;index" json:"course_id"`
+	Course       Course       `gorm:"foreignKey:CourseID" json:"course,omitempty"`
+	Section      string       `gorm:"not null;index" json:"section"`
+	FacultyID    uint         `gorm:"not null;index" json:"faculty_id"`
+	Faculty      User         `gorm:"foreignKey:FacultyID" json:"faculty,omitempty"`
+	Room         string       `json:"room,omitempty"`
+	GeofenceID   *uint        `json:"geofence_id,omitempty"`
+	Weekday      int          `gorm:"not null" json:"weekday"` // 0 = Sunday
+	Period       int          `gorm:"not null" json:"period"`
+	StartTime    string       `json:"start_time,omitempty"` // HH:MM
+	EndTime      string       `json:"end_time,omitempty"`   // HH:MM
+	GraceMinutes *int         `json:"grace_minutes,omitempty"`
+	ValidFrom    time.Time    `gorm:"not null;type:date" json:"valid_from"`
+	ValidTo      time.Time    `gorm:"not null;type:date" json:"valid_to"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+type SessionStatus string
+
+const (
+	SessionScheduled SessionStatus = "scheduled"
+	SessionCancelled SessionStatus = "cancelled"
+)
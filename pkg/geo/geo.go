@@ -0,0 +1,91 @@
+// Package geo provides the small amount of geometry needed for geofenced
+// check-ins: great-circle distances and point-in-polygon tests on WGS84
+// coordinates.
+package geo
+
+import "math"
+
+const earthRadiusMeters = 6371000
+
+// Point is a WGS84 coordinate in degrees
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Valid reports whether p is a possible coordinate
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Distance returns the great-circle distance between a and b in meters
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Polygon is a closed ring of points; the last point connects to the first
+type Polygon []Point
+
+// Contains reports whether p lies inside the polygon (ray casting)
+func (poly Polygon) Contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// DistanceTo returns how far p is from the polygon in meters, 0 when inside.
+// A single point polygon is treated as a circle centre.
+func (poly Polygon) DistanceTo(p Point) float64 {
+	switch len(poly) {
+	case 0:
+		return math.Inf(1)
+	case 1:
+		return Distance(poly[0], p)
+	}
+	if len(poly) >= 3 && poly.Contains(p) {
+		return 0
+	}
+
+	best := math.Inf(1)
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		best = math.Min(best, segmentDistance(p, a, b))
+	}
+	return best
+}
+
+// Within reports whether p is inside the polygon or within radius meters of it
+func (poly Polygon) Within(p Point, radius float64) bool {
+	return poly.DistanceTo(p) <= radius
+}
+
+// segmentDistance projects onto a local equirectangular plane around p,
+// which is accurate at the scale of a campus
+func segmentDistance(p, a, b Point) float64 {
+	scale := math.Cos(radians(p.Lat))
+	ax, ay := radians(a.Lng-p.Lng)*scale, radians(a.Lat-p.Lat)
+	bx, by := radians(b.Lng-p.Lng)*scale, radians(b.Lat-p.Lat)
+
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/length))
+	}
+	x, y := ax+t*dx, ay+t*dy
+	return math.Sqrt(x*x+y*y) * earthRadiusMeters
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"attendance-workflow/pkg/config"
+)
+
+// fakeS3 is an in-memory stand-in for a MinIO-style server. It checks the
+// Signature Version 4 of every request the way the server side would.
+type fakeS3 struct {
+	bucket    string
+	accessKey string
+	secretKey string
+	region    string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+	fail    bool
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{
+		bucket:    "attendance",
+		accessKey: "minio",
+		secretKey: "minio-secret",
+		region:    "us-east-1",
+		objects:   make(map[string][]byte),
+		types:     make(map[string]string),
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := f.verify(r, body); err != nil {
+		http.Error(w, "SignatureDoesNotMatch: "+err.Error(), http.StatusForbidden)
+		return
+	}
+	if f.fail {
+		http.Error(w, "InternalError", http.StatusInternalServerError)
+		return
+	}
+
+	prefix := "/" + f.bucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		// S3 answers 204 whether or not the key existed
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// verify recomputes the request signature from what the server received
+func (f *fakeS3) verify(r *http.Request, body []byte) error {
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != sha256Hex(body) {
+		return fmt.Errorf("payload hash %s does not match the body", got)
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if len(amzDate) < 8 {
+		return fmt.Errorf("missing X-Amz-Date")
+	}
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", amzDate[:8], f.region)
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", r.Host, sha256Hex(body), amzDate),
+		"host;x-amz-content-sha256;x-amz-date",
+		sha256Hex(body),
+	}, "\n")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+f.secretKey), amzDate[:8])
+	key = hmacSHA256(key, f.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	want := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=%x",
+		f.accessKey, scope, hmacSHA256(key, stringToSign))
+	if got := r.Header.Get("Authorization"); got != want {
+		return fmt.Errorf("authorization %q, want %q", got, want)
+	}
+	return nil
+}
+
+func newTestS3Storage(t *testing.T, f *fakeS3, server *httptest.Server) *S3Storage {
+	t.Helper()
+	s, err := NewS3Storage(config.S3Config{
+		Endpoint:  server.URL,
+		Region:    f.region,
+		Bucket:    f.bucket,
+		AccessKey: f.accessKey,
+		SecretKey: f.secretKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestS3StoragePutGetDelete(t *testing.T) {
+	f, server := newFakeS3(t)
+	s := newTestS3Storage(t, f, server)
+	ctx := context.Background()
+
+	for _, key := range []string{"photos/1/a.jpg", "documents/2/medical certificate (1).pdf"} {
+		data := []byte("content of " + key)
+		if err := s.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+		if got := f.types[key]; got != "image/jpeg" {
+			t.Errorf("Put(%q) stored content type %q", key, got)
+		}
+
+		r, err := s.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		got, _ := io.ReadAll(r)
+		r.Close()
+		if !bytes.Equal(got, data) {
+			t.Errorf("Get(%q) = %q, want %q", key, got, data)
+		}
+
+		if err := s.Delete(ctx, key); err != nil {
+			t.Fatalf("Delete(%q): %v", key, err)
+		}
+		if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) after Delete = %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+func TestS3StorageMissingKey(t *testing.T) {
+	f, server := newFakeS3(t)
+	s := newTestS3Storage(t, f, server)
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, "photos/missing.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a missing key = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "photos/missing.jpg"); err != nil {
+		t.Errorf("Delete of a missing key = %v, want nil", err)
+	}
+}
+
+func TestS3StorageErrors(t *testing.T) {
+	f, server := newFakeS3(t)
+	ctx := context.Background()
+
+	s, err := NewS3Storage(config.S3Config{
+		Endpoint:  server.URL,
+		Region:    f.region,
+		Bucket:    f.bucket,
+		AccessKey: f.accessKey,
+		SecretKey: "not-the-secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "a", strings.NewReader("x"), 1, ""); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put with a wrong secret = %v, want a 403 error", err)
+	}
+
+	s = newTestS3Storage(t, f, server)
+	f.fail = true
+	if err := s.Put(ctx, "a", strings.NewReader("x"), 1, ""); err == nil {
+		t.Error("Put when the server fails = nil, want an error")
+	}
+	if _, err := s.Get(ctx, "a"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Get when the server fails = %v, want a server error", err)
+	}
+	if err := s.Delete(ctx, "a"); err == nil {
+		t.Error("Delete when the server fails = nil, want an error")
+	}
+}
+
+func TestNewS3StorageRequiresCredentials(t *testing.T) {
+	if _, err := NewS3Storage(config.S3Config{Bucket: "b"}); err == nil {
+		t.Error("NewS3Storage without keys = nil error")
+	}
+	if _, err := NewS3Storage(config.S3Config{AccessKey: "a", SecretKey: "s"}); err == nil {
+		t.Error("NewS3Storage without a bucket = nil error")
+	}
+}
@@ -0,0 +1,398 @@
+// Package xlsx reads and writes single-sheet Office Open XML workbooks as
+// plain string grids. It covers spreadsheets exchanged as registers, not
+// formulas or styling.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the MIME type of an xlsx workbook
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// ErrNoSheet is returned for workbooks without a readable worksheet
+var ErrNoSheet = errors.New("workbook has no worksheet")
+
+// ErrTooLarge is returned for workbooks past the limits of Read
+var ErrTooLarge = errors.New("workbook is too large")
+
+// MaxRows and MaxColumns are the size of the largest worksheet xlsx allows
+const (
+	MaxRows    = 1 << 20
+	MaxColumns = 1 << 14
+)
+
+const (
+	// maxPartSize bounds the decompressed size of each part Read opens
+	maxPartSize = 32 << 20
+	// maxCells bounds the cells Read returns, blanks inside rows included
+	maxCells = 4 << 20
+)
+
+const (
+	nsMain = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	nsRel  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+var staticParts = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`,
+	"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`,
+}
+
+// Write writes rows as the only sheet of a workbook. Cells are stored as
+// inline strings so that values such as dates are kept as typed.
+func Write(w io.Writer, sheetName string, rows [][]string) error {
+	zw := zip.NewWriter(w)
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, staticParts[name]); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/workbook.xml")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="%s" xmlns:r="%s"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		nsMain, nsRel, escape(sheetName))
+
+	f, err = zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="%s"><sheetData>`, nsMain)
+	for i, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, value := range row {
+			if value == "" {
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, columnName(j), i+1, escape(value))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	if _, err := io.WriteString(f, b.String()); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+type xmlRels struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xmlWorkbook struct {
+	Sheets []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xmlText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xmlText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type xmlCell struct {
+	R  string  `xml:"r,attr"`
+	T  string  `xml:"t,attr"`
+	V  string  `xml:"v"`
+	IS xmlText `xml:"is"`
+}
+
+// Limits bounds the rows and columns Read accepts values in; blank cells
+// past them are skipped. Zero means the largest worksheet xlsx allows.
+type Limits struct {
+	Rows    int
+	Columns int
+}
+
+// Read returns the cells of the first worksheet as strings, with blank cells
+// as empty strings and trailing blanks trimmed from each row. A cell past
+// limits fails with ErrTooLarge, as does a part that decompresses past
+// maxPartSize.
+func Read(r io.ReaderAt, size int64, limits Limits) ([][]string, error) {
+	if limits.Rows <= 0 || limits.Rows > MaxRows {
+		limits.Rows = MaxRows
+	}
+	if limits.Columns <= 0 || limits.Columns > MaxColumns {
+		limits.Columns = MaxColumns
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []xmlText `xml:"si"`
+		}
+		if err := decode(f, &sst); err != nil {
+			return nil, err
+		}
+		for _, item := range sst.Items {
+			shared = append(shared, item.String())
+		}
+	}
+
+	f, ok := files[firstSheet(files)]
+	if !ok {
+		return nil, ErrNoSheet
+	}
+	return readSheet(f, shared, limits)
+}
+
+// readSheet streams the rows of a worksheet so that limits are enforced
+// before the grid grows to hold a cell
+func readSheet(f *zip.File, shared []string, limits Limits) ([][]string, error) {
+	p, err := openPart(f)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	var rows [][]string
+	var cells []string
+	total := 0
+	index, ordinal, col := 0, 0, 0
+	d := xml.NewDecoder(p)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, p.check(err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				index = ordinal
+				ordinal++
+				for _, a := range t.Attr {
+					if a.Name.Local != "r" {
+						continue
+					}
+					n, err := strconv.Atoi(a.Value)
+					if err != nil {
+						return nil, fmt.Errorf("invalid row number %q", a.Value)
+					}
+					if n > 0 {
+						index = n - 1
+					}
+				}
+				cells, col = nil, 0
+			case "c":
+				var cell xmlCell
+				if err := d.DecodeElement(&cell, &t); err != nil {
+					return nil, p.check(err)
+				}
+				position := col
+				col++
+				if cell.R != "" {
+					if parsed, ok := columnIndex(cell.R); ok {
+						position = parsed
+					}
+				}
+				value := cell.V
+				switch cell.T {
+				case "s":
+					n, err := strconv.Atoi(cell.V)
+					if err != nil || n < 0 || n >= len(shared) {
+						return nil, fmt.Errorf("cell %s: invalid shared string", cell.R)
+					}
+					value = shared[n]
+				case "inlineStr":
+					value = cell.IS.String()
+				}
+				// Blank cells past the limits are often only formatting
+				if index >= limits.Rows || position >= limits.Columns {
+					if strings.TrimSpace(value) == "" {
+						continue
+					}
+					if index >= limits.Rows {
+						return nil, fmt.Errorf("%w: row %d is past row %d", ErrTooLarge, index+1, limits.Rows)
+					}
+					return nil, fmt.Errorf("%w: column %s is past column %s", ErrTooLarge, columnName(position), columnName(limits.Columns-1))
+				}
+				for len(cells) <= position {
+					cells = append(cells, "")
+				}
+				cells[position] = value
+			}
+		case xml.EndElement:
+			if t.Name.Local != "row" {
+				continue
+			}
+			for len(cells) > 0 && strings.TrimSpace(cells[len(cells)-1]) == "" {
+				cells = cells[:len(cells)-1]
+			}
+			if len(cells) == 0 && index >= limits.Rows {
+				continue
+			}
+			total += len(cells)
+			if total > maxCells {
+				return nil, fmt.Errorf("%w: more than %d cells", ErrTooLarge, maxCells)
+			}
+			for len(rows) <= index {
+				rows = append(rows, nil)
+			}
+			rows[index] = cells
+		}
+	}
+}
+
+// firstSheet resolves the part name of the workbook's first sheet
+func firstSheet(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+	wb, ok := files["xl/workbook.xml"]
+	if !ok {
+		return fallback
+	}
+	var workbook xmlWorkbook
+	if err := decode(wb, &workbook); err != nil || len(workbook.Sheets) == 0 {
+		return fallback
+	}
+	relsFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return fallback
+	}
+	var rels xmlRels
+	if err := decode(relsFile, &rels); err != nil {
+		return fallback
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].RID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/")
+			}
+			return path.Join("xl", rel.Target)
+		}
+	}
+	return fallback
+}
+
+func decode(f *zip.File, v interface{}) error {
+	p, err := openPart(f)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	return p.check(xml.NewDecoder(p).Decode(v))
+}
+
+// part reads a zip entry of the workbook, stopping at maxPartSize however
+// small the entry claims to be
+type part struct {
+	io.LimitedReader
+	io.Closer
+	name string
+}
+
+func openPart(f *zip.File) (*part, error) {
+	if f.UncompressedSize64 > maxPartSize {
+		return nil, fmt.Errorf("%w: %s is larger than %d MB", ErrTooLarge, f.Name, maxPartSize>>20)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &part{LimitedReader: io.LimitedReader{R: rc, N: maxPartSize}, Closer: rc, name: f.Name}, nil
+}
+
+// check reports a read that ran into the size limit as ErrTooLarge
+func (p *part) check(err error) error {
+	if err != nil && p.N <= 0 {
+		return fmt.Errorf("%w: %s is larger than %d MB", ErrTooLarge, p.name, maxPartSize>>20)
+	}
+	return err
+}
+
+// columnName converts a zero-based column index to its letters: 0 is A,
+// 26 is AA
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// columnIndex extracts the zero-based column of a cell reference like "C7".
+// Columns past MaxColumns are returned as MaxColumns.
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		if col <= MaxColumns {
+			col = col*26 + int(ch-'A'+1)
+		}
+		n++
+	}
+	if col > MaxColumns {
+		col = MaxColumns + 1
+	}
+	return col - 1, n > 0
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
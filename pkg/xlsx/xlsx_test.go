@@ -0,0 +1,222 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// workbook zips parts into an xlsx file. The sheet is the default first
+// sheet unless the parts include a workbook that says otherwise.
+func workbook(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sheet(rows string) map[string]string {
+	return map[string]string{
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?><worksheet xmlns="` + nsMain + `"><sheetData>` + rows + `</sheetData></worksheet>`,
+	}
+}
+
+func read(data []byte, limits Limits) ([][]string, error) {
+	return Read(bytes.NewReader(data), int64(len(data)), limits)
+}
+
+func TestWriteRead(t *testing.T) {
+	rows := [][]string{
+		{"student_id", "name", "2026-03-10 P1"},
+		{"7", "Ana <O'Neil> & co", "P"},
+		{"8", "", "A"},
+		nil,
+		{"9", "  spaced  "},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, "CS101 A", rows); err != nil {
+		t.Fatal(err)
+	}
+	got, err := read(buf.Bytes(), Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("Read after Write = %q, want %q", got, rows)
+	}
+}
+
+func TestReadCells(t *testing.T) {
+	parts := sheet(`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>` +
+		`<row r="3"><c r="B3"><v>42</v></c><c r="D3" t="inlineStr"><is><t>late</t></is></c><c r="E3" t="inlineStr"><is><t> </t></is></c></row>` +
+		`<row><c><v>x</v></c><c><v>y</v></c></row>`)
+	parts["xl/sharedStrings.xml"] = `<sst xmlns="` + nsMain + `"><si><t>student_id</t></si><si><r><t>rich </t></r><r><t>text</t></r></si></sst>`
+
+	got, err := read(workbook(t, parts), Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"student_id", "", "rich text"},
+		nil,
+		{"x", "y"},
+	}
+	// The third row element has no r, so it takes its position in the sheet
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read = %q, want %q", got, want)
+	}
+}
+
+func TestReadFirstSheet(t *testing.T) {
+	parts := sheet(`<row r="1"><c r="A1"><v>wrong</v></c></row>`)
+	parts["xl/workbook.xml"] = `<workbook xmlns="` + nsMain + `" xmlns:r="` + nsRel + `"><sheets><sheet name="Register" sheetId="1" r:id="rId5"/></sheets></workbook>`
+	parts["xl/_rels/workbook.xml.rels"] = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId5" Target="worksheets/register.xml"/></Relationships>`
+	parts["xl/worksheets/register.xml"] = `<worksheet xmlns="` + nsMain + `"><sheetData><row r="1"><c r="A1"><v>right</v></c></row></sheetData></worksheet>`
+
+	got, err := read(workbook(t, parts), Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, [][]string{{"right"}}) {
+		t.Errorf("Read = %q, want the sheet named by the workbook", got)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts map[string]string
+		want  error
+	}{
+		{"no sheet", map[string]string{"xl/workbook.xml": `<workbook/>`}, ErrNoSheet},
+		{"shared string out of range", sheet(`<row r="1"><c r="A1" t="s"><v>3</v></c></row>`), nil},
+		{"bad row number", sheet(`<row r="one"><c r="A1"><v>1</v></c></row>`), nil},
+		{"truncated xml", map[string]string{"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1"><v>1`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := read(workbook(t, tt.parts), Limits{})
+			if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Errorf("Read = %v, want an error", err)
+			}
+		})
+	}
+	if _, err := read([]byte("student_id,name\n"), Limits{}); err == nil {
+		t.Error("Read of a CSV file = nil error")
+	}
+}
+
+func TestReadLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   string
+		limits Limits
+		want   [][]string
+		err    bool
+	}{
+		{"within limits", `<row r="2"><c r="C2"><v>P</v></c></row>`, Limits{Rows: 2, Columns: 3}, [][]string{nil, {"", "", "P"}}, false},
+		{"row past the limit", `<row r="3"><c r="A3"><v>P</v></c></row>`, Limits{Rows: 2, Columns: 3}, nil, true},
+		{"column past the limit", `<row r="1"><c r="D1"><v>P</v></c></row>`, Limits{Rows: 2, Columns: 3}, nil, true},
+		{"unnumbered cell past the limit", `<row r="1"><c><v>1</v></c><c><v>2</v></c><c><v>3</v></c><c><v>4</v></c></row>`, Limits{Rows: 2, Columns: 3}, nil, true},
+		{"blank cells past the limits", `<row r="1"><c r="A1"><v>x</v></c><c r="Z1" s="1"/></row><row r="40" s="2"><c r="A40" s="1"/></row>`, Limits{Rows: 2, Columns: 3}, [][]string{{"x"}}, false},
+		{"row past the xlsx limit", `<row r="20000000"><c r="A20000000"><v>1</v></c></row>`, Limits{}, nil, true},
+		{"column past the xlsx limit", `<row r="1"><c r="XFE1"><v>1</v></c></row>`, Limits{}, nil, true},
+		{"column reference that overflows", `<row r="1"><c r="ZZZZZZZZZZZZZZZZZZZZ1"><v>1</v></c></row>`, Limits{}, nil, true},
+		{"huge blank row", `<row r="20000000" spans="1:16384"><c r="XFD20000000"/></row>`, Limits{}, nil, false},
+		{"last xlsx cell", `<row r="1"><c r="XFD1"><v>1</v></c></row>`, Limits{}, [][]string{append(make([]string, MaxColumns-1), "1")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := read(workbook(t, sheet(tt.rows)), tt.limits)
+			if tt.err {
+				if !errors.Is(err, ErrTooLarge) {
+					t.Errorf("Read = %v, want ErrTooLarge", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read = %d rows, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+// The last row xlsx allows is accepted, at the cost of an empty slot for
+// each row before it
+func TestReadLastRow(t *testing.T) {
+	got, err := read(workbook(t, sheet(`<row r="1048576"><c r="A1048576"><v>1</v></c></row>`)), Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != MaxRows || got[MaxRows-1][0] != "1" {
+		t.Errorf("Read = %d rows, want %d", len(got), MaxRows)
+	}
+}
+
+func TestReadDecompressionLimit(t *testing.T) {
+	// Blanks between rows compress to almost nothing
+	rows := `<row r="1"><c r="A1"><v>1</v></c></row>` + strings.Repeat(" ", maxPartSize) + `<row r="2"><c r="A2"><v>2</v></c></row>`
+	data := workbook(t, sheet(rows))
+	if len(data) > 1<<20 {
+		t.Fatalf("test workbook is %d bytes", len(data))
+	}
+	if _, err := read(data, Limits{}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Read of a part past the size limit = %v, want ErrTooLarge", err)
+	}
+
+	// A zip entry can claim to be smaller than it decompresses to; reading
+	// stops at the claimed size
+	var content bytes.Buffer
+	fw, _ := flate.NewWriter(&content, flate.BestCompression)
+	fw.Write([]byte(sheet(rows)["xl/worksheets/sheet1.xml"]))
+	fw.Close()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               "xl/worksheets/sheet1.xml",
+		Method:             zip.Deflate,
+		CompressedSize64:   uint64(content.Len()),
+		UncompressedSize64: 1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(content.Bytes())
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read(buf.Bytes(), Limits{}); err == nil {
+		t.Error("Read of a part that understates its size = nil error")
+	}
+}
+
+func TestColumns(t *testing.T) {
+	for i, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA", MaxColumns - 1: "XFD"} {
+		if got := columnName(i); got != name {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, name)
+		}
+		if got, ok := columnIndex(name + "12"); !ok || got != i {
+			t.Errorf("columnIndex(%s12) = %d, %v, want %d", name, got, ok, i)
+		}
+	}
+	if _, ok := columnIndex("12"); ok {
+		t.Error("columnIndex(12) found a column")
+	}
+}
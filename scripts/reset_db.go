@@ -24,9 +24,41 @@ func main() {
 	log.Println("Dropping all tables...")
 	if err := database.Migrator().DropTable(
 		&db.User{},
+		&db.LeaveTransition{},
+		&db.LeaveApproval{},
+		&db.LeaveWorkflow{},
+		&db.LeaveQuota{},
 		&db.LeaveRequest{},
 		&db.Attendance{},
+		&db.ClassSession{},
+		&db.TimetableEntry{},
+		&db.Geofence{},
+		&db.Device{},
+		&db.Punch{},
+		&db.SyncReceipt{},
+		&db.AttendanceHistory{},
+		&db.AttendanceCorrection{},
+		&db.EligibilityRule{},
+		&db.EligibilityStatus{},
+		&db.Condonation{},
+		&db.CondonationApproval{},
+		"condonation_documents",
+		&db.RollCallEntry{},
+		&db.RollCall{},
+		&db.GatePass{},
+		&db.HostelResident{},
+		&db.Hostel{},
+		&db.Card{},
+		&db.Terminal{},
+		&db.Enrollment{},
+		&db.Course{},
 		&db.Notification{},
+		&db.File{},
+		&db.AcademicTerm{},
+		&db.Holiday{},
+		&db.WeeklyOff{},
+		&db.SpecialWorkingDay{},
+		&db.CalendarEvent{},
 	); err != nil {
 		log.Fatalf("Failed to drop tables: %v", err)
 	}
@@ -62,6 +94,14 @@ func main() {
 		Dept:     "Computer Science",
 	}
 
+	hod := &db.User{
+		Name:     "hod",
+		Email:    "hod@college.edu",
+		Password: "$2a$10$DemoHashedPasswordFF", // Demo password: hod123
+		Role:     db.RoleHOD,
+		Dept:     "Computer Science",
+	}
+
 	students := []*db.User{
 		{
 			Name:     "stud1",
@@ -80,7 +120,7 @@ func main() {
 	}
 
 	// Create all users
-	users := []*db.User{admin, warden, faculty}
+	users := []*db.User{admin, warden, faculty, hod}
 	users = append(users, students...)
 
 	for _, user := range users {
@@ -97,7 +137,7 @@ func main() {
 			Reason:     "Medical appointment",
 			StartDate:  time.Now().AddDate(0, 0, 1),
 			EndDate:    time.Now().AddDate(0, 0, 2),
-			Status:     db.StatusApproved,
+			Status:     db.StatusSubmitted,
 			ApprovedBy: &warden.ID,
 		},
 		{
@@ -106,16 +146,27 @@ func main() {
 			Reason:    "Family function",
 			StartDate: time.Now().AddDate(0, 0, 5),
 			EndDate:   time.Now().AddDate(0, 0, 7),
-			Status:    db.StatusPending,
+			Status:    db.StatusSubmitted,
 		},
 	}
 
 	for _, leave := range leaveRequests {
+		leave.ApprovalPath = db.DefaultLeaveApprovalPath()
+		leave.SetAwaiting(leave.Stages()[0])
 		if err := database.Create(leave).Error; err != nil {
 			log.Fatalf("Failed to create leave request: %v", err)
 		}
 	}
 
+	// Leaves start submitted, the first one is then approved
+	leaveRequests[0].SetAwaiting(nil)
+	if err := leaveRequests[0].MoveTo(db.StatusApproved, warden.ID, ""); err != nil {
+		log.Fatalf("Failed to approve leave request: %v", err)
+	}
+	if err := database.Save(leaveRequests[0]).Error; err != nil {
+		log.Fatalf("Failed to approve leave request: %v", err)
+	}
+
 	// Create sample attendance records
 	startDate := time.Now().AddDate(0, 0, -10)
 	for _, student := range students {
@@ -124,9 +175,9 @@ func main() {
 			attendance := &db.Attendance{
 				StudentID: student.ID,
 				Date:      date,
-				Present:   i%2 == 0, // Alternate between present and absent
 				MarkedBy:  faculty.ID,
 			}
+			attendance.SetStatus(db.StatusFromPresent(i%2 == 0)) // Alternate between present and absent
 			if err := database.Create(attendance).Error; err != nil {
 				log.Fatalf("Failed to create attendance record: %v", err)
 			}